@@ -0,0 +1,108 @@
+// Команда import загружает песни из CSV/NDJSON файла напрямую в базу данных.
+//
+//	go run ./cmd/import -file songs.csv
+//	go run ./cmd/import -file songs.ndjson -format ndjson
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"mikromolekula2002/music_library_ver1.0/internal/config"
+	"mikromolekula2002/music_library_ver1.0/internal/openapi"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
+	"mikromolekula2002/music_library_ver1.0/internal/service"
+	"mikromolekula2002/music_library_ver1.0/pkg/logger"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+)
+
+func main() {
+	file := flag.String("file", "", "path to the import file")
+	format := flag.String("format", "", "file format: csv or ndjson (detected from the extension when omitted)")
+	configPath := flag.String("config", ".", "directory with the .env file")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *format == "" {
+		switch filepath.Ext(*file) {
+		case ".csv":
+			*format = service.ImportFormatCSV
+		case ".ndjson", ".jsonl":
+			*format = service.ImportFormatNDJSON
+		default:
+			log.Fatal("cannot detect file format, use -format")
+		}
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Storage == "memory" {
+		log.Fatal("import requires postgres storage, STORAGE=memory is not persistent")
+	}
+
+	loger := logger.InitLogger(cfg.LoggerLevel, cfg.LoggerOut, cfg.LoggerFilePath, cfg.LoggerFormat)
+
+	songRepo, err := repository.NewRepository(cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+	if err != nil {
+		loger.Fatal("Database connection failed: ", err)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		loger.Fatal(err)
+	}
+	defer f.Close()
+
+	rows, err := service.ParseImport(f, *format)
+	if err != nil {
+		loger.Fatal("Failed to parse import file: ", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	songService, err := service.NewSongService(songRepo, loger, service.Options{
+		MusicAPIHost: cfg.MusicAPIHost,
+		MusicBaseURL: cfg.MusicBaseURL,
+		MusicAPI: openapi.ClientOptions{
+			Timeout:          cfg.MusicAPITimeout,
+			MaxRetries:       cfg.MusicAPIMaxRetries,
+			RetryBase:        cfg.MusicAPIRetryBase,
+			RetryMax:         cfg.MusicAPIRetryMax,
+			BreakerThreshold: cfg.MusicAPIBreakerThreshold,
+			BreakerCooldown:  cfg.MusicAPIBreakerCooldown,
+		},
+		DetailsProviders: cfg.DetailsProviderList(),
+		DetailsFile:      cfg.DetailsFile,
+		DetailsCache: service.DetailsCacheOptions{
+			Size:        cfg.MusicCacheSize,
+			TTL:         cfg.MusicCacheTTL,
+			NegativeTTL: cfg.MusicCacheNegativeTTL,
+		},
+		ImportConcurrency: cfg.ImportConcurrency,
+	})
+	if err != nil {
+		loger.Fatal("Service initialization failed: ", err)
+	}
+	report := songService.ImportSongs(service.WithActor(ctx, service.ActorImport), rows)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		loger.Fatal(err)
+	}
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -2,9 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	_ "mikromolekula2002/music_library_ver1.0/docs"
+	"mikromolekula2002/music_library_ver1.0/internal/auth"
 	"mikromolekula2002/music_library_ver1.0/internal/config"
+	"mikromolekula2002/music_library_ver1.0/internal/health"
+	"mikromolekula2002/music_library_ver1.0/internal/metrics"
+	"mikromolekula2002/music_library_ver1.0/internal/openapi"
+	"mikromolekula2002/music_library_ver1.0/internal/ratelimit"
 	"mikromolekula2002/music_library_ver1.0/internal/repository"
 	"mikromolekula2002/music_library_ver1.0/internal/router"
 	"mikromolekula2002/music_library_ver1.0/internal/service"
@@ -18,44 +25,149 @@ import (
 	"github.com/golang-migrate/migrate"
 )
 
+// migrationsPath - каталог миграций схемы, применяемых при запуске
+const migrationsPath = "migration"
+
 // @title Music Library API
 // @version 1.0
 // @description This is a RESTful API for managing a music library.
 
 // @host localhost:8080
 // @BasePath
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT as "Bearer <token>" with sub, role (reader, editor or admin) and exp claims
 func main() {
 	cfg, err := config.LoadConfig(".")
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	loger := logger.InitLogger(cfg.LoggerLevel, cfg.LoggerOut, cfg.LoggerFilePath)
+	loger := logger.InitLogger(cfg.LoggerLevel, cfg.LoggerOut, cfg.LoggerFilePath, cfg.LoggerFormat)
 	loger.Info("Starting the application...")
 
-	loger.Debug("Connecting to the database...")
-	songRepo, err := repository.NewRepository(cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
-	if err != nil {
-		loger.Fatal("Database connection failed: ", err)
+	var songStore repository.SongStore
+	var songRepo *repository.Repository
+	switch cfg.Storage {
+	case "memory":
+		loger.Warn("Using in-memory storage, data will be lost on restart")
+		songStore = repository.NewMemoryStore()
+	default:
+		loger.Debug("Connecting to the database...")
+		songRepo, err = repository.NewRepository(cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+		if err != nil {
+			loger.Fatal("Database connection failed: ", err)
+		}
+		loger.Debug("Connected to the database successfully.")
+
+		loger.Debug("Applying database migrations...")
+		if err := songRepo.ApplyMigrations(migrationsPath); err != nil {
+			if err == migrate.ErrNoChange {
+				loger.Debug("Nothing to update schema")
+			} else {
+				loger.Warn("Migration failed: ", err)
+			}
+		}
+		loger.Debug("Migrations applied successfully.")
+
+		songStore = songRepo
 	}
-	loger.Debug("Connected to the database successfully.")
 
-	loger.Debug("Applying database migrations...")
-	if err := songRepo.ApplyMigrations("migration"); err != nil {
-		if err == migrate.ErrNoChange {
-			loger.Debug("Nothing to update schema")
-		} else {
-			loger.Warn("Migration failed: ", err)
+	var appMetrics *metrics.Metrics
+	if !cfg.MetricsDisabled {
+		appMetrics = metrics.New()
+		songStore = repository.Instrument(songStore, appMetrics.ObserveStore)
+		if songRepo != nil {
+			appMetrics.RegisterDB(songRepo.DB())
 		}
 	}
-	loger.Debug("Migrations applied successfully.")
 
 	loger.Debug("Initializing services and router...")
-	songService := service.NewSongService(songRepo, loger, cfg.MusicAPIHost, cfg.MusicBaseURL)
-	songRouter := router.NewRouter(songService)
+	musicAPI := openapi.ClientOptions{
+		Timeout:          cfg.MusicAPITimeout,
+		MaxRetries:       cfg.MusicAPIMaxRetries,
+		RetryBase:        cfg.MusicAPIRetryBase,
+		RetryMax:         cfg.MusicAPIRetryMax,
+		BreakerThreshold: cfg.MusicAPIBreakerThreshold,
+		BreakerCooldown:  cfg.MusicAPIBreakerCooldown,
+	}
+	if appMetrics != nil {
+		musicAPI.OnAttempt = appMetrics.ObserveMusicInfo
+		musicAPI.OnStateChange = appMetrics.BreakerStateChanged
+	}
+	songService, err := service.NewSongService(songStore, loger, service.Options{
+		MusicAPIHost:     cfg.MusicAPIHost,
+		MusicBaseURL:     cfg.MusicBaseURL,
+		MusicAPI:         musicAPI,
+		DetailsProviders: cfg.DetailsProviderList(),
+		DetailsFile:      cfg.DetailsFile,
+		DetailsCache: service.DetailsCacheOptions{
+			Size:        cfg.MusicCacheSize,
+			TTL:         cfg.MusicCacheTTL,
+			NegativeTTL: cfg.MusicCacheNegativeTTL,
+		},
+		ImportConcurrency: cfg.ImportConcurrency,
+		Enrichment: service.EnrichmentOptions{
+			Workers:     cfg.EnrichmentWorkers,
+			MaxAttempts: cfg.EnrichmentMaxAttempts,
+			RetryBase:   cfg.EnrichmentRetryBase,
+			RetryMax:    cfg.EnrichmentRetryMax,
+		},
+		Trash: service.TrashOptions{
+			Retention:     cfg.TrashRetention,
+			PurgeInterval: cfg.TrashPurgeInterval,
+		},
+	})
+	if err != nil {
+		loger.Fatal("Service initialization failed: ", err)
+	}
+	if appMetrics != nil {
+		appMetrics.RegisterBreaker(songService.MusicInfoBreakerState)
+		appMetrics.RegisterLibrary(songService.GetLibraryStats, loger)
+	}
+
+	var authenticator *auth.Authenticator
+	if cfg.AuthDisabled {
+		loger.Warn("Authentication is disabled, every client has full access")
+	} else if authenticator, err = newAuthenticator(cfg); err != nil {
+		loger.Fatal("Authentication setup failed: ", err)
+	}
+
+	limiter, err := newLimiter(cfg, songRepo)
+	if err != nil {
+		loger.Fatal("Rate limiter setup failed: ", err)
+	}
+	if limiter == nil {
+		loger.Warn("Rate limiting is disabled")
+	}
+
+	checker, err := newChecker(cfg, songRepo, songService)
+	if err != nil {
+		loger.Fatal("Health checks setup failed: ", err)
+	}
+
+	songRouter := router.NewRouter(songService, authenticator, limiter, appMetrics, checker)
+	if err := songRouter.SetTrustedProxies(cfg.TrustedProxyList()); err != nil {
+		loger.Fatal("Invalid TRUSTED_PROXIES: ", err)
+	}
 	songRouter.SetRoutes(cfg.EnvType)
 	loger.Debug("Router initialized.")
 
+	// Фоновые задачи обогащения песен данными внешнего API и очистки корзины
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	waitWorkers := songService.RunEnrichmentWorkers(workersCtx)
+	waitPurger := songService.RunTrashPurger(workersCtx)
+	waitSweeper := func() {}
+	if limiter != nil {
+		waitSweeper = limiter.RunSweeper(workersCtx, loger)
+	}
+
 	// Создаем сервер с тайм-аутами
 	server := &http.Server{
 		Addr:    ":" + cfg.ServerPort,
@@ -77,6 +189,11 @@ func main() {
 	<-quit
 	loger.Warn("Shutting down server...")
 
+	// Сначала /readyz начинает отвечать 503, и за HEALTH_SHUTDOWN_DELAY балансировщик
+	// перестает направлять сюда запросы; затем сервер дорабатывает текущие и останавливается
+	checker.StartShutdown()
+	time.Sleep(cfg.HealthShutdownDelay)
+
 	// Контекст с тайм-аутом для graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -85,5 +202,89 @@ func main() {
 		loger.Errorf("Server forced to shutdown: %v", err)
 	}
 
+	stopWorkers()
+	waitWorkers()
+	waitPurger()
+	waitSweeper()
+
 	loger.Info("Server stopped gracefully")
 }
+
+func newAuthenticator(cfg config.Config) (*auth.Authenticator, error) {
+	keys, err := auth.ParseAPIKeys(cfg.AuthAPIKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := auth.Options{
+		APIKeys:    keys,
+		HMACSecret: []byte(cfg.AuthJWTHMACSecret),
+		Issuer:     cfg.AuthJWTIssuer,
+		Audience:   cfg.AuthJWTAudience,
+		Leeway:     cfg.AuthJWTLeeway,
+	}
+	if cfg.AuthJWTRSAPublicKeyFile != "" {
+		data, err := os.ReadFile(cfg.AuthJWTRSAPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if opts.RSAPublicKey, err = auth.ParseRSAPublicKey(data); err != nil {
+			return nil, err
+		}
+	}
+
+	return auth.NewAuthenticator(opts)
+}
+
+// newChecker собирает проверки готовности: соединение с базой и версию схемы
+// при STORAGE=postgres и, если включено, доступность music-info
+func newChecker(cfg config.Config, songRepo *repository.Repository, songService *service.MusicLibService) (*health.Checker, error) {
+	checker := health.NewChecker(cfg.HealthCheckTimeout)
+	if songRepo != nil {
+		expected, err := repository.LatestMigrationVersion(migrationsPath)
+		if err != nil {
+			return nil, err
+		}
+		checker.Add("database", songRepo.Ping)
+		checker.Add("migrations", health.MigrationCheck(songRepo.MigrationVersion, expected))
+	}
+	if cfg.HealthCheckMusicInfo {
+		checker.Add("music_info", songService.PingMusicInfo)
+	}
+
+	return checker, nil
+}
+
+// newLimiter создает ограничитель частоты запросов; nil - ограничение выключено
+func newLimiter(cfg config.Config, songRepo *repository.Repository) (*ratelimit.Limiter, error) {
+	var backend ratelimit.Backend
+	switch cfg.RateLimitBackend {
+	case "off":
+		return nil, nil
+	case "", "memory":
+		backend = ratelimit.NewMemoryBackend()
+	case "postgres":
+		if songRepo == nil {
+			return nil, errors.New("RATE_LIMIT_BACKEND=postgres requires STORAGE=postgres")
+		}
+		backend = songRepo.RateLimitStore()
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_BACKEND %q", cfg.RateLimitBackend)
+	}
+
+	limits := make(map[ratelimit.Class]ratelimit.Limit)
+	for class, value := range map[ratelimit.Class]string{
+		ratelimit.Read:     cfg.RateLimitRead,
+		ratelimit.Write:    cfg.RateLimitWrite,
+		ratelimit.Upstream: cfg.RateLimitUpstream,
+		ratelimit.Auth:     cfg.RateLimitAuth,
+	} {
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			return nil, err
+		}
+		limits[class] = limit
+	}
+
+	return ratelimit.NewLimiter(backend, limits), nil
+}
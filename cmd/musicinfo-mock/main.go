@@ -0,0 +1,217 @@
+// Команда musicinfo-mock - локальная замена внешнего API music-info
+// (apiAutoGenerated/MusicInfo/api/openapi.yaml) для разработки и интеграционных тестов.
+// Отдает GET /info по файлу с песнями и умеет имитировать сбои:
+//
+//	go run ./cmd/musicinfo-mock -addr :8006
+//	go run ./cmd/musicinfo-mock -fixtures songs.json -latency 200ms -error-rate 0.2 -date-format dotted
+//
+// Сценарий отдельного запроса можно задать заголовком X-Mock-Scenario:
+// ok, error, notfound, malformed или slow.
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+//go:embed fixtures.json
+var defaultFixtures []byte
+
+// songDetail повторяет схему SongDetail из openapi.yaml
+type songDetail struct {
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+type fixture struct {
+	Group string `json:"group"`
+	Song  string `json:"song"`
+	songDetail
+}
+
+// Сценарии ответа
+const (
+	scenarioOK        = "ok"
+	scenarioError     = "error"
+	scenarioNotFound  = "notfound"
+	scenarioMalformed = "malformed"
+	scenarioSlow      = "slow"
+)
+
+// Форматы даты выхода в ответе
+const (
+	dateAsIs   = "as-is"
+	dateISO    = "iso"
+	dateDotted = "dotted"
+	dateMixed  = "mixed"
+)
+
+type server struct {
+	songs map[string]songDetail
+
+	latency       time.Duration
+	jitter        time.Duration
+	slowLatency   time.Duration
+	errorRate     float64
+	notFoundRate  float64
+	malformedRate float64
+	dateFormat    string
+}
+
+func main() {
+	s := &server{}
+
+	addr := flag.String("addr", ":8006", "listen address")
+	fixtures := flag.String("fixtures", "", "JSON file with songs: [{group, song, releaseDate, text, link}]; built-in set when empty")
+	flag.DurationVar(&s.latency, "latency", 0, "delay before every response")
+	flag.DurationVar(&s.jitter, "jitter", 0, "random extra delay from 0 to jitter")
+	flag.DurationVar(&s.slowLatency, "slow-latency", 10*time.Second, "delay of the slow scenario")
+	flag.Float64Var(&s.errorRate, "error-rate", 0, "fraction of requests answered with 500")
+	flag.Float64Var(&s.notFoundRate, "notfound-rate", 0, "fraction of requests for known songs answered with 404")
+	flag.Float64Var(&s.malformedRate, "malformed-rate", 0, "fraction of requests answered with a malformed body")
+	flag.StringVar(&s.dateFormat, "date-format", dateAsIs, "releaseDate format: as-is (from fixtures), iso (YYYY-MM-DD), dotted (DD.MM.YYYY) or mixed (random)")
+	flag.Parse()
+
+	switch s.dateFormat {
+	case dateAsIs, dateISO, dateDotted, dateMixed:
+	default:
+		log.Fatalf("unknown -date-format %q", s.dateFormat)
+	}
+
+	data := defaultFixtures
+	if *fixtures != "" {
+		var err error
+		if data, err = os.ReadFile(*fixtures); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var list []fixture
+	if err := json.Unmarshal(data, &list); err != nil {
+		log.Fatalf("invalid fixtures: %v", err)
+	}
+
+	s.songs = make(map[string]songDetail, len(list))
+	for _, f := range list {
+		s.songs[songKey(f.Group, f.Song)] = f.songDetail
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info", s.info)
+
+	log.Printf("music-info mock with %d songs listening on %s", len(s.songs), *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func (s *server) info(w http.ResponseWriter, r *http.Request) {
+	group, song := r.URL.Query().Get("group"), r.URL.Query().Get("song")
+
+	scenario := r.Header.Get("X-Mock-Scenario")
+	if scenario == "" {
+		scenario = s.pickScenario()
+	}
+	log.Printf("GET /info group=%q song=%q scenario=%s", group, song, scenario)
+
+	delay := s.latency
+	if s.jitter > 0 {
+		delay += rand.N(s.jitter)
+	}
+	if scenario == scenarioSlow {
+		delay = s.slowLatency
+	}
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+		return
+	}
+
+	if group == "" || song == "" {
+		http.Error(w, "group and song are required", http.StatusBadRequest)
+		return
+	}
+
+	detail, ok := s.songs[songKey(group, song)]
+	switch {
+	case scenario == scenarioError:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	case scenario == scenarioNotFound || !ok:
+		http.NotFound(w, r)
+		return
+	case scenario == scenarioMalformed:
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(malformedBody(detail)))
+		return
+	}
+
+	detail.ReleaseDate = s.formatDate(detail.ReleaseDate)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+func (s *server) pickScenario() string {
+	p := rand.Float64()
+	switch {
+	case p < s.errorRate:
+		return scenarioError
+	case p < s.errorRate+s.notFoundRate:
+		return scenarioNotFound
+	case p < s.errorRate+s.notFoundRate+s.malformedRate:
+		return scenarioMalformed
+	}
+	return scenarioOK
+}
+
+// formatDate переводит дату фикстуры (YYYY-MM-DD или DD.MM.YYYY) в формат -date-format
+func (s *server) formatDate(date string) string {
+	format := s.dateFormat
+	if format == dateMixed {
+		format = []string{dateISO, dateDotted}[rand.IntN(2)]
+	}
+
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		if t, err = time.Parse("02.01.2006", date); err != nil {
+			return date
+		}
+	}
+
+	switch format {
+	case dateISO:
+		return t.Format("2006-01-02")
+	case dateDotted:
+		return t.Format("02.01.2006")
+	}
+	return date
+}
+
+// malformedBody возвращает одно из типичных повреждений ответа
+func malformedBody(detail songDetail) string {
+	switch rand.IntN(3) {
+	case 0:
+		// Оборванный JSON
+		body, _ := json.Marshal(detail)
+		return string(body[:len(body)/2])
+	case 1:
+		// Нет обязательного поля
+		body, _ := json.Marshal(map[string]string{"releaseDate": detail.ReleaseDate, "link": detail.Link})
+		return string(body)
+	default:
+		// Неверный тип поля
+		body, _ := json.Marshal(map[string]interface{}{"releaseDate": 2006, "text": detail.Text, "link": detail.Link})
+		return string(body)
+	}
+}
+
+func songKey(group, song string) string {
+	return strings.ToLower(strings.TrimSpace(group)) + "\x00" + strings.ToLower(strings.TrimSpace(song))
+}
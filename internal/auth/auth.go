@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Role - уровень доступа к API; каждая следующая роль включает права предыдущей
+type Role int
+
+const (
+	// RoleReader - только чтение
+	RoleReader Role = iota + 1
+	// RoleEditor - создание и изменение песен, групп и альбомов
+	RoleEditor
+	// RoleAdmin - удаление, корзина и журнал аудита
+	RoleAdmin
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleReader:
+		return "reader"
+	case RoleEditor:
+		return "editor"
+	case RoleAdmin:
+		return "admin"
+	}
+	return "unknown"
+}
+
+// ParseRole разбирает название роли: reader, editor или admin
+func ParseRole(s string) (Role, error) {
+	for _, role := range []Role{RoleReader, RoleEditor, RoleAdmin} {
+		if strings.EqualFold(strings.TrimSpace(s), role.String()) {
+			return role, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown role %q", s)
+}
+
+// Principal - аутентифицированный клиент API
+type Principal struct {
+	// Subject - имя клиента; записывается автором изменений в ревизии и журнал аудита
+	Subject string
+	Role    Role
+}
+
+// Allows сообщает, достаточно ли роли клиента для действия, требующего role
+func (p *Principal) Allows(role Role) bool {
+	return p.Role >= role
+}
+
+type principalKey struct{}
+
+// WithPrincipal возвращает контекст с аутентифицированным клиентом
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext возвращает аутентифицированного клиента из контекста
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+var (
+	// ErrNoCredentials - в запросе нет ни API-ключа, ни токена
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials - ключ неизвестен, либо токен не прошел проверку
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Options - способы аутентификации; должен быть задан хотя бы один
+type Options struct {
+	// APIKeys - статические ключи из заголовка X-API-Key и их владельцы
+	APIKeys map[string]Principal
+	// HMACSecret - секрет для проверки JWT с подписью HS256/HS384/HS512
+	HMACSecret []byte
+	// RSAPublicKey - ключ для проверки JWT с подписью RS256/RS384/RS512
+	RSAPublicKey *rsa.PublicKey
+	// Issuer и Audience, если заданы, должны совпадать с iss и aud токена
+	Issuer   string
+	Audience string
+	// Leeway - допустимое расхождение часов при проверке exp и nbf
+	Leeway time.Duration
+}
+
+// Authenticator проверяет API-ключи и JWT из заголовков запроса
+type Authenticator struct {
+	opts Options
+	// Ключи хранятся хешами, чтобы поиск не зависел по времени от совпадения префикса
+	keys map[[sha256.Size]byte]Principal
+	now  func() time.Time
+}
+
+func NewAuthenticator(opts Options) (*Authenticator, error) {
+	if len(opts.APIKeys) == 0 && len(opts.HMACSecret) == 0 && opts.RSAPublicKey == nil {
+		return nil, errors.New("auth: no API keys or JWT keys configured")
+	}
+
+	a := &Authenticator{
+		opts: opts,
+		keys: make(map[[sha256.Size]byte]Principal, len(opts.APIKeys)),
+		now:  time.Now,
+	}
+	for key, p := range opts.APIKeys {
+		if key == "" || p.Subject == "" || p.Role == 0 {
+			return nil, fmt.Errorf("auth: API key of %q needs a key, subject and role", p.Subject)
+		}
+		a.keys[sha256.Sum256([]byte(key))] = p
+	}
+
+	return a, nil
+}
+
+// Authenticate находит клиента по заголовку X-API-Key или Authorization: Bearer <JWT>
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		p, ok := a.keys[sha256.Sum256([]byte(key))]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+		}
+		return &p, nil
+	}
+
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, ErrNoCredentials
+	}
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, fmt.Errorf("%w: unsupported authorization scheme", ErrInvalidCredentials)
+	}
+
+	p, err := a.verifyJWT(strings.TrimSpace(token))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return p, nil
+}
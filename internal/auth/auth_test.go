@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseRole(t *testing.T) {
+	for s, want := range map[string]Role{"reader": RoleReader, " Editor ": RoleEditor, "ADMIN": RoleAdmin} {
+		if role, err := ParseRole(s); err != nil || role != want {
+			t.Errorf("ParseRole(%q) = %v, %v", s, role, err)
+		}
+	}
+	if _, err := ParseRole("owner"); err == nil {
+		t.Error("unknown role accepted")
+	}
+
+	editor := Principal{Subject: "ed", Role: RoleEditor}
+	if !editor.Allows(RoleReader) || !editor.Allows(RoleEditor) || editor.Allows(RoleAdmin) {
+		t.Errorf("editor permissions are wrong")
+	}
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys(" rita:reader:r-key , ed:editor:e:key,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ключ может содержать двоеточие: разделяются только первые два поля
+	if len(keys) != 2 || keys["r-key"] != (Principal{Subject: "rita", Role: RoleReader}) || keys["e:key"] != (Principal{Subject: "ed", Role: RoleEditor}) {
+		t.Fatalf("keys: %+v", keys)
+	}
+
+	for _, s := range []string{"rita:reader", ":reader:key", "rita:reader:", "rita:owner:key", "rita:reader:key,ed:editor:key"} {
+		if _, err := ParseAPIKeys(s); err == nil {
+			t.Errorf("ParseAPIKeys(%q) succeeded", s)
+		}
+	}
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	if _, err := NewAuthenticator(Options{}); err == nil {
+		t.Error("authenticator without keys created")
+	}
+	if _, err := NewAuthenticator(Options{APIKeys: map[string]Principal{"key": {Subject: "rita"}}}); err == nil {
+		t.Error("API key without a role accepted")
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	a, err := NewAuthenticator(Options{APIKeys: map[string]Principal{"r-key": {Subject: "rita", Role: RoleReader}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(headers ...string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		for i := 0; i < len(headers); i += 2 {
+			r.Header.Set(headers[i], headers[i+1])
+		}
+		return r
+	}
+
+	p, err := a.Authenticate(request("X-API-Key", "r-key"))
+	if err != nil || *p != (Principal{Subject: "rita", Role: RoleReader}) {
+		t.Fatalf("Authenticate(r-key) = %+v, %v", p, err)
+	}
+
+	if _, err := a.Authenticate(request()); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("no credentials: %v", err)
+	}
+	for _, headers := range [][]string{
+		{"X-API-Key", "w-key"},
+		{"Authorization", "Basic cml0YTpwYXNz"},
+		// JWT не настроен
+		{"Authorization", "Bearer a.b.c"},
+	} {
+		if _, err := a.Authenticate(request(headers...)); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Authenticate(%q) = %v, want ErrInvalidCredentials", headers, err)
+		}
+	}
+}
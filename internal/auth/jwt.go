@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+// jwtClaims - поля токена, которые проверяет сервис. role - одна из ролей API,
+// exp обязателен: бессрочный токен нельзя отозвать.
+type jwtClaims struct {
+	Subject   string      `json:"sub"`
+	Role      string      `json:"role"`
+	Issuer    string      `json:"iss"`
+	Audience  jwtAudience `json:"aud"`
+	ExpiresAt *int64      `json:"exp"`
+	NotBefore *int64      `json:"nbf"`
+}
+
+// jwtAudience принимает aud и строкой, и массивом строк
+type jwtAudience []string
+
+func (a *jwtAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = jwtAudience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+var jwtHashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256, "HS384": crypto.SHA384, "HS512": crypto.SHA512,
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+}
+
+// verifyJWT проверяет подпись и сроки токена в компактной форме header.payload.signature
+func (a *Authenticator) verifyJWT(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed token header: %v", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature: %v", err)
+	}
+	if err := a.verifySignature(header.Alg, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %v", err)
+	}
+	if err := a.validateClaims(&claims); err != nil {
+		return nil, err
+	}
+
+	role, err := ParseRole(claims.Role)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{Subject: claims.Subject, Role: role}, nil
+}
+
+func (a *Authenticator) verifySignature(alg, signed string, signature []byte) error {
+	hash, ok := jwtHashes[alg]
+	if !ok {
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+
+	// Алгоритм из заголовка принимается, только если для него настроен ключ,
+	// иначе открытый RSA-ключ можно было бы подсунуть как секрет HMAC
+	switch {
+	case strings.HasPrefix(alg, "HS") && len(a.opts.HMACSecret) > 0:
+		mac := hmac.New(hash.New, a.opts.HMACSecret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	case strings.HasPrefix(alg, "RS") && a.opts.RSAPublicKey != nil:
+		h := hash.New()
+		h.Write([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(a.opts.RSAPublicKey, hash, h.Sum(nil), signature); err != nil {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("signing algorithm %q is not configured", alg)
+}
+
+func (a *Authenticator) validateClaims(claims *jwtClaims) error {
+	now := a.now()
+	if claims.Subject == "" {
+		return errors.New("token has no subject")
+	}
+	if claims.ExpiresAt == nil {
+		return errors.New("token has no expiration time")
+	}
+	if !now.Before(time.Unix(*claims.ExpiresAt, 0).Add(a.opts.Leeway)) {
+		return errors.New("token is expired")
+	}
+	if claims.NotBefore != nil && now.Add(a.opts.Leeway).Before(time.Unix(*claims.NotBefore, 0)) {
+		return errors.New("token is not valid yet")
+	}
+	if a.opts.Issuer != "" && claims.Issuer != a.opts.Issuer {
+		return fmt.Errorf("unexpected token issuer %q", claims.Issuer)
+	}
+	if a.opts.Audience != "" && !slices.Contains(claims.Audience, a.opts.Audience) {
+		return errors.New("token is not issued for this audience")
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// ParseRSAPublicKey читает открытый RSA-ключ из PEM: PUBLIC KEY (PKIX) или RSA PUBLIC KEY (PKCS#1)
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("auth: no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("auth: public key is not an RSA key")
+		}
+		return rsaKey, nil
+	}
+	return nil, fmt.Errorf("auth: unsupported PEM block %q", block.Type)
+}
+
+// ParseAPIKeys разбирает список ключей вида "subject:role:key" через запятую
+func ParseAPIKeys(s string) (map[string]Principal, error) {
+	keys := make(map[string]Principal)
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("auth: API key entry must look like subject:role:key")
+		}
+		role, err := ParseRole(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("auth: API key of %q: %v", parts[0], err)
+		}
+		if _, ok := keys[parts[2]]; ok {
+			return nil, fmt.Errorf("auth: API key of %q is used twice", parts[0])
+		}
+		keys[parts[2]] = Principal{Subject: parts[0], Role: role}
+	}
+	return keys, nil
+}
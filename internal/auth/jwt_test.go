@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("test-secret")
+
+// signToken собирает JWT с заголовком alg и подписывает его sign
+func signToken(t *testing.T, alg string, claims map[string]interface{}, sign func(signed string) []byte) string {
+	t.Helper()
+
+	enc := base64.RawURLEncoding
+	header, err := json.Marshal(jwtHeader{Alg: alg, Typ: "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	return signed + "." + enc.EncodeToString(sign(signed))
+}
+
+func hmacSigner(hash crypto.Hash, secret []byte) func(string) []byte {
+	return func(signed string) []byte {
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signed))
+		return mac.Sum(nil)
+	}
+}
+
+func rsaSigner(t *testing.T, hash crypto.Hash, key *rsa.PrivateKey) func(string) []byte {
+	return func(signed string) []byte {
+		h := hash.New()
+		h.Write([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, hash, h.Sum(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+}
+
+func authenticateToken(a *Authenticator, token string) (*Principal, error) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return a.Authenticate(r)
+}
+
+func TestVerifyJWTClaims(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, err := NewAuthenticator(Options{HMACSecret: testSecret, Issuer: "library", Audience: "api", Leeway: 30 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.now = func() time.Time { return now }
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"sub": "jwt-editor", "role": "editor", "iss": "library", "aud": "api",
+			"exp": now.Add(time.Hour).Unix(),
+		}
+	}
+
+	p, err := authenticateToken(a, signToken(t, "HS256", valid(), hmacSigner(crypto.SHA256, testSecret)))
+	if err != nil || *p != (Principal{Subject: "jwt-editor", Role: RoleEditor}) {
+		t.Fatalf("valid token: %+v, %v", p, err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		edit  func(claims map[string]interface{})
+		valid bool
+	}{
+		{"audience list", func(c map[string]interface{}) { c["aud"] = []string{"web", "api"} }, true},
+		{"expired within leeway", func(c map[string]interface{}) { c["exp"] = now.Add(-10 * time.Second).Unix() }, true},
+		{"nbf within leeway", func(c map[string]interface{}) { c["nbf"] = now.Add(10 * time.Second).Unix() }, true},
+		{"no exp", func(c map[string]interface{}) { delete(c, "exp") }, false},
+		{"expired", func(c map[string]interface{}) { c["exp"] = now.Add(-time.Minute).Unix() }, false},
+		{"not valid yet", func(c map[string]interface{}) { c["nbf"] = now.Add(time.Minute).Unix() }, false},
+		{"no subject", func(c map[string]interface{}) { delete(c, "sub") }, false},
+		{"unknown role", func(c map[string]interface{}) { c["role"] = "owner" }, false},
+		{"wrong issuer", func(c map[string]interface{}) { c["iss"] = "other" }, false},
+		{"wrong audience", func(c map[string]interface{}) { c["aud"] = []string{"web"} }, false},
+		{"no audience", func(c map[string]interface{}) { delete(c, "aud") }, false},
+	} {
+		claims := valid()
+		tc.edit(claims)
+		_, err := authenticateToken(a, signToken(t, "HS256", claims, hmacSigner(crypto.SHA256, testSecret)))
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+	}
+}
+
+func TestVerifyJWTSignature(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix})
+
+	claims := map[string]interface{}{"sub": "jwt-reader", "role": "reader", "exp": time.Now().Add(time.Hour).Unix()}
+	hs := signToken(t, "HS512", claims, hmacSigner(crypto.SHA512, testSecret))
+	rs := signToken(t, "RS256", claims, rsaSigner(t, crypto.SHA256, key))
+
+	both, err := NewAuthenticator(Options{HMACSecret: testSecret, RSAPublicKey: &key.PublicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []string{hs, rs} {
+		if _, err := authenticateToken(both, token); err != nil {
+			t.Errorf("token %s: %v", token[:20], err)
+		}
+	}
+
+	onlyRSA, err := NewAuthenticator(Options{RSAPublicKey: &key.PublicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, token := range map[string]string{
+		"HS512 without a secret": hs,
+		// Открытый ключ, подсунутый как секрет HMAC, не принимается
+		"HS256 signed with the public key": signToken(t, "HS256", claims, hmacSigner(crypto.SHA256, pemKey)),
+		"alg none":                         signToken(t, "none", claims, func(string) []byte { return nil }),
+		"tampered payload":                 tamper(rs),
+		"wrong key":                        signToken(t, "HS256", claims, hmacSigner(crypto.SHA256, []byte("other"))),
+		"two segments":                     "a.b",
+		"bad signature encoding":           rs[:strings.LastIndex(rs, ".")] + ".!!!",
+	} {
+		if _, err := authenticateToken(onlyRSA, token); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+}
+
+// tamper подменяет роль в токене, не трогая подпись
+func tamper(token string) string {
+	parts := strings.Split(token, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(payload), `"reader"`, `"admin"`, 1)))
+	return strings.Join(parts, ".")
+}
+
+func TestParseRSAPublicKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, block := range []*pem.Block{
+		{Type: "PUBLIC KEY", Bytes: pkix},
+		{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)},
+	} {
+		parsed, err := ParseRSAPublicKey(pem.EncodeToMemory(block))
+		if err != nil || !parsed.Equal(&key.PublicKey) {
+			t.Errorf("%s: %v", block.Type, err)
+		}
+	}
+
+	for _, data := range [][]byte{
+		[]byte("not a key"),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+	} {
+		if _, err := ParseRSAPublicKey(data); err == nil {
+			t.Errorf("ParseRSAPublicKey(%.20q) succeeded", data)
+		}
+	}
+}
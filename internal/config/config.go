@@ -1,6 +1,9 @@
 package config
 
 import (
+	"strings"
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -10,13 +13,60 @@ type Config struct {
 	DBUser         string `mapstructure:"DB_USER"`
 	DBPassword     string `mapstructure:"DB_PASSWORD"`
 	DBName         string `mapstructure:"DB_NAME"`
+	Storage        string `mapstructure:"STORAGE"`
 	ServerPort     string `mapstructure:"SERVER_PORT"`
 	LoggerLevel    string `mapstructure:"LOGGER_LEVEL"`
 	LoggerOut      string `mapstructure:"LOGGER_OUT"`
 	LoggerFilePath string `mapstructure:"LOGGER_FILEPATH"`
+	LoggerFormat   string `mapstructure:"LOGGER_FORMAT"`
 	EnvType        string `mapstructure:"ENV_TYPE"`
 	MusicAPIHost   string `mapstructure:"MUSIC_API_HOST"`
 	MusicBaseURL   string `mapstructure:"MUSIC_BASE_URL"`
+
+	MusicAPITimeout          time.Duration `mapstructure:"MUSIC_API_TIMEOUT"`
+	MusicAPIMaxRetries       int           `mapstructure:"MUSIC_API_MAX_RETRIES"`
+	MusicAPIRetryBase        time.Duration `mapstructure:"MUSIC_API_RETRY_BASE"`
+	MusicAPIRetryMax         time.Duration `mapstructure:"MUSIC_API_RETRY_MAX"`
+	MusicAPIBreakerThreshold int           `mapstructure:"MUSIC_API_BREAKER_THRESHOLD"`
+	MusicAPIBreakerCooldown  time.Duration `mapstructure:"MUSIC_API_BREAKER_COOLDOWN"`
+
+	DetailsProviders string `mapstructure:"DETAILS_PROVIDERS"`
+	DetailsFile      string `mapstructure:"DETAILS_FILE"`
+
+	MusicCacheSize        int           `mapstructure:"MUSIC_CACHE_SIZE"`
+	MusicCacheTTL         time.Duration `mapstructure:"MUSIC_CACHE_TTL"`
+	MusicCacheNegativeTTL time.Duration `mapstructure:"MUSIC_CACHE_NEGATIVE_TTL"`
+
+	ImportConcurrency int `mapstructure:"IMPORT_CONCURRENCY"`
+
+	EnrichmentWorkers     int           `mapstructure:"ENRICHMENT_WORKERS"`
+	EnrichmentMaxAttempts int           `mapstructure:"ENRICHMENT_MAX_ATTEMPTS"`
+	EnrichmentRetryBase   time.Duration `mapstructure:"ENRICHMENT_RETRY_BASE"`
+	EnrichmentRetryMax    time.Duration `mapstructure:"ENRICHMENT_RETRY_MAX"`
+
+	TrashRetention     time.Duration `mapstructure:"TRASH_RETENTION"`
+	TrashPurgeInterval time.Duration `mapstructure:"TRASH_PURGE_INTERVAL"`
+
+	AuthDisabled            bool          `mapstructure:"AUTH_DISABLED"`
+	AuthAPIKeys             string        `mapstructure:"AUTH_API_KEYS"`
+	AuthJWTHMACSecret       string        `mapstructure:"AUTH_JWT_HMAC_SECRET"`
+	AuthJWTRSAPublicKeyFile string        `mapstructure:"AUTH_JWT_RSA_PUBLIC_KEY_FILE"`
+	AuthJWTIssuer           string        `mapstructure:"AUTH_JWT_ISSUER"`
+	AuthJWTAudience         string        `mapstructure:"AUTH_JWT_AUDIENCE"`
+	AuthJWTLeeway           time.Duration `mapstructure:"AUTH_JWT_LEEWAY"`
+
+	RateLimitBackend  string `mapstructure:"RATE_LIMIT_BACKEND"`
+	RateLimitRead     string `mapstructure:"RATE_LIMIT_READ"`
+	RateLimitWrite    string `mapstructure:"RATE_LIMIT_WRITE"`
+	RateLimitUpstream string `mapstructure:"RATE_LIMIT_UPSTREAM"`
+	RateLimitAuth     string `mapstructure:"RATE_LIMIT_AUTH"`
+	TrustedProxies    string `mapstructure:"TRUSTED_PROXIES"`
+
+	MetricsDisabled bool `mapstructure:"METRICS_DISABLED"`
+
+	HealthCheckTimeout   time.Duration `mapstructure:"HEALTH_CHECK_TIMEOUT"`
+	HealthCheckMusicInfo bool          `mapstructure:"HEALTH_CHECK_MUSIC_INFO"`
+	HealthShutdownDelay  time.Duration `mapstructure:"HEALTH_SHUTDOWN_DELAY"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -34,3 +84,23 @@ func LoadConfig(path string) (config Config, err error) {
 	err = viper.Unmarshal(&config)
 	return
 }
+
+// DetailsProviderList разбирает DETAILS_PROVIDERS - имена источников через запятую
+func (c Config) DetailsProviderList() []string {
+	return splitList(c.DetailsProviders)
+}
+
+// TrustedProxyList разбирает TRUSTED_PROXIES - адреса и подсети прокси через запятую
+func (c Config) TrustedProxyList() []string {
+	return splitList(c.TrustedProxies)
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,336 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// @Summary Create an album
+// @Description Creates an album of a group. Type is one of LP, EP or single (LP by default).
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param album body models.Album true "Album data"
+// @Success 201 {object} models.Album "Album created"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body or group not found"
+// @Failure 409 {object} models.ErrorResponse "Album already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /albums [post]
+func (m *MusicLibController) CreateAlbum(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	var album models.Album
+	if err := ctx.ShouldBindJSON(&album); err != nil {
+		m.log(ctx).Error("CreateAlbum: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if album.ReleaseDate != "" && !m.service.IsValidDate(album.ReleaseDate) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release date, expected YYYY-MM-DD"})
+		return
+	}
+
+	if err := m.service.CreateAlbum(ctx.Request.Context(), &album); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrGroupNotFound):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Group not found"})
+		case errors.Is(err, repository.ErrDuplicate):
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Album already exists"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, album)
+}
+
+// @Summary List albums
+// @Description Returns albums, newest first, with optional filters and pagination.
+// @Tags albums
+// @Produce json
+// @Param group_id query int false "Filter by group ID"
+// @Param type query string false "Filter by album type (LP, EP, single)"
+// @Param limit query int false "Number of results to return" default(15)
+// @Param offset query int false "Offset from the beginning" default(0)
+// @Success 200 {array} models.Album "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /albums [get]
+func (m *MusicLibController) GetAlbums(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	limit, offset, err := parseLimitOffset(ctx)
+	if err != nil {
+		m.log(ctx).Error("GetAlbums: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := make(map[string]string)
+	if groupID := ctx.Query("group_id"); groupID != "" {
+		if _, err := strconv.ParseUint(groupID, 10, 32); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'group_id' parameter"})
+			return
+		}
+		filter["group_id"] = groupID
+	}
+	if albumType := ctx.Query("type"); albumType != "" {
+		filter["type"] = albumType
+	}
+
+	albums, err := m.service.GetAlbums(ctx.Request.Context(), filter, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	if albums == nil {
+		albums = []models.Album{}
+	}
+
+	ctx.JSON(200, albums)
+}
+
+// @Summary Get album by ID
+// @Tags albums
+// @Produce json
+// @Param id path int true "Album ID"
+// @Success 200 {object} models.Album "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid album ID"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Album not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /albums/{id} [get]
+func (m *MusicLibController) GetAlbumByID(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	album, err := m.service.GetAlbumByID(ctx.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(404, gin.H{"error": "Album not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(200, album)
+}
+
+// @Summary Update album
+// @Description Updates only the provided fields. An empty release_date clears the date.
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param id path int true "Album ID"
+// @Param album body models.AlbumPatch true "Fields to update"
+// @Success 200 {object} models.Album "Updated album"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 404 {object} models.ErrorResponse "Album not found"
+// @Failure 409 {object} models.ErrorResponse "Album with this title already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /albums/{id} [patch]
+func (m *MusicLibController) UpdateAlbum(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var patch models.AlbumPatch
+	if err := ctx.ShouldBindJSON(&patch); err != nil {
+		m.log(ctx).Error("UpdateAlbum: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if patch.ReleaseDate != nil && *patch.ReleaseDate != "" && !m.service.IsValidDate(*patch.ReleaseDate) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release date, expected YYYY-MM-DD"})
+		return
+	}
+
+	if err := m.service.UpdateAlbum(ctx.Request.Context(), id, &patch); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			ctx.JSON(404, gin.H{"error": "Album not found"})
+		case errors.Is(err, repository.ErrDuplicate):
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Album with this title already exists"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}
+		return
+	}
+
+	album, err := m.service.GetAlbumByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	ctx.JSON(200, album)
+}
+
+// @Summary Delete album
+// @Description Deletes an album and its track list. The songs themselves are kept.
+// @Tags albums
+// @Produce json
+// @Param id path int true "Album ID"
+// @Success 200 {object} models.ErrorResponse "Album deleted successfully"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid album ID"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Album not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /albums/{id} [delete]
+func (m *MusicLibController) DeleteAlbum(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := m.service.DeleteAlbum(ctx.Request.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(404, gin.H{"error": "Album not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "Album deleted successfully"})
+}
+
+// @Summary List album tracks
+// @Description Returns the album's songs ordered by track number.
+// @Tags albums
+// @Produce json
+// @Param id path int true "Album ID"
+// @Success 200 {array} models.AlbumTrack "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid album ID"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Album not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /albums/{id}/tracks [get]
+func (m *MusicLibController) GetAlbumTracks(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tracks, err := m.service.GetAlbumTracks(ctx.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(404, gin.H{"error": "Album not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(200, tracks)
+}
+
+// @Summary Set album tracks
+// @Description Replaces the album's track list. Track numbers follow the order of song_ids, starting from 1.
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param id path int true "Album ID"
+// @Param tracks body models.AlbumTracksReq true "Ordered song IDs"
+// @Success 200 {array} models.AlbumTrack "New track list"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body, unknown or repeated song"
+// @Failure 404 {object} models.ErrorResponse "Album not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /albums/{id}/tracks [put]
+func (m *MusicLibController) SetAlbumTracks(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.AlbumTracksReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		m.log(ctx).Error("SetAlbumTracks: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := m.service.SetAlbumTracks(ctx.Request.Context(), id, req.SongIDs); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			ctx.JSON(404, gin.H{"error": "Album not found"})
+		case errors.Is(err, repository.ErrSongNotFound):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Song not found"})
+		case errors.Is(err, repository.ErrDuplicate):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "A song may appear only once in an album"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}
+		return
+	}
+
+	tracks, err := m.service.GetAlbumTracks(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	ctx.JSON(200, tracks)
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// parseAuditTime читает границу периода журнала аудита в формате RFC3339 или YYYY-MM-DD
+// и приводит ее к UTC. Дата без времени - сутки по UTC, в параметре to включается весь день.
+func parseAuditTime(ctx *gin.Context, param string) (time.Time, error) {
+	value := ctx.Query(param)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid '%s' parameter", param)
+	}
+	if param == "to" {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// @Summary Get audit log
+// @Description Returns the append-only log of song changes, newest first: creates, updates, deletes,
+// @Description restores and purges with the actor, request ID, source (api, import, enrichment, retention)
+// @Description and changed fields as before/after values. created_at is in UTC.
+// @Tags audit
+// @Produce json
+// @Param song_id query int false "Song ID"
+// @Param actor query string false "Author of the change"
+// @Param from query string false "Start of the period, RFC3339 with any offset or YYYY-MM-DD (UTC day)"
+// @Param to query string false "End of the period (exclusive), RFC3339 with any offset or YYYY-MM-DD (the whole UTC day is included)"
+// @Param limit query int false "Number of entries to return" default(15)
+// @Param offset query int false "Offset from the newest entry" default(0)
+// @Success 200 {array} models.AuditEntry "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /audit [get]
+func (m *MusicLibController) GetAuditLog(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	limit, offset, err := parseLimitOffset(ctx)
+	if err != nil {
+		m.log(ctx).Error("GetAuditLog: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := models.AuditFilter{Actor: ctx.Query("actor")}
+	if songID := ctx.Query("song_id"); songID != "" {
+		id, err := strconv.ParseUint(songID, 10, 32)
+		if err != nil || id == 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'song_id' parameter"})
+			return
+		}
+		filter.SongID = uint(id)
+	}
+	for param, dest := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if *dest, err = parseAuditTime(ctx, param); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	entries, err := m.service.GetAuditLog(ctx.Request.Context(), filter, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entries)
+}
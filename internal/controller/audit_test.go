@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseAuditTime(t *testing.T) {
+	for _, tc := range []struct {
+		param, value string
+		want         time.Time
+		wantErr      bool
+	}{
+		{"from", "", time.Time{}, false},
+		{"from", "2024-03-01T12:30:00Z", time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), false},
+		{"from", "2024-03-01T15:30:00+03:00", time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), false},
+		{"from", "2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		// Дата в to включает весь день
+		{"to", "2024-03-01", time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), false},
+		{"to", "2024-03-01T12:30:00Z", time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), false},
+		{"from", "01.03.2024", time.Time{}, true},
+		{"to", "2024-03-01 12:30", time.Time{}, true},
+	} {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/audit?"+url.Values{tc.param: {tc.value}}.Encode(), nil)
+
+		got, err := parseAuditTime(ctx, tc.param)
+		if (err != nil) != tc.wantErr || !got.Equal(tc.want) || (!got.IsZero() && got.Location() != time.UTC) {
+			t.Errorf("parseAuditTime(%s=%q) = %v, %v", tc.param, tc.value, got, err)
+		}
+	}
+}
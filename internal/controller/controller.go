@@ -3,8 +3,11 @@ package controller
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
 	"mikromolekula2002/music_library_ver1.0/internal/service"
+	"mikromolekula2002/music_library_ver1.0/pkg/logger"
 	"net/http"
 	"strconv"
 
@@ -20,83 +23,109 @@ func NewMusicLibController(service *service.MusicLibService) *MusicLibController
 	return &MusicLibController{service: service}
 }
 
+// log возвращает запись лога запроса с его идентификатором
+func (m *MusicLibController) log(ctx *gin.Context) *logrus.Entry {
+	return logger.FromContext(ctx.Request.Context(), m.service.Logger)
+}
+
 // @Summary Save song data
-// @Description Save group and song from the request and fetch additional text from an external API
+// @Description Saves group and song from the request and queues a background job that fetches the release date, link and text from an external API.
+// @Description The song is created with enrichment_status "pending"; poll /jobs/{job_id} or /songs/{id} for the result.
+// @Description Optional lrc sets time-synced lyrics right away; the job then fetches only the release date and link.
 // @Tags sav song
 // @Accept json
 // @Produce json
 // @Param song body models.CreateSongReq true "Song data"
-// @Success 201 {object} models.CreateSongReq "Song successfully saved"
-// @Failure 400 {object} models.ErrorResponse "Invalid request format"
+// @Success 202 {object} models.CreateSongResp "Song saved, enrichment job queued"
+// @Header 202 {string} Location "URL of the enrichment job"
+// @Failure 400 {object} models.ErrorResponse "Invalid request format or LRC"
+// @Failure 409 {object} models.ErrorResponse "Song already exists"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /create-song [post]
 func (m *MusicLibController) SaveSong(ctx *gin.Context) {
-	m.service.Logger.Info("Handling request", logrus.Fields{
+	m.log(ctx).WithFields(logrus.Fields{
 		"method": ctx.Request.Method,
 		"url":    ctx.Request.URL.String(),
-	})
+	}).Info("Handling request")
 
 	var song models.CreateSongReq
-	var songResp models.CreateSongResp
 
 	if err := ctx.ShouldBindJSON(&song); err != nil {
-		m.service.Logger.Error(err)
+		m.log(ctx).Error(err)
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
-	m.service.Logger.Debug("save song with parameters:", logrus.Fields{
+	m.log(ctx).WithFields(logrus.Fields{
 		"group": song.Group,
 		"song":  song.Song,
-	})
+	}).Debug("save song with parameters:")
 
-	songData, err := m.service.GetSongDetailsFromAPI(song.Group, song.Song, ctx)
+	songID, jobID, err := m.service.CreateSong(ctx.Request.Context(), song.Group, song.Song, song.LRC)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidLRC) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, repository.ErrDuplicate) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Song already exists"})
+			return
+		}
 		ctx.JSON(500, gin.H{"error": "Internal server error"})
 		return
 	}
 
-	if err := m.service.SaveSong(songData); err != nil {
-		ctx.JSON(500, gin.H{"error": "Internal server error"})
-		return
-	}
-
-	m.service.Logger.Info("Song successfully saved", logrus.Fields{
-		"id":    songData.ID,
-		"group": songData.Group,
-		"song":  songData.Song,
+	m.log(ctx).WithFields(logrus.Fields{
+		"id":     songID,
+		"job id": jobID,
+		"group":  song.Group,
+		"song":   song.Song,
+	}).Info("Song saved, enrichment queued")
+
+	ctx.Header("Location", fmt.Sprintf("/jobs/%d", jobID))
+	ctx.JSON(http.StatusAccepted, models.CreateSongResp{
+		ID:               songID,
+		Group:            song.Group,
+		Song:             song.Song,
+		EnrichmentStatus: models.EnrichmentPending,
+		JobID:            jobID,
 	})
-
-	songResp.ID = songData.ID
-	songResp.Group = song.Group
-	songResp.Song = song.Song
-
-	ctx.JSON(201, songResp)
 }
 
 // @Summary Get song text by group and song name
-// @Description Fetches the lyrics of a song from a specific group with pagination.
+// @Description Fetches the lyrics of a song from a specific group with pagination by verses or by lines.
 // @Tags song text
 // @Accept json
 // @Produce json
 // @Param group query string true "Group name"
 // @Param song query string true "Song name"
-// @Param limit query int false "Number of lines to return" default(10)
+// @Param by query string false "Pagination unit: verse or line" default(verse)
+// @Param limit query int false "Number of verses or lines to return" default(15)
 // @Param offset query int false "Offset from the beginning" default(0)
 // @Success 200 {object} models.SongTextResponse "Successful response"
 // @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
 // @Failure 404 {object} models.ErrorResponse "Not Found: Song text not found"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /song [get]
 func (m *MusicLibController) GetSongTextByGroup(ctx *gin.Context) {
-	m.service.Logger.Info("Handling request", logrus.Fields{
+	m.log(ctx).WithFields(logrus.Fields{
 		"method": ctx.Request.Method,
 		"url":    ctx.Request.URL.String(),
-	})
+	}).Info("Handling request")
 
 	limit, offset, err := parseLimitOffset(ctx)
 	if err != nil {
-		m.service.Logger.Error("GetSongTextByGroup: ", err)
+		m.log(ctx).Error("GetSongTextByGroup: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	by, err := parseTextUnit(ctx)
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -104,17 +133,17 @@ func (m *MusicLibController) GetSongTextByGroup(ctx *gin.Context) {
 	groupName := ctx.Query("group")
 	songName := ctx.Query("song")
 	if groupName == "" || songName == "" {
-		m.service.Logger.Error("GetSongTextByGroup: invalid parameters")
+		m.log(ctx).Error("GetSongTextByGroup: invalid parameters")
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Missing required parameters: group or song"})
 		return
 	}
 
-	m.service.Logger.Debug("get song text by group with parameters:", logrus.Fields{
+	m.log(ctx).WithFields(logrus.Fields{
 		"group": groupName,
 		"song":  songName,
-	})
+	}).Debug("get song text by group with parameters:")
 
-	song, err := m.service.GetSongTextByGroup(groupName, songName, limit, offset)
+	song, err := m.service.GetSongTextByGroup(ctx.Request.Context(), groupName, songName, by, limit, offset)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			ctx.JSON(404, gin.H{"error": "Song text not found"})
@@ -137,34 +166,36 @@ func (m *MusicLibController) GetSongTextByGroup(ctx *gin.Context) {
 // @Failure 400 {object} models.ErrorResponse "Invalid request body"
 // @Failure 404 {object} models.ErrorResponse "Song not found"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /song [put]
 func (m *MusicLibController) UpdateSong(ctx *gin.Context) {
-	m.service.Logger.Info("Handling request", logrus.Fields{
+	m.log(ctx).WithFields(logrus.Fields{
 		"method": ctx.Request.Method,
 		"url":    ctx.Request.URL.String(),
-	})
+	}).Info("Handling request")
 
 	var song models.Song
 	if err := ctx.ShouldBindJSON(&song); err != nil {
-		m.service.Logger.Error("UpdateSong: invalid parameters")
+		m.log(ctx).Error("UpdateSong: invalid parameters")
 		ctx.JSON(400, gin.H{"error": "Invalid request body"})
 		return
 	}
 
-	m.service.Logger.Debug("update song with parameters:", logrus.Fields{
+	m.log(ctx).WithFields(logrus.Fields{
 		"group":        song.Group,
 		"song":         song.Song,
 		"release_date": song.ReleaseDate,
 		"text":         song.Text,
 		"link":         song.Link,
-	})
+	}).Debug("update song with parameters:")
 
 	if song.ReleaseDate != "" && !m.service.IsValidDate(song.ReleaseDate) {
-		m.service.Logger.Error("GetSongTextByGroup: invalid parameter release date")
+		m.log(ctx).Error("GetSongTextByGroup: invalid parameter release date")
 		ctx.JSON(400, gin.H{"error": "Invalid request body"})
 	}
 
-	if err := m.service.UpdateSong(&song); err != nil {
+	if err := m.service.UpdateSong(ctx.Request.Context(), &song); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			ctx.JSON(404, gin.H{"error": "Song not found"})
 			return
@@ -173,16 +204,16 @@ func (m *MusicLibController) UpdateSong(ctx *gin.Context) {
 		return
 	}
 
-	m.service.Logger.Info("Song data updated successfully", logrus.Fields{
+	m.log(ctx).WithFields(logrus.Fields{
 		"group": song.Group,
 		"song":  song.Song,
-	})
+	}).Info("Song data updated successfully")
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Song updated successfully"})
 }
 
 // @Summary Delete a song by group and song name
-// @Description Deletes a song from the library based on the provided group and song name.
+// @Description Moves the song to the trash; it can be restored with POST /trash/{id}/restore until it is purged.
 // @Tags delete song
 // @Accept json
 // @Produce json
@@ -192,12 +223,14 @@ func (m *MusicLibController) UpdateSong(ctx *gin.Context) {
 // @Failure 400 {object} models.ErrorResponse "Bad Request: Missing required parameters"
 // @Failure 404 {object} models.ErrorResponse "Not Found: Song not found"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /song [delete]
 func (m *MusicLibController) DeleteSong(ctx *gin.Context) {
-	m.service.Logger.Info("Handling request", logrus.Fields{
+	m.log(ctx).WithFields(logrus.Fields{
 		"method": ctx.Request.Method,
 		"url":    ctx.Request.URL.String(),
-	})
+	}).Info("Handling request")
 
 	groupName := ctx.Query("group")
 	songName := ctx.Query("song")
@@ -206,12 +239,12 @@ func (m *MusicLibController) DeleteSong(ctx *gin.Context) {
 		return
 	}
 
-	m.service.Logger.Debug("delete song with parameters:", logrus.Fields{
+	m.log(ctx).WithFields(logrus.Fields{
 		"group": groupName,
 		"song":  songName,
-	})
+	}).Debug("delete song with parameters:")
 
-	if err := m.service.DeleteSong(groupName, songName); err != nil {
+	if err := m.service.DeleteSong(ctx.Request.Context(), groupName, songName); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			ctx.JSON(404, gin.H{"error": "Song not found"})
 			return
@@ -225,36 +258,129 @@ func (m *MusicLibController) DeleteSong(ctx *gin.Context) {
 
 // @Summary Get all songs with optional filters
 // @Description Retrieves a list of songs based on optional filters, with pagination support.
+// @Description Offset pagination returns a plain array; cursor pagination (and total=body) returns a models.SongPage object with items, next_cursor/prev_cursor and total.
 // @Tags songs
 // @Accept json
 // @Produce json
+// @Param group_id query int false "Filter by group ID"
+// @Param album_id query int false "Filter by album ID"
 // @Param group query string false "Filter by group name"
 // @Param song query string false "Filter by song name"
+// @Param groupMatch query string false "Group name match mode: exact, prefix, contains (case-insensitive) or fuzzy (trigram similarity)" default(exact)
+// @Param songMatch query string false "Song name match mode: exact, prefix, contains (case-insensitive) or fuzzy (trigram similarity)" default(exact)
+// @Param similarity query number false "Minimum similarity (0..1] for fuzzy matching; fuzzy results are ordered by similarity" default(0.3)
 // @Param link query string false "Filter by link"
 // @Param releaseDate query string false "Filter by exact release date (YYYY-MM-DD)"
 // @Param startDate query string false "Filter by release date range start (YYYY-MM-DD)"
 // @Param endDate query string false "Filter by release date range end (YYYY-MM-DD)"
-// @Param limit query int false "Number of results to return" default(10)
-// @Param offset query int false "Offset from the beginning" default(0)
+// @Param limit query int false "Number of results to return" default(15)
+// @Param offset query int false "Offset from the beginning (offset pagination only)" default(0)
+// @Param sort query string false "Comma-separated sort keys, '-' prefix for descending order: group, song, release_date, created_at, updated_at, id (e.g. group,-release_date,song)" default(-release_date,-id)
+// @Param pagination query string false "Pagination mode: offset (plain array) or cursor (page object, cursors are tied to the sort order)" default(offset)
+// @Param cursor query string false "next_cursor or prev_cursor from a previous page; implies cursor pagination"
+// @Param total query string false "Return the total number of matching songs: header (X-Total-Count) or body"
 // @Success 200 {array} models.Song "Successful response with list of songs"
 // @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
 // @Failure 404 {object} models.ErrorResponse "Not Found: No songs found"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /songs [get]
 func (m *MusicLibController) GetAllSongs(ctx *gin.Context) {
-	m.service.Logger.Info("Handling request", logrus.Fields{
+	m.log(ctx).WithFields(logrus.Fields{
 		"method": ctx.Request.Method,
 		"url":    ctx.Request.URL.String(),
-	})
+	}).Info("Handling request")
 
 	limit, offset, err := parseLimitOffset(ctx)
 	if err != nil {
-		m.service.Logger.Error("GetAllSongs: ", err)
+		m.log(ctx).Error("GetAllSongs: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseSongFilter(ctx)
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	m.log(ctx).WithFields(logrus.Fields{
+		"limit":   limit,
+		"offset":  offset,
+		"filters": filter,
+		"sort":    ctx.Query("sort"),
+	}).Debug("get songs with parameters:")
+
+	sort, err := service.ParseSongSort(ctx.Query("sort"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	total := ctx.Query("total")
+	if total != "" && total != "header" && total != "body" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'total' parameter"})
+		return
+	}
+
+	cursor := ctx.Query("cursor")
+	pagination := ctx.DefaultQuery("pagination", "offset")
+	if cursor != "" {
+		pagination = "cursor"
+	}
+
+	switch pagination {
+	case "cursor":
+		m.getSongsPage(ctx, filter, sort, cursor, limit, total)
+		return
+	case "offset":
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'pagination' parameter"})
+		return
+	}
+
+	songs, err := m.service.GetAllSongs(ctx.Request.Context(), filter, sort, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	var count int
+	if total != "" {
+		if count, err = m.service.CountSongs(ctx.Request.Context(), filter); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			return
+		}
+		ctx.Header("X-Total-Count", strconv.Itoa(count))
+	}
+
+	if len(songs) == 0 {
+		ctx.JSON(404, gin.H{"error": "No songs found"})
+		return
+	}
+
+	if total == "body" {
+		ctx.JSON(200, models.SongPage{Items: songs, Total: &count})
+		return
+	}
+
+	ctx.JSON(200, songs)
+}
+
+// parseSongFilter собирает фильтр списка песен из параметров запроса
+func parseSongFilter(ctx *gin.Context) (map[string]string, error) {
 	filter := make(map[string]string)
+	for _, param := range []string{"group_id", "album_id"} {
+		value := ctx.Query(param)
+		if value == "" {
+			continue
+		}
+		if _, err := strconv.ParseUint(value, 10, 32); err != nil {
+			return nil, fmt.Errorf("invalid '%s' parameter", param)
+		}
+		filter[param] = value
+	}
 	group := ctx.Query("group")
 	if group != "" {
 		filter["group_name"] = group
@@ -263,45 +389,51 @@ func (m *MusicLibController) GetAllSongs(ctx *gin.Context) {
 	if song != "" {
 		filter["song"] = song
 	}
-	link := ctx.Query("link")
-	if link != "" {
-		filter["link"] = link
-	}
-	releaseDate := ctx.Query("releaseDate")
-	if releaseDate != "" {
-		filter["releaseDate"] = releaseDate
+	for _, param := range []string{"groupMatch", "songMatch"} {
+		mode := ctx.Query(param)
+		switch mode {
+		case "", models.MatchExact:
+		case models.MatchPrefix, models.MatchContains, models.MatchFuzzy:
+			filter[param] = mode
+		default:
+			return nil, fmt.Errorf("invalid '%s' parameter", param)
+		}
 	}
-	startDate := ctx.Query("startDate")
-	if startDate != "" {
-		filter["startDate"] = startDate
+	similarity := ctx.Query("similarity")
+	if similarity != "" {
+		threshold, err := strconv.ParseFloat(similarity, 64)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			return nil, errors.New("invalid 'similarity' parameter")
+		}
+		filter["similarity"] = similarity
 	}
-	endDate := ctx.Query("endDate")
-	if endDate != "" {
-		filter["endDate"] = endDate
+	for _, param := range []string{"link", "releaseDate", "startDate", "endDate"} {
+		if value := ctx.Query(param); value != "" {
+			filter[param] = value
+		}
 	}
 
-	m.service.Logger.Debug("get songs with parameters:", logrus.Fields{
-		"limit":        limit,
-		"offset":       offset,
-		"group":        group,
-		"song":         song,
-		"release date": releaseDate,
-		"start date":   startDate,
-		"end date":     endDate,
-	})
+	return filter, nil
+}
 
-	songs, err := m.service.GetAllSongs(filter, limit, offset)
+// getSongsPage отдает страницу песен в режиме курсорной пагинации
+func (m *MusicLibController) getSongsPage(ctx *gin.Context, filter map[string]string, sort []models.SortKey, cursor string, limit int, total string) {
+	page, err := m.service.GetSongsPage(ctx.Request.Context(), filter, sort, cursor, limit, total != "")
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrCursorNotSupported) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
 		return
 	}
 
-	if len(songs) == 0 {
-		ctx.JSON(404, gin.H{"error": "No songs found"})
-		return
+	if total == "header" {
+		ctx.Header("X-Total-Count", strconv.Itoa(*page.Total))
+		page.Total = nil
 	}
 
-	ctx.JSON(200, songs)
+	ctx.JSON(200, page)
 }
 
 func parseLimitOffset(ctx *gin.Context) (int, int, error) {
@@ -320,3 +452,320 @@ func parseLimitOffset(ctx *gin.Context) (int, int, error) {
 
 	return limit, offset, nil
 }
+
+// @Summary Get song by ID
+// @Description Returns song details together with its full text and enrichment_status (pending, done or failed).
+// @Tags songs by id
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.Song "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid song ID"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id} [get]
+func (m *MusicLibController) GetSongByID(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	song, err := m.service.GetSongByID(ctx.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(404, gin.H{"error": "Song not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(200, song)
+}
+
+// @Summary Replace song by ID
+// @Description Replaces all song data, including group and song name. Text is split into verses by empty lines.
+// @Tags songs by id
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param song body models.Song true "New song data"
+// @Success 200 {object} models.Song "Updated song"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 409 {object} models.ErrorResponse "Song with this group and name already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id} [put]
+func (m *MusicLibController) ReplaceSong(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var song models.Song
+	if err := ctx.ShouldBindJSON(&song); err != nil {
+		m.log(ctx).Error("ReplaceSong: invalid parameters")
+		ctx.JSON(400, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if !m.service.IsValidDate(song.ReleaseDate) {
+		m.log(ctx).Error("ReplaceSong: invalid parameter release date")
+		ctx.JSON(400, gin.H{"error": "Invalid release date, expected YYYY-MM-DD"})
+		return
+	}
+
+	if err := m.service.ReplaceSong(ctx.Request.Context(), id, &song); err != nil {
+		m.respondUpdateError(ctx, err)
+		return
+	}
+
+	m.respondSong(ctx, id)
+}
+
+// @Summary Partially update song by ID
+// @Description Updates only the provided fields. Renaming a song or moving it to another group (by group_id or by name) is allowed.
+// @Description lrc replaces the text with time-synced lyrics and cannot be combined with text.
+// @Tags songs by id
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param song body models.SongPatch true "Fields to update"
+// @Success 200 {object} models.Song "Updated song"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 409 {object} models.ErrorResponse "Song with this group and name already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id} [patch]
+func (m *MusicLibController) PatchSong(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var patch models.SongPatch
+	if err := ctx.ShouldBindJSON(&patch); err != nil {
+		m.log(ctx).Error("PatchSong: invalid parameters")
+		ctx.JSON(400, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if (patch.Group != nil && *patch.Group == "") || (patch.Song != nil && *patch.Song == "") {
+		ctx.JSON(400, gin.H{"error": "Group and song must not be empty"})
+		return
+	}
+
+	if patch.GroupID != nil && patch.Group != nil {
+		ctx.JSON(400, gin.H{"error": "Only one of group and group_id may be set"})
+		return
+	}
+
+	if patch.Text != nil && patch.LRC != nil {
+		ctx.JSON(400, gin.H{"error": "Only one of text and lrc may be set"})
+		return
+	}
+
+	if patch.ReleaseDate != nil && !m.service.IsValidDate(*patch.ReleaseDate) {
+		m.log(ctx).Error("PatchSong: invalid parameter release date")
+		ctx.JSON(400, gin.H{"error": "Invalid release date, expected YYYY-MM-DD"})
+		return
+	}
+
+	if err := m.service.PatchSong(ctx.Request.Context(), id, &patch); err != nil {
+		m.respondUpdateError(ctx, err)
+		return
+	}
+
+	m.respondSong(ctx, id)
+}
+
+// @Summary Delete song by ID
+// @Description Moves the song to the trash; it can be restored with POST /trash/{id}/restore until it is purged.
+// @Tags songs by id
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.ErrorResponse "Song deleted successfully"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid song ID"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id} [delete]
+func (m *MusicLibController) DeleteSongByID(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := m.service.DeleteSongByID(ctx.Request.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(404, gin.H{"error": "Song not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "Song deleted successfully"})
+}
+
+// @Summary Get song text by ID
+// @Description Fetches the lyrics of a song with pagination by verses or by lines.
+// @Tags songs by id
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param by query string false "Pagination unit: verse or line" default(verse)
+// @Param limit query int false "Number of verses or lines to return" default(15)
+// @Param offset query int false "Offset from the beginning" default(0)
+// @Success 200 {object} models.SongTextResp "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/text [get]
+func (m *MusicLibController) GetSongTextByID(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, offset, err := parseLimitOffset(ctx)
+	if err != nil {
+		m.log(ctx).Error("GetSongTextByID: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	by, err := parseTextUnit(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	text, err := m.service.GetSongTextByID(ctx.Request.Context(), id, by, limit, offset)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(404, gin.H{"error": "Song not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(200, text)
+}
+
+// @Summary Get a single verse
+// @Description Returns verse number n (starting from 1) of the song with its type; for a repeat repeat_of is the position of the original verse.
+// @Tags songs by id
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param n path int true "Verse number, starting from 1"
+// @Success 200 {object} models.VerseResp "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Verse not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/verses/{n} [get]
+func (m *MusicLibController) GetVerse(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	n, err := parseID(ctx, "n")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	verse, err := m.service.GetVerse(ctx.Request.Context(), id, int(n))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(404, gin.H{"error": "Verse not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(200, verse)
+}
+
+func (m *MusicLibController) respondUpdateError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		ctx.JSON(404, gin.H{"error": "Song not found"})
+	case errors.Is(err, repository.ErrDuplicate):
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Song with this group and name already exists"})
+	case errors.Is(err, repository.ErrGroupNotFound):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Group not found"})
+	case errors.Is(err, service.ErrInvalidLRC), errors.Is(err, service.ErrInvalidVerses):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(500, gin.H{"error": "Internal server error"})
+	}
+}
+
+func (m *MusicLibController) respondSong(ctx *gin.Context, id uint) {
+	song, err := m.service.GetSongByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(500, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, song)
+}
+
+// parseID читает положительный целочисленный параметр пути
+func parseID(ctx *gin.Context, param string) (uint, error) {
+	id, err := strconv.ParseUint(ctx.Param(param), 10, 32)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid '%s' parameter", param)
+	}
+
+	return uint(id), nil
+}
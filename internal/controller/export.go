@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// exportFlushEvery - через сколько песен выгрузка отправляется клиенту
+const exportFlushEvery = 100
+
+// @Summary Export the library
+// @Description Streams all songs matching the filters as CSV, a JSON array or NDJSON.
+// @Description Songs are read with a database cursor, so the export is not loaded into memory.
+// @Description Takes the same filters as /songs. With lyrics=true verses are added (CSV: one "text" column, verses separated by an empty line).
+// @Tags export
+// @Produce json,text/csv,application/x-ndjson
+// @Param format query string false "Export format: csv, json or ndjson" default(json)
+// @Param lyrics query bool false "Include song verses" default(false)
+// @Param group_id query int false "Filter by group ID"
+// @Param album_id query int false "Filter by album ID"
+// @Param group query string false "Filter by group name"
+// @Param song query string false "Filter by song name"
+// @Param groupMatch query string false "Group name match mode: exact, prefix, contains or fuzzy" default(exact)
+// @Param songMatch query string false "Song name match mode: exact, prefix, contains or fuzzy" default(exact)
+// @Param similarity query number false "Minimum similarity (0..1] for fuzzy matching" default(0.3)
+// @Param link query string false "Filter by link"
+// @Param releaseDate query string false "Filter by exact release date (YYYY-MM-DD)"
+// @Param startDate query string false "Filter by release date range start (YYYY-MM-DD)"
+// @Param endDate query string false "Filter by release date range end (YYYY-MM-DD)"
+// @Param sort query string false "Comma-separated sort keys, '-' prefix for descending order" default(-release_date,-id)
+// @Success 200 {array} models.ExportSong "Exported songs"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /export [get]
+func (m *MusicLibController) ExportSongs(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	format := ctx.DefaultQuery("format", service.ExportFormatJSON)
+
+	withText := false
+	if lyrics := ctx.Query("lyrics"); lyrics != "" {
+		var err error
+		if withText, err = strconv.ParseBool(lyrics); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'lyrics' parameter"})
+			return
+		}
+	}
+
+	enc, err := service.NewSongEncoder(ctx.Writer, format, withText)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseSongFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sort, err := service.ParseSongSort(ctx.Query("sort"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Заголовки отправляются вместе с первой песней: пока ничего не записано,
+	// ошибку выборки еще можно вернуть обычным ответом
+	started := false
+	begin := func() error {
+		started = true
+		ctx.Header("Content-Type", enc.ContentType())
+		ctx.Header("Content-Disposition", `attachment; filename="songs.`+format+`"`)
+		ctx.Status(http.StatusOK)
+		return enc.Begin()
+	}
+
+	count := 0
+	err = m.service.ExportSongs(ctx.Request.Context(), filter, sort, withText, func(song models.ExportSong) error {
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(song); err != nil {
+			return err
+		}
+
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := enc.Flush(); err != nil {
+				return err
+			}
+			ctx.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !started {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			return
+		}
+		// Статус уже отправлен, клиент получит оборванный документ
+		m.log(ctx).Error("ExportSongs: export interrupted: ", err)
+		return
+	}
+
+	if !started {
+		if err := begin(); err != nil {
+			m.log(ctx).Error("ExportSongs: ", err)
+			return
+		}
+	}
+	if err := enc.End(); err != nil {
+		m.log(ctx).Error("ExportSongs: ", err)
+		return
+	}
+
+	m.log(ctx).WithFields(logrus.Fields{"format": format, "songs": count}).Info("Library exported")
+}
@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// @Summary Create a group
+// @Description Creates a group (artist). Group names are unique regardless of case.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param group body models.Group true "Group data"
+// @Success 201 {object} models.Group "Group created"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 409 {object} models.ErrorResponse "Group already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /groups [post]
+func (m *MusicLibController) CreateGroup(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	var group models.Group
+	if err := ctx.ShouldBindJSON(&group); err != nil {
+		m.log(ctx).Error("CreateGroup: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := m.service.CreateGroup(ctx.Request.Context(), &group); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Group already exists"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, group)
+}
+
+// @Summary List groups
+// @Description Returns groups ordered by name with optional filters and pagination.
+// @Tags groups
+// @Produce json
+// @Param name query string false "Filter by group name (case-insensitive)"
+// @Param country query string false "Filter by country (case-insensitive)"
+// @Param limit query int false "Number of results to return" default(15)
+// @Param offset query int false "Offset from the beginning" default(0)
+// @Success 200 {array} models.Group "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /groups [get]
+func (m *MusicLibController) GetGroups(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	limit, offset, err := parseLimitOffset(ctx)
+	if err != nil {
+		m.log(ctx).Error("GetGroups: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := make(map[string]string)
+	if name := ctx.Query("name"); name != "" {
+		filter["name"] = name
+	}
+	if country := ctx.Query("country"); country != "" {
+		filter["country"] = country
+	}
+
+	groups, err := m.service.GetGroups(ctx.Request.Context(), filter, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	if groups == nil {
+		groups = []models.Group{}
+	}
+
+	ctx.JSON(200, groups)
+}
+
+// @Summary Get group by ID
+// @Tags groups
+// @Produce json
+// @Param id path int true "Group ID"
+// @Success 200 {object} models.Group "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid group ID"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Group not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /groups/{id} [get]
+func (m *MusicLibController) GetGroupByID(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := m.service.GetGroupByID(ctx.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(404, gin.H{"error": "Group not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(200, group)
+}
+
+// @Summary Update group
+// @Description Updates only the provided fields. Renaming a group also renames it in all of its songs.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param group body models.GroupPatch true "Fields to update"
+// @Success 200 {object} models.Group "Updated group"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 404 {object} models.ErrorResponse "Group not found"
+// @Failure 409 {object} models.ErrorResponse "Group with this name already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /groups/{id} [patch]
+func (m *MusicLibController) UpdateGroup(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var patch models.GroupPatch
+	if err := ctx.ShouldBindJSON(&patch); err != nil {
+		m.log(ctx).Error("UpdateGroup: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := m.service.UpdateGroup(ctx.Request.Context(), id, &patch); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			ctx.JSON(404, gin.H{"error": "Group not found"})
+		case errors.Is(err, repository.ErrDuplicate):
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Group with this name already exists"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}
+		return
+	}
+
+	group, err := m.service.GetGroupByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	ctx.JSON(200, group)
+}
+
+// @Summary Delete group
+// @Description Deletes a group. A group that still has songs or albums cannot be deleted.
+// @Tags groups
+// @Produce json
+// @Param id path int true "Group ID"
+// @Success 200 {object} models.ErrorResponse "Group deleted successfully"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid group ID"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Group not found"
+// @Failure 409 {object} models.ErrorResponse "Group still has songs or albums"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /groups/{id} [delete]
+func (m *MusicLibController) DeleteGroup(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := m.service.DeleteGroup(ctx.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			ctx.JSON(404, gin.H{"error": "Group not found"})
+		case errors.Is(err, repository.ErrInUse):
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Group still has songs or albums"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "Group deleted successfully"})
+}
+
+// @Summary List songs of a group
+// @Tags groups
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param limit query int false "Number of results to return" default(15)
+// @Param offset query int false "Offset from the beginning" default(0)
+// @Success 200 {array} models.Song "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Group not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /groups/{id}/songs [get]
+func (m *MusicLibController) GetGroupSongs(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, offset, err := parseLimitOffset(ctx)
+	if err != nil {
+		m.log(ctx).Error("GetGroupSongs: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	songs, err := m.service.GetGroupSongs(ctx.Request.Context(), id, limit, offset)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(404, gin.H{"error": "Group not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	if songs == nil {
+		songs = []models.Song{}
+	}
+
+	ctx.JSON(200, songs)
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mikromolekula2002/music_library_ver1.0/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxImportBodySize = 32 << 20
+	maxImportRows     = 10000
+)
+
+// @Summary Bulk import songs
+// @Description Imports songs from a CSV file (header: group,song,release_date,text,link) or NDJSON (one JSON object per line).
+// @Description Missing release date, text or link are fetched from the configured details providers (DETAILS_PROVIDERS) with bounded concurrency.
+// @Description The file can be sent as the raw request body or as the "file" field of a multipart form.
+// @Tags import
+// @Accept text/csv,application/x-ndjson,multipart/form-data
+// @Produce json
+// @Param format query string false "File format: csv or ndjson. Detected from Content-Type when omitted"
+// @Param file formData file false "Import file (multipart upload)"
+// @Success 200 {object} models.ImportReport "Per-row import report"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid file or format"
+// @Failure 413 {object} models.ErrorResponse "Request Entity Too Large: Too many rows"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /import [post]
+func (m *MusicLibController) ImportSongs(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBodySize)
+
+	body, format, err := importSource(ctx)
+	if err != nil {
+		m.log(ctx).Error("ImportSongs: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	rows, err := service.ParseImport(body, format)
+	if err != nil {
+		m.log(ctx).Error("ImportSongs: ", err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import file is too large"})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(rows) > maxImportRows {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Import is limited to %d rows", maxImportRows)})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, m.service.ImportSongs(ctx.Request.Context(), rows))
+}
+
+// importSource возвращает тело файла импорта и его формат
+func importSource(ctx *gin.Context) (io.ReadCloser, string, error) {
+	format := strings.ToLower(ctx.Query("format"))
+	contentType := ctx.ContentType()
+
+	body := ctx.Request.Body
+	if contentType == "multipart/form-data" {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			return nil, "", errors.New("missing multipart field: file")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		body = file
+
+		if format == "" {
+			switch {
+			case strings.HasSuffix(header.Filename, ".csv"):
+				format = service.ImportFormatCSV
+			case strings.HasSuffix(header.Filename, ".ndjson"), strings.HasSuffix(header.Filename, ".jsonl"):
+				format = service.ImportFormatNDJSON
+			}
+		}
+	}
+
+	if format == "" {
+		switch contentType {
+		case "text/csv":
+			format = service.ImportFormatCSV
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			format = service.ImportFormatNDJSON
+		default:
+			body.Close()
+			return nil, "", errors.New("unknown import format: set format=csv|ndjson or Content-Type")
+		}
+	}
+
+	if format != service.ImportFormatCSV && format != service.ImportFormatNDJSON {
+		body.Close()
+		return nil, "", service.ErrUnsupportedImportFormat
+	}
+
+	return body, format, nil
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"mikromolekula2002/music_library_ver1.0/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const maxLRCBodySize = 1 << 20
+
+// lyricsContentTypes - Content-Type ответа для каждого формата синхронизированного текста
+var lyricsContentTypes = map[string]string{
+	service.LyricsFormatLRC: "text/plain; charset=utf-8",
+	service.LyricsFormatSRT: "application/x-subrip; charset=utf-8",
+	service.LyricsFormatVTT: "text/vtt; charset=utf-8",
+}
+
+// @Summary Get time-synced lyrics as LRC
+// @Description Returns lines with [mm:ss.xx] timestamps, verses are separated by blank lines and typed verses start with a marker like [Chorus].
+// @Tags synced lyrics
+// @Produce plain
+// @Param id path int true "Song ID"
+// @Success 200 {string} string "LRC file"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid song ID"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song not found or lyrics are not time-synced"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/lyrics.lrc [get]
+func (m *MusicLibController) GetLyricsLRC(ctx *gin.Context) {
+	m.serveSyncedLyrics(ctx, service.LyricsFormatLRC)
+}
+
+// @Summary Get time-synced lyrics as SRT
+// @Description Returns one subtitle cue per line. A cue lasts until the next line starts, but at most 5 seconds.
+// @Tags synced lyrics
+// @Produce plain
+// @Param id path int true "Song ID"
+// @Success 200 {string} string "SubRip file"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid song ID"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song not found or lyrics are not time-synced"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/lyrics.srt [get]
+func (m *MusicLibController) GetLyricsSRT(ctx *gin.Context) {
+	m.serveSyncedLyrics(ctx, service.LyricsFormatSRT)
+}
+
+// @Summary Get time-synced lyrics as WebVTT
+// @Description Returns one cue per line. A cue lasts until the next line starts, but at most 5 seconds.
+// @Tags synced lyrics
+// @Produce plain
+// @Param id path int true "Song ID"
+// @Success 200 {string} string "WebVTT file"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid song ID"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song not found or lyrics are not time-synced"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/lyrics.vtt [get]
+func (m *MusicLibController) GetLyricsVTT(ctx *gin.Context) {
+	m.serveSyncedLyrics(ctx, service.LyricsFormatVTT)
+}
+
+func (m *MusicLibController) serveSyncedLyrics(ctx *gin.Context, format string) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lyrics, err := m.service.GetSyncedLyrics(ctx.Request.Context(), id, format)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+		case errors.Is(err, service.ErrNotSynced):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Song lyrics are not time-synced"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		}
+		return
+	}
+
+	ctx.Data(http.StatusOK, lyricsContentTypes[format], []byte(lyrics))
+}
+
+// @Summary Upload LRC lyrics
+// @Description Replaces the song text with a parsed LRC file (raw body or the "file" field of a multipart form).
+// @Description Blank lines separate verses, markers like [Chorus] set the verse type, lines with several timestamps
+// @Description are repeated at each of them and the offset tag shifts all timestamps.
+// @Tags synced lyrics
+// @Accept plain,multipart/form-data
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param file formData file false "LRC file (multipart upload)"
+// @Success 200 {object} models.SongVersesResp "Parsed verses"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid LRC"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song not found"
+// @Failure 413 {object} models.ErrorResponse "Request Entity Too Large"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/lyrics.lrc [put]
+func (m *MusicLibController) UploadLRC(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxLRCBodySize)
+
+	body := ctx.Request.Body
+	if ctx.ContentType() == "multipart/form-data" {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "missing multipart field: file"})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	lrc, err := io.ReadAll(body)
+	if err != nil {
+		m.log(ctx).Error("UploadLRC: ", err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "LRC file is too large"})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := m.service.SetSongLRC(ctx.Request.Context(), id, string(lrc)); err != nil {
+		m.respondUpdateError(ctx, err)
+		return
+	}
+
+	verses, err := m.service.GetSongVerses(ctx.Request.Context(), id, -1, 0)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, verses)
+}
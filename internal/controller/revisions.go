@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// parseRev читает номер ревизии из параметра пути rev
+func parseRev(ctx *gin.Context) (int, error) {
+	rev, err := strconv.Atoi(ctx.Param("rev"))
+	if err != nil || rev < 1 {
+		return 0, errors.New("invalid 'rev' parameter")
+	}
+	return rev, nil
+}
+
+func respondRevisionError(ctx *gin.Context, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Song or revision not found"})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+}
+
+// @Summary Get song revisions
+// @Description Returns immutable snapshots of the song, newest first, without their text.
+// @Description A revision is written on every change of the song data or lyrics, the author is the authenticated client
+// @Description (the X-Actor header when authentication is disabled).
+// @Tags song revisions
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param limit query int false "Number of revisions to return" default(15)
+// @Param offset query int false "Offset from the newest revision" default(0)
+// @Success 200 {array} models.SongRevision "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/revisions [get]
+func (m *MusicLibController) GetSongRevisions(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, offset, err := parseLimitOffset(ctx)
+	if err != nil {
+		m.log(ctx).Error("GetSongRevisions: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	revisions, err := m.service.GetSongRevisions(ctx.Request.Context(), id, limit, offset)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, revisions)
+}
+
+// @Summary Get song revision
+// @Description Returns the song data and verses as they were in the revision.
+// @Tags song revisions
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} models.SongRevision "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song or revision not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/revisions/{rev} [get]
+func (m *MusicLibController) GetSongRevision(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rev, err := parseRev(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	revision, err := m.service.GetSongRevision(ctx.Request.Context(), id, rev)
+	if err != nil {
+		respondRevisionError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, revision)
+}
+
+// @Summary Diff song revisions
+// @Description Compares the revision with an earlier one (the previous revision by default): changed fields
+// @Description and a line-level diff of the lyrics, where verse types are shown as markers like [Chorus].
+// @Tags song revisions
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param rev path int true "Revision number"
+// @Param from query int false "Revision to compare with, defaults to rev-1"
+// @Success 200 {object} models.RevisionDiff "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song or revision not found"
+// @Failure 422 {object} models.ErrorResponse "Unprocessable Entity: Revision texts are too large to diff"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/revisions/{rev}/diff [get]
+func (m *MusicLibController) DiffSongRevisions(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rev, err := parseRev(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from := 0
+	if fromStr := ctx.Query("from"); fromStr != "" {
+		from, err = strconv.Atoi(fromStr)
+		if err != nil || from < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'from' parameter"})
+			return
+		}
+	}
+
+	diff, err := m.service.DiffRevisions(ctx.Request.Context(), id, from, rev)
+	if err != nil {
+		if errors.Is(err, service.ErrDiffTooLarge) {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		respondRevisionError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, diff)
+}
+
+// @Summary Revert song to revision
+// @Description Restores the song data and lyrics from the revision. The revert is recorded as a new revision.
+// @Tags song revisions
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} models.Song "Reverted song"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song or revision not found"
+// @Failure 409 {object} models.ErrorResponse "Conflict: Another song already has the group and name of the revision"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/revisions/{rev}/revert [post]
+func (m *MusicLibController) RevertSong(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rev, err := parseRev(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := m.service.RevertSong(ctx.Request.Context(), id, rev); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondRevisionError(ctx, err)
+			return
+		}
+		m.respondUpdateError(ctx, err)
+		return
+	}
+
+	m.respondSong(ctx, id)
+}
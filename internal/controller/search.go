@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// @Summary Full-text search in lyrics
+// @Description Searches song verses and returns matches ranked by relevance, with the verse number (starting from 1) and highlighted fragments.
+// @Description highlight is HTML-escaped verse text in which only the matches are wrapped in <b>...</b>, so it is safe to render as HTML.
+// @Description websearch mode supports "quoted phrases", OR and -exclusions.
+// @Tags search
+// @Produce json
+// @Param q query string true "Search query"
+// @Param lang query string false "Text search language: simple, en (english) or ru (russian)" default(simple)
+// @Param mode query string false "Query mode: websearch, plain or phrase" default(websearch)
+// @Param limit query int false "Number of results to return" default(15)
+// @Param offset query int false "Offset from the beginning" default(0)
+// @Success 200 {array} models.SearchHit "Matching verses"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /search [get]
+func (m *MusicLibController) SearchLyrics(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	limit, offset, err := parseLimitOffset(ctx)
+	if err != nil {
+		m.log(ctx).Error("SearchLyrics: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := ctx.Query("q")
+	if query == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Missing required parameter: q"})
+		return
+	}
+
+	hits, err := m.service.SearchLyrics(ctx.Request.Context(), query, ctx.Query("lang"), ctx.Query("mode"), limit, offset)
+	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedLanguage) || errors.Is(err, service.ErrUnsupportedSearchMode) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(200, hits)
+}
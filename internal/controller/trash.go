@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// @Summary Get deleted songs
+// @Description Returns songs in the trash, most recently deleted first. Songs are purged for good
+// @Description after the retention period (TRASH_RETENTION).
+// @Tags trash
+// @Produce json
+// @Param limit query int false "Number of songs to return" default(15)
+// @Param offset query int false "Offset from the beginning" default(0)
+// @Success 200 {array} models.Song "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /trash [get]
+func (m *MusicLibController) GetDeletedSongs(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	limit, offset, err := parseLimitOffset(ctx)
+	if err != nil {
+		m.log(ctx).Error("GetDeletedSongs: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	songs, err := m.service.GetDeletedSongs(ctx.Request.Context(), limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, songs)
+}
+
+// @Summary Restore deleted song
+// @Description Moves the song out of the trash together with its text and revision history.
+// @Tags trash
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.Song "Restored song"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid song ID"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song is not in the trash"
+// @Failure 409 {object} models.ErrorResponse "Conflict: The group already has another song with this name"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /trash/{id}/restore [post]
+func (m *MusicLibController) RestoreSong(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := m.service.RestoreSong(ctx.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Song not found in trash"})
+		case errors.Is(err, repository.ErrDuplicate):
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Song with this group and name already exists"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		}
+		return
+	}
+
+	m.respondSong(ctx, id)
+}
+
+// @Summary Purge deleted song
+// @Description Deletes the song from the trash for good, together with its text and revisions.
+// @Tags trash
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.ErrorResponse "Song purged successfully"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid song ID"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song is not in the trash"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /trash/{id} [delete]
+func (m *MusicLibController) PurgeSong(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := m.service.PurgeSong(ctx.Request.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Song not found in trash"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Song purged successfully"})
+}
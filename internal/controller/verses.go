@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// parseTextUnit читает единицу пагинации текста: verse (по умолчанию) или line
+func parseTextUnit(ctx *gin.Context) (string, error) {
+	switch by := ctx.DefaultQuery("by", models.TextByVerse); by {
+	case models.TextByVerse, models.TextByLine:
+		return by, nil
+	default:
+		return "", errors.New("invalid 'by' parameter, expected verse or line")
+	}
+}
+
+// @Summary Get song verses
+// @Description Returns verses of the song in order with their position, type (verse, chorus, bridge, intro, outro)
+// @Description and repeat_of - the position of the original verse for repeats.
+// @Tags songs by id
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param limit query int false "Number of verses to return" default(15)
+// @Param offset query int false "Offset from the beginning" default(0)
+// @Success 200 {object} models.SongVersesResp "Successful response"
+// @Failure 400 {object} models.ErrorResponse "Bad Request: Invalid parameters"
+// @Failure 404 {object} models.ErrorResponse "Not Found: Song not found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/verses [get]
+func (m *MusicLibController) GetSongVerses(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, offset, err := parseLimitOffset(ctx)
+	if err != nil {
+		m.log(ctx).Error("GetSongVerses: ", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	verses, err := m.service.GetSongVerses(ctx.Request.Context(), id, limit, offset)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, verses)
+}
+
+// @Summary Replace song verses
+// @Description Replaces the song text with explicitly typed verses. Positions are assigned in order;
+// @Description a repeat only needs repeat_of (position of an earlier original verse) and is stored once.
+// @Tags songs by id
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param verses body models.SongVersesReq true "Verses in order"
+// @Success 200 {object} models.SongVersesResp "Updated verses"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body or verses"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/verses [put]
+func (m *MusicLibController) SetSongVerses(ctx *gin.Context) {
+	m.log(ctx).WithFields(logrus.Fields{
+		"method": ctx.Request.Method,
+		"url":    ctx.Request.URL.String(),
+	}).Info("Handling request")
+
+	id, err := parseID(ctx, "id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.SongVersesReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		m.log(ctx).Error("SetSongVerses: invalid parameters")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := m.service.SetSongVerses(ctx.Request.Context(), id, req.Verses); err != nil {
+		m.respondUpdateError(ctx, err)
+		return
+	}
+
+	verses, err := m.service.GetSongVerses(ctx.Request.Context(), id, len(req.Verses), 0)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, verses)
+}
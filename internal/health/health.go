@@ -0,0 +1,146 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultCheckTimeout ограничивает время одной проверки готовности
+const defaultCheckTimeout = 2 * time.Second
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckFunc - проверка зависимости; ошибка означает, что сервис не готов принимать запросы
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult - результат одной проверки
+type CheckResult struct {
+	Status     string  `json:"status"`
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report - ответ /readyz
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker выполняет проверки готовности и хранит признак завершения работы
+type Checker struct {
+	timeout      time.Duration
+	checks       []check
+	shuttingDown atomic.Bool
+}
+
+// NewChecker создает набор проверок; timeout <= 0 заменяется значением по умолчанию
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Add добавляет проверку; вызывается до запуска сервера
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// StartShutdown переводит сервис в состояние "не готов", чтобы балансировщик
+// перестал направлять запросы до остановки сервера
+func (c *Checker) StartShutdown() {
+	c.shuttingDown.Store(true)
+}
+
+// Ready выполняет все проверки параллельно; при завершении работы проверки не выполняются
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(c.checks)+1)}
+	if c.shuttingDown.Load() {
+		report.Status = StatusFail
+		report.Checks["shutdown"] = CheckResult{Status: StatusFail, Error: "server is shutting down"}
+		return report
+	}
+
+	results := make([]CheckResult, len(c.checks))
+	var wg sync.WaitGroup
+	for i, ch := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, ch.fn)
+		}()
+	}
+	wg.Wait()
+
+	for i, ch := range c.checks {
+		report.Checks[ch.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, fn CheckFunc) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := fn(ctx)
+	result := CheckResult{Status: StatusOK, DurationMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// MigrationCheck сравнивает примененную версию схемы с ожидаемой сборкой. Более новая схема
+// допустима: при поэтапном обновлении старые реплики работают со схемой новых.
+func MigrationCheck(version func(ctx context.Context) (uint, bool, error), expected uint) CheckFunc {
+	return func(ctx context.Context) error {
+		current, dirty, err := version(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d is dirty", current)
+		}
+		if current < expected {
+			return fmt.Errorf("schema version %d, expected %d", current, expected)
+		}
+		return nil
+	}
+}
+
+// LiveHandler отвечает 200, пока процесс способен обрабатывать запросы
+func (c *Checker) LiveHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
+
+// ReadyHandler отвечает 200, если все проверки прошли, иначе 503
+func (c *Checker) ReadyHandler(ctx *gin.Context) {
+	report := c.Ready(ctx.Request.Context())
+
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, report)
+}
@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReady(t *testing.T) {
+	c := NewChecker(50 * time.Millisecond)
+	if c.timeout != 50*time.Millisecond || NewChecker(0).timeout != defaultCheckTimeout {
+		t.Fatal("check timeout is not applied")
+	}
+
+	// Каждая проверка ждет начала другой, поэтому проходят они только при параллельном запуске
+	var started atomic.Int32
+	parallel := func(ctx context.Context) error {
+		started.Add(1)
+		for started.Load() < 2 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Millisecond):
+			}
+		}
+		return nil
+	}
+	c.Add("database", parallel)
+	c.Add("migrations", parallel)
+
+	report := c.Ready(context.Background())
+	if report.Status != StatusOK || len(report.Checks) != 2 || report.Checks["database"].Status != StatusOK || report.Checks["migrations"].Status != StatusOK {
+		t.Fatalf("report: %+v", report)
+	}
+
+	// Проверка, не уложившаяся в timeout, получает отмененный контекст
+	c.Add("music_info", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	report = c.Ready(context.Background())
+	if report.Status != StatusFail || report.Checks["music_info"].Error != context.DeadlineExceeded.Error() || report.Checks["database"].Status != StatusOK {
+		t.Fatalf("report with a hanging check: %+v", report)
+	}
+
+	c.StartShutdown()
+	report = c.Ready(context.Background())
+	if report.Status != StatusFail || len(report.Checks) != 1 || report.Checks["shutdown"].Status != StatusFail {
+		t.Fatalf("report during shutdown: %+v", report)
+	}
+}
+
+func TestMigrationCheck(t *testing.T) {
+	for _, tc := range []struct {
+		current uint
+		dirty   bool
+		err     error
+		ok      bool
+	}{
+		{current: 17, ok: true},
+		// Более новая схема допустима при поэтапном обновлении
+		{current: 18, ok: true},
+		{current: 16},
+		{current: 17, dirty: true},
+		{err: errors.New("relation \"schema_migrations\" does not exist")},
+	} {
+		check := MigrationCheck(func(context.Context) (uint, bool, error) { return tc.current, tc.dirty, tc.err }, 17)
+		if err := check(context.Background()); (err == nil) != tc.ok {
+			t.Errorf("version %d, dirty %v, error %v: %v", tc.current, tc.dirty, tc.err, err)
+		}
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	failing := errors.New("connection refused")
+	c := NewChecker(0)
+	c.Add("database", func(context.Context) error { return failing })
+
+	r := gin.New()
+	r.GET("/healthz", c.LiveHandler)
+	r.GET("/readyz", c.ReadyHandler)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusServiceUnavailable || report.Checks["database"].Error != failing.Error() {
+		t.Fatalf("readyz %d: %s", rec.Code, rec.Body)
+	}
+
+	// Живость не зависит от проверок готовности
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("healthz %d: %s", rec.Code, rec.Body)
+	}
+
+	c.checks[0].fn = func(context.Context) error { return nil }
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("readyz %d: %s", rec.Code, rec.Body)
+	}
+}
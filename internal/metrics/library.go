@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// libraryScrapeTimeout ограничивает время подсчета размера библиотеки при сборе метрик
+const libraryScrapeTimeout = 5 * time.Second
+
+var (
+	songsDesc        = prometheus.NewDesc(namespace+"_songs", "Songs in the library, excluding the trash.", nil, nil)
+	deletedSongsDesc = prometheus.NewDesc(namespace+"_deleted_songs", "Songs in the trash.", nil, nil)
+	groupsDesc       = prometheus.NewDesc(namespace+"_groups", "Groups in the library.", nil, nil)
+	albumsDesc       = prometheus.NewDesc(namespace+"_albums", "Albums in the library.", nil, nil)
+	pendingJobsDesc  = prometheus.NewDesc(namespace+"_enrichment_jobs_pending", "Queued or running enrichment jobs.", nil, nil)
+	statsUpDesc      = prometheus.NewDesc(namespace+"_library_stats_up", "Whether the last library stats query succeeded.", nil, nil)
+)
+
+// libraryCollector запрашивает размер библиотеки при каждом сборе, а не по таймеру,
+// поэтому значения всегда актуальны и не нужен фоновый опрос
+type libraryCollector struct {
+	stats  func(ctx context.Context) (*models.LibraryStats, error)
+	logger *logrus.Logger
+}
+
+func (c *libraryCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{songsDesc, deletedSongsDesc, groupsDesc, albumsDesc, pendingJobsDesc, statsUpDesc} {
+		ch <- desc
+	}
+}
+
+func (c *libraryCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), libraryScrapeTimeout)
+	defer cancel()
+
+	stats, err := c.stats(ctx)
+	if err != nil {
+		c.logger.WithError(err).Warn("Failed to collect library stats")
+		ch <- prometheus.MustNewConstMetric(statsUpDesc, prometheus.GaugeValue, 0)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(statsUpDesc, prometheus.GaugeValue, 1)
+	ch <- prometheus.MustNewConstMetric(songsDesc, prometheus.GaugeValue, float64(stats.Songs))
+	ch <- prometheus.MustNewConstMetric(deletedSongsDesc, prometheus.GaugeValue, float64(stats.DeletedSongs))
+	ch <- prometheus.MustNewConstMetric(groupsDesc, prometheus.GaugeValue, float64(stats.Groups))
+	ch <- prometheus.MustNewConstMetric(albumsDesc, prometheus.GaugeValue, float64(stats.Albums))
+	ch <- prometheus.MustNewConstMetric(pendingJobsDesc, prometheus.GaugeValue, float64(stats.PendingJobs))
+}
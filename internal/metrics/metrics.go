@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/openapi"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const namespace = "music_library"
+
+// Metrics хранит реестр Prometheus и метрики HTTP, хранилища, music-info и библиотеки
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests       *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+	storeDuration      *prometheus.HistogramVec
+	musicInfoRequests  *prometheus.CounterVec
+	musicInfoDuration  *prometheus.HistogramVec
+	breakerTransitions *prometheus.CounterVec
+}
+
+// New создает реестр с метриками процесса и Go-рантайма
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		storeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "store_query_duration_seconds",
+			Help:      "Song store query latency by method and outcome.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"method", "outcome"}),
+		musicInfoRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "music_info_requests_total",
+			Help:      "Music info API request attempts by outcome.",
+		}, []string{"outcome"}),
+		musicInfoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "music_info_request_duration_seconds",
+			Help:      "Music info API request attempt latency by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+		breakerTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "music_info_breaker_transitions_total",
+			Help:      "Music info circuit breaker state changes by target state.",
+		}, []string{"to"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.storeDuration,
+		m.musicInfoRequests,
+		m.musicInfoDuration,
+		m.breakerTransitions,
+	)
+
+	return m
+}
+
+// Handler отдает метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Middleware считает запросы и их длительность; route - шаблон маршрута gin,
+// чтобы ID в пути не размножали ряды, для неизвестных путей - "unmatched"
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(ctx.Writer.Status())
+		m.httpRequests.WithLabelValues(ctx.Request.Method, route, status).Inc()
+		m.httpDuration.WithLabelValues(ctx.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveStore записывает длительность метода хранилища; подходит как repository.ObserveFunc
+func (m *Metrics) ObserveStore(method string, duration time.Duration, err error) {
+	m.storeDuration.WithLabelValues(method, storeOutcome(err)).Observe(duration.Seconds())
+}
+
+func storeOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, sql.ErrNoRows):
+		return "not_found"
+	case errors.Is(err, repository.ErrDuplicate):
+		return "conflict"
+	}
+	return "error"
+}
+
+// ObserveMusicInfo записывает итог и длительность попытки запроса к music-info;
+// подходит как openapi.ClientOptions.OnAttempt
+func (m *Metrics) ObserveMusicInfo(_ context.Context, duration time.Duration, err error) {
+	outcome := openapi.Outcome(err)
+	m.musicInfoRequests.WithLabelValues(outcome).Inc()
+	m.musicInfoDuration.WithLabelValues(outcome).Observe(duration.Seconds())
+}
+
+// BreakerStateChanged считает переходы выключателя; подходит как openapi.ClientOptions.OnStateChange
+func (m *Metrics) BreakerStateChanged(_, to openapi.BreakerState) {
+	m.breakerTransitions.WithLabelValues(to.String()).Inc()
+}
+
+// RegisterBreaker добавляет текущее состояние выключателя: 0 - closed, 1 - open, 2 - half-open
+func (m *Metrics) RegisterBreaker(state func() openapi.BreakerState) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "music_info_breaker_state",
+		Help:      "Music info circuit breaker state: 0 closed, 1 open, 2 half-open.",
+	}, func() float64 {
+		return float64(state())
+	}))
+}
+
+// RegisterDB добавляет статистику пула соединений с базой
+func (m *Metrics) RegisterDB(db *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// RegisterLibrary добавляет размер библиотеки, который считается при каждом сборе метрик
+func (m *Metrics) RegisterLibrary(stats func(ctx context.Context) (*models.LibraryStats, error), logger *logrus.Logger) {
+	m.registry.MustRegister(&libraryCollector{stats: stats, logger: logger})
+}
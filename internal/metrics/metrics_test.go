@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/openapi"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := New()
+
+	r := gin.New()
+	r.Use(m.Middleware())
+	r.GET("/songs/:id", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	for _, path := range []string{"/songs/1", "/songs/2", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// ID в пути не размножают ряды
+	if n := testutil.ToFloat64(m.httpRequests.WithLabelValues(http.MethodGet, "/songs/:id", "200")); n != 2 {
+		t.Fatalf("requests to /songs/:id: %v", n)
+	}
+	if n := testutil.ToFloat64(m.httpRequests.WithLabelValues(http.MethodGet, "unmatched", "404")); n != 1 {
+		t.Fatalf("unmatched requests: %v", n)
+	}
+	if n := testutil.CollectAndCount(m.httpDuration); n != 2 {
+		t.Fatalf("%d duration series", n)
+	}
+}
+
+func TestStoreOutcome(t *testing.T) {
+	for err, want := range map[error]string{
+		nil:           "ok",
+		sql.ErrNoRows: "not_found",
+		fmt.Errorf("repository.GetSong: %w", sql.ErrNoRows): "not_found",
+		repository.ErrDuplicate:                             "conflict",
+		errors.New("connection refused"):                    "error",
+	} {
+		if got := storeOutcome(err); got != want {
+			t.Errorf("storeOutcome(%v) = %s, want %s", err, got, want)
+		}
+	}
+
+	m := New()
+	m.ObserveStore("GetSongByID", time.Millisecond, sql.ErrNoRows)
+	m.ObserveStore("GetSongByID", time.Millisecond, nil)
+	if n := testutil.CollectAndCount(m.storeDuration); n != 2 {
+		t.Fatalf("%d store series", n)
+	}
+}
+
+func TestMusicInfoMetrics(t *testing.T) {
+	m := New()
+	m.ObserveMusicInfo(context.Background(), time.Millisecond, nil)
+	m.ObserveMusicInfo(context.Background(), time.Millisecond, nil)
+	if n := testutil.ToFloat64(m.musicInfoRequests.WithLabelValues(openapi.Outcome(nil))); n != 2 {
+		t.Fatalf("successful music-info requests: %v", n)
+	}
+
+	state := openapi.BreakerClosed
+	m.RegisterBreaker(func() openapi.BreakerState { return state })
+	m.BreakerStateChanged(openapi.BreakerClosed, openapi.BreakerOpen)
+	state = openapi.BreakerOpen
+	if n := testutil.ToFloat64(m.breakerTransitions.WithLabelValues("open")); n != 1 {
+		t.Fatalf("transitions to open: %v", n)
+	}
+
+	expected := `
+# HELP music_library_music_info_breaker_state Music info circuit breaker state: 0 closed, 1 open, 2 half-open.
+# TYPE music_library_music_info_breaker_state gauge
+music_library_music_info_breaker_state 1
+`
+	if err := testutil.GatherAndCompare(m.registry, strings.NewReader(expected), "music_library_music_info_breaker_state"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLibraryCollector(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	var statsErr error
+	m := New()
+	m.RegisterLibrary(func(ctx context.Context) (*models.LibraryStats, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("library stats are queried without a timeout")
+		}
+		return &models.LibraryStats{Songs: 3, DeletedSongs: 1, Groups: 2, Albums: 1, PendingJobs: 4}, statsErr
+	}, logger)
+
+	expected := `
+# HELP music_library_library_stats_up Whether the last library stats query succeeded.
+# TYPE music_library_library_stats_up gauge
+music_library_library_stats_up 1
+# HELP music_library_songs Songs in the library, excluding the trash.
+# TYPE music_library_songs gauge
+music_library_songs 3
+# HELP music_library_enrichment_jobs_pending Queued or running enrichment jobs.
+# TYPE music_library_enrichment_jobs_pending gauge
+music_library_enrichment_jobs_pending 4
+`
+	names := []string{"music_library_library_stats_up", "music_library_songs", "music_library_enrichment_jobs_pending"}
+	if err := testutil.GatherAndCompare(m.registry, strings.NewReader(expected), names...); err != nil {
+		t.Fatal(err)
+	}
+
+	// При ошибке отдается только признак неудачи, без устаревших значений
+	statsErr = errors.New("database is down")
+	expected = `
+# HELP music_library_library_stats_up Whether the last library stats query succeeded.
+# TYPE music_library_library_stats_up gauge
+music_library_library_stats_up 0
+`
+	if err := testutil.GatherAndCompare(m.registry, strings.NewReader(expected), names...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m := New()
+	m.ObserveStore("GetSongs", time.Millisecond, nil)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, name := range []string{"music_library_store_query_duration_seconds_count", "go_goroutines", "process_start_time_seconds"} {
+		if !strings.Contains(body, name) {
+			t.Errorf("/metrics has no %s", name)
+		}
+	}
+}
@@ -1,12 +1,25 @@
 package models
 
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
 type Song struct {
 	ID          uint   `json:"id" binding:"omitempty"`
+	GroupID     uint   `json:"group_id,omitempty" binding:"omitempty"`
 	Group       string `json:"group" binding:"required"`
 	Song        string `json:"song" binding:"required"`
 	ReleaseDate string `json:"release_date" binding:"omitempty"`
 	Text        string `json:"text" binding:"omitempty"`
 	Link        string `json:"link" binding:"omitempty"`
+	CreatedAt   string `json:"created_at,omitempty" binding:"omitempty"`
+	UpdatedAt   string `json:"updated_at,omitempty" binding:"omitempty"`
+	// EnrichmentStatus - состояние загрузки данных из внешнего API: pending, done или failed
+	EnrichmentStatus string `json:"enrichment_status,omitempty" binding:"omitempty"`
+	// DeletedAt - время перемещения в корзину, заполняется только в списке корзины
+	DeletedAt string `json:"deleted_at,omitempty" binding:"omitempty"`
 }
 
 type SongTextResp struct {
@@ -25,12 +38,16 @@ type ErrorResponse struct {
 type CreateSongReq struct {
 	Group string `json:"group" binding:"required"`
 	Song  string `json:"song" binding:"required"`
+	// LRC - необязательный текст с временными метками; тогда текст не загружается из внешних источников
+	LRC string `json:"lrc,omitempty"`
 }
 
 type CreateSongResp struct {
-	ID    uint   `json:"id" binding:"required"`
-	Group string `json:"group" binding:"required"`
-	Song  string `json:"song" binding:"required"`
+	ID               uint   `json:"id" binding:"required"`
+	Group            string `json:"group" binding:"required"`
+	Song             string `json:"song" binding:"required"`
+	EnrichmentStatus string `json:"enrichment_status"`
+	JobID            uint   `json:"job_id"`
 }
 
 type SongDetailResp struct {
@@ -44,3 +61,406 @@ type SongTextResponse struct {
 	Song  string   `json:"song"`
 	Text  []string `json:"text"`
 }
+
+// SongPatch - частичное обновление песни, изменяются только переданные поля
+type SongPatch struct {
+	GroupID     *uint   `json:"group_id"`
+	Group       *string `json:"group"`
+	Song        *string `json:"song"`
+	ReleaseDate *string `json:"release_date"`
+	Text        *string `json:"text"`
+	// LRC заменяет текст песни текстом с временными метками; нельзя передавать вместе с text
+	LRC  *string `json:"lrc"`
+	Link *string `json:"link"`
+}
+
+type VerseResp struct {
+	SongID   uint   `json:"song_id"`
+	Verse    int    `json:"verse"`
+	Type     string `json:"type,omitempty"`
+	RepeatOf int    `json:"repeat_of,omitempty"`
+	Text     string `json:"text"`
+}
+
+// Типы куплетов
+const (
+	VerseTypeVerse  = "verse"
+	VerseTypeChorus = "chorus"
+	VerseTypeBridge = "bridge"
+	VerseTypeIntro  = "intro"
+	VerseTypeOutro  = "outro"
+)
+
+// Единицы пагинации текста песни
+const (
+	TextByVerse = "verse"
+	TextByLine  = "line"
+)
+
+// Verse - куплет песни. Position начинается с 1. Повтор ссылается на позицию
+// исходного куплета в RepeatOf и хранится один раз, Type и Text берутся из исходного.
+// LineTimes - время начала каждой строки в миллисекундах, если текст синхронизирован;
+// у повтора свое время.
+type Verse struct {
+	Position  int     `json:"position"`
+	Type      string  `json:"type,omitempty"`
+	RepeatOf  int     `json:"repeat_of,omitempty"`
+	Text      string  `json:"text"`
+	LineTimes []int64 `json:"line_times,omitempty"`
+}
+
+// Marker возвращает метку типа куплета вида [Chorus] или пустую строку
+func (v Verse) Marker() string {
+	if v.Type == "" {
+		return ""
+	}
+	return "[" + strings.ToUpper(v.Type[:1]) + v.Type[1:] + "]"
+}
+
+// Rendered возвращает куплет текстом; тип записывается меткой в первой строке,
+// чтобы текст можно было снова разобрать на куплеты
+func (v Verse) Rendered() string {
+	if v.Type == "" {
+		return v.Text
+	}
+	return v.Marker() + "\n" + v.Text
+}
+
+type SongVersesResp struct {
+	ID     uint    `json:"id"`
+	Group  string  `json:"group"`
+	Song   string  `json:"song"`
+	Verses []Verse `json:"verses"`
+}
+
+// SongVersesReq - текст песни по куплетам. Позиции назначаются по порядку,
+// у повтора достаточно указать repeat_of.
+type SongVersesReq struct {
+	Verses []Verse `json:"verses" binding:"required"`
+}
+
+// Group - группа (исполнитель), к которой относятся песни
+type Group struct {
+	ID          uint   `json:"id" binding:"omitempty"`
+	Name        string `json:"name" binding:"required"`
+	Country     string `json:"country" binding:"omitempty,max=100"`
+	FormedYear  *int   `json:"formed_year,omitempty" binding:"omitempty,gte=1000,lte=9999"`
+	Description string `json:"description" binding:"omitempty"`
+}
+
+type GroupPatch struct {
+	Name        *string `json:"name" binding:"omitempty,min=1"`
+	Country     *string `json:"country" binding:"omitempty,max=100"`
+	FormedYear  *int    `json:"formed_year" binding:"omitempty,gte=1000,lte=9999"`
+	Description *string `json:"description"`
+}
+
+// Album - альбом группы (LP, EP или сингл)
+type Album struct {
+	ID          uint   `json:"id" binding:"omitempty"`
+	GroupID     uint   `json:"group_id" binding:"required"`
+	Title       string `json:"title" binding:"required"`
+	ReleaseDate string `json:"release_date,omitempty" binding:"omitempty"`
+	Type        string `json:"type" binding:"omitempty,oneof=LP EP single"`
+}
+
+type AlbumPatch struct {
+	Title       *string `json:"title" binding:"omitempty,min=1"`
+	ReleaseDate *string `json:"release_date"`
+	Type        *string `json:"type" binding:"omitempty,oneof=LP EP single"`
+}
+
+// AlbumTrack - песня альбома с ее номером в списке треков
+type AlbumTrack struct {
+	Position int `json:"position"`
+	Song
+}
+
+type AlbumTracksReq struct {
+	SongIDs []uint `json:"song_ids" binding:"required"`
+}
+
+// Языки и режимы полнотекстового поиска по текстам песен
+const (
+	SearchLangSimple  = "simple"
+	SearchLangEnglish = "english"
+	SearchLangRussian = "russian"
+
+	SearchModePlain     = "plain"
+	SearchModePhrase    = "phrase"
+	SearchModeWebsearch = "websearch"
+)
+
+type SearchQuery struct {
+	Query    string
+	Language string
+	Mode     string
+}
+
+// SearchHit - куплет, найденный полнотекстовым поиском. Highlight - фрагменты куплета,
+// экранированные как HTML, в которых совпадения обернуты в <b>...</b>
+type SearchHit struct {
+	SongID    uint    `json:"song_id"`
+	Group     string  `json:"group"`
+	Song      string  `json:"song"`
+	Verse     int     `json:"verse"`
+	Rank      float64 `json:"rank"`
+	Highlight string  `json:"highlight"`
+}
+
+// Режимы сравнения для фильтров группы и песни
+const (
+	MatchExact    = "exact"
+	MatchPrefix   = "prefix"
+	MatchContains = "contains"
+	MatchFuzzy    = "fuzzy"
+
+	DefaultSimilarity = "0.3"
+)
+
+// SongCursor - позиция в списке песен для keyset-пагинации: значения ключей
+// сортировки последней (или первой, если Backward) песни страницы
+type SongCursor struct {
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// Поля, по которым можно сортировать список песен
+const (
+	SortGroup       = "group"
+	SortSong        = "song"
+	SortReleaseDate = "release_date"
+	SortCreatedAt   = "created_at"
+	SortUpdatedAt   = "updated_at"
+	SortID          = "id"
+)
+
+// SortKey - ключ сортировки списка песен
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// SongPage - страница списка песен в режиме курсорной пагинации
+type SongPage struct {
+	Items      []Song `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+// SortValue возвращает значение поля сортировки песни в виде строки
+func (s Song) SortValue(field string) string {
+	switch field {
+	case SortGroup:
+		return s.Group
+	case SortSong:
+		return s.Song
+	case SortReleaseDate:
+		return s.ReleaseDate
+	case SortCreatedAt:
+		return s.CreatedAt
+	case SortUpdatedAt:
+		return s.UpdatedAt
+	case SortID:
+		return strconv.FormatUint(uint64(s.ID), 10)
+	}
+	return ""
+}
+
+// ImportRow - строка файла импорта; незаполненные дата, текст и ссылка
+// запрашиваются во внешнем API
+type ImportRow struct {
+	Row         int    `json:"-"`
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"release_date"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+	ParseError  string `json:"-"`
+}
+
+// Статусы строк импорта
+const (
+	ImportCreated   = "created"
+	ImportDuplicate = "duplicate"
+	ImportFailed    = "failed"
+)
+
+type ImportResult struct {
+	Row    int    `json:"row"`
+	Group  string `json:"group"`
+	Song   string `json:"song"`
+	Status string `json:"status"`
+	ID     uint   `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Sources - источник каждого поля, если часть данных подтягивалась из внешних источников
+	Sources map[string]string `json:"sources,omitempty"`
+}
+
+type ImportReport struct {
+	Created    int            `json:"created"`
+	Duplicates int            `json:"duplicates"`
+	Failed     int            `json:"failed"`
+	Results    []ImportResult `json:"results"`
+}
+
+// ExportSong - песня в выгрузке библиотеки; Verses заполняется, только если запрошен текст
+type ExportSong struct {
+	ID          uint     `json:"id"`
+	GroupID     uint     `json:"group_id,omitempty"`
+	Group       string   `json:"group"`
+	Song        string   `json:"song"`
+	ReleaseDate string   `json:"release_date"`
+	Link        string   `json:"link"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
+	Verses      []string `json:"verses,omitempty"`
+}
+
+// Статусы обогащения песни данными внешнего API
+const (
+	EnrichmentPending = "pending"
+	EnrichmentDone    = "done"
+	EnrichmentFailed  = "failed"
+)
+
+// Статусы задачи обогащения
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// EnrichmentJob - фоновая задача загрузки даты выхода, ссылки и текста песни.
+// Для queued RunAt - время следующей попытки, для running - окончание аренды задачи.
+type EnrichmentJob struct {
+	ID          uint   `json:"id"`
+	SongID      uint   `json:"song_id"`
+	Status      string `json:"status"`
+	Attempts    int    `json:"attempts"`
+	MaxAttempts int    `json:"max_attempts"`
+	LastError   string `json:"last_error,omitempty"`
+	// Sources - источник каждого поля песни, заполненного задачей
+	Sources   map[string]string `json:"sources,omitempty"`
+	RunAt     string            `json:"run_at"`
+	CreatedAt string            `json:"created_at"`
+	UpdatedAt string            `json:"updated_at"`
+}
+
+// Поля SongDetails, для которых записывается источник
+const (
+	DetailReleaseDate = "release_date"
+	DetailText        = "text"
+	DetailLink        = "link"
+)
+
+// SongDetails - дата выхода, текст и ссылка песни из внешних источников.
+// Sources - какой источник дал каждое заполненное поле.
+type SongDetails struct {
+	ReleaseDate string            `json:"release_date"`
+	Text        string            `json:"text"`
+	Link        string            `json:"link"`
+	Sources     map[string]string `json:"sources,omitempty"`
+}
+
+// SongRevision - неизменяемый снимок данных и текста песни после очередного изменения.
+// В списке ревизий Verses не заполняется.
+type SongRevision struct {
+	SongID      uint    `json:"song_id"`
+	Rev         int     `json:"rev"`
+	Author      string  `json:"author"`
+	CreatedAt   string  `json:"created_at"`
+	GroupID     uint    `json:"group_id,omitempty"`
+	Group       string  `json:"group"`
+	Song        string  `json:"song"`
+	ReleaseDate string  `json:"release_date"`
+	Link        string  `json:"link"`
+	Verses      []Verse `json:"verses,omitempty"`
+}
+
+// Операции строк в RevisionDiff
+const (
+	DiffEqual   = "equal"
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+)
+
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// FieldChange - значение поля до и после изменения
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// RevisionDiff - изменения между двумя ревизиями песни: поля и построчный дифф текста
+type RevisionDiff struct {
+	SongID uint          `json:"song_id"`
+	From   int           `json:"from"`
+	To     int           `json:"to"`
+	Fields []FieldChange `json:"fields"`
+	Lines  []DiffLine    `json:"lines"`
+}
+
+// Действия в журнале аудита
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// Источники изменений в журнале аудита
+const (
+	AuditSourceAPI        = "api"
+	AuditSourceImport     = "import"
+	AuditSourceEnrichment = "enrichment"
+	AuditSourceRetention  = "retention"
+)
+
+// ChangeMeta - кто и откуда вносит изменение; записывается в ревизии и журнал аудита
+type ChangeMeta struct {
+	Actor     string
+	RequestID string
+	Source    string
+}
+
+// AuditEntry - запись журнала аудита об изменении песни. Group и Song - название песни
+// на момент изменения, они остаются и после окончательного удаления песни.
+type AuditEntry struct {
+	ID        uint          `json:"id"`
+	SongID    uint          `json:"song_id"`
+	Group     string        `json:"group"`
+	Song      string        `json:"song"`
+	Action    string        `json:"action"`
+	Actor     string        `json:"actor"`
+	RequestID string        `json:"request_id,omitempty"`
+	Source    string        `json:"source"`
+	Changes   []FieldChange `json:"changes"`
+	CreatedAt string        `json:"created_at"`
+}
+
+// AuditFilter - условия выборки журнала аудита; нулевые значения не ограничивают выборку
+type AuditFilter struct {
+	SongID uint
+	Actor  string
+	From   time.Time
+	To     time.Time
+}
+
+// LibraryStats - размеры библиотеки для метрик
+type LibraryStats struct {
+	Songs        int
+	DeletedSongs int
+	Groups       int
+	Albums       int
+	PendingJobs  int
+}
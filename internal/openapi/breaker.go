@@ -0,0 +1,135 @@
+package openapi
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// BreakerState - состояние автоматического выключателя запросов к music-info
+type BreakerState int
+
+const (
+	// BreakerClosed - запросы проходят, ошибки подряд считаются
+	BreakerClosed BreakerState = iota
+	// BreakerOpen - запросы сразу отклоняются до окончания паузы
+	BreakerOpen
+	// BreakerHalfOpen - пропускается один пробный запрос, его результат решает, закрыться или открыться снова
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// ErrCircuitOpen возвращается без обращения к music-info, пока выключатель открыт
+var ErrCircuitOpen = errors.New("music info circuit breaker is open")
+
+// breaker открывается после threshold неудачных запросов подряд и через cooldown
+// пропускает один пробный запрос. onChange вызывается синхронно под блокировкой
+// выключателя и не должен обращаться к нему.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	onChange  func(from, to BreakerState)
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration, onChange func(from, to BreakerState)) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown, onChange: onChange}
+}
+
+// allow сообщает, можно ли выполнить запрос. Разрешенный запрос должен
+// завершиться вызовом done.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// done учитывает результат запроса; failed - ошибка, говорящая о недоступности music-info
+func (b *breaker) done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+		if failed {
+			b.open()
+		} else {
+			b.failures = 0
+			b.setState(BreakerClosed)
+		}
+		return
+	}
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerClosed && b.failures >= b.threshold {
+		b.open()
+	}
+}
+
+// release завершает запрос, прерванный вызывающей стороной, не учитывая его результат
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+	}
+}
+
+func (b *breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *breaker) open() {
+	b.openedAt = time.Now()
+	b.setState(BreakerOpen)
+}
+
+func (b *breaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+	from := b.state
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(from, state)
+	}
+}
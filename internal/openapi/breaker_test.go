@@ -0,0 +1,78 @@
+package openapi
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	var changes []string
+	b := newBreaker(2, 20*time.Millisecond, func(from, to BreakerState) {
+		changes = append(changes, from.String()+"->"+to.String())
+	})
+
+	// Успешный запрос сбрасывает счетчик ошибок подряд
+	for _, failed := range []bool{true, false, true} {
+		if err := b.allow(); err != nil {
+			t.Fatal(err)
+		}
+		b.done(failed)
+	}
+	if b.State() != BreakerClosed {
+		t.Fatalf("state after non-consecutive failures: %v", b.State())
+	}
+
+	b.allow()
+	b.done(true)
+	if b.State() != BreakerOpen {
+		t.Fatalf("state after %d failures in a row: %v", b.threshold, b.State())
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow while open: %v", err)
+	}
+
+	// После паузы проходит ровно один пробный запрос; неудача снова открывает выключатель
+	time.Sleep(30 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second request while probing: %v", err)
+	}
+	b.done(true)
+	if b.State() != BreakerOpen {
+		t.Fatalf("state after a failed probe: %v", b.State())
+	}
+
+	// Отмененный пробный запрос не решает судьбу выключателя
+	time.Sleep(30 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	b.release()
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("state after a released probe: %v", b.State())
+	}
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe after release: %v", err)
+	}
+	b.done(false)
+	if b.State() != BreakerClosed {
+		t.Fatalf("state after a successful probe: %v", b.State())
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if !slices.Equal(changes, want) {
+		t.Fatalf("state changes: %v, want %v", changes, want)
+	}
+}
+
+func TestBreakerStateString(t *testing.T) {
+	for state, want := range map[BreakerState]string{BreakerClosed: "closed", BreakerOpen: "open", BreakerHalfOpen: "half-open", 7: "unknown"} {
+		if state.String() != want {
+			t.Errorf("%d.String() = %q, want %q", state, state.String(), want)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package openapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	openapiMusic "mikromolekula2002/music_library_ver1.0/apiAutoGenerated/MusicInfo"
+)
+
+// ClientOptions - настройки устойчивого клиента music-info; нулевые значения заменяются значениями по умолчанию
+type ClientOptions struct {
+	// Timeout ограничивает одну попытку запроса
+	Timeout time.Duration
+	// MaxRetries - число повторов после первой попытки при 5xx и сетевых ошибках;
+	// отрицательное значение отключает повторы
+	MaxRetries int
+	// RetryBase и RetryMax задают экспоненциальную паузу между повторами: случайное
+	// значение от 0 до min(RetryMax, RetryBase*2^n)
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// BreakerThreshold - число неудачных запросов подряд, после которого выключатель открывается
+	BreakerThreshold int
+	// BreakerCooldown - сколько выключатель остается открытым до пробного запроса
+	BreakerCooldown time.Duration
+
+	// OnStateChange вызывается при каждой смене состояния выключателя (для логов и метрик)
+	OnStateChange func(from, to BreakerState)
+	// OnRetry вызывается перед каждым повтором с контекстом запроса, номером неудачной попытки (с 1) и ее ошибкой
+	OnRetry func(ctx context.Context, attempt int, err error, delay time.Duration)
+	// OnAttempt вызывается после каждой попытки запроса, в том числе отклоненной выключателем,
+	// с ее длительностью и ошибкой (см. Outcome)
+	OnAttempt func(ctx context.Context, duration time.Duration, err error)
+}
+
+const (
+	defaultTimeout          = 5 * time.Second
+	defaultMaxRetries       = 2
+	defaultRetryBase        = 200 * time.Millisecond
+	defaultRetryMax         = 2 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+func (o ClientOptions) withDefaults() ClientOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTimeout
+	}
+	switch {
+	case o.MaxRetries == 0:
+		o.MaxRetries = defaultMaxRetries
+	case o.MaxRetries < 0:
+		o.MaxRetries = 0
+	}
+	if o.RetryBase <= 0 {
+		o.RetryBase = defaultRetryBase
+	}
+	if o.RetryMax < o.RetryBase {
+		o.RetryMax = max(defaultRetryMax, o.RetryBase)
+	}
+	if o.BreakerThreshold <= 0 {
+		o.BreakerThreshold = defaultBreakerThreshold
+	}
+	if o.BreakerCooldown <= 0 {
+		o.BreakerCooldown = defaultBreakerCooldown
+	}
+	return o
+}
+
+// StatusError - music-info ответил статусом, отличным от 2xx
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return "music info responded with " + e.Status
+}
+
+// ErrMalformedResponse - music-info вернул 2xx с телом, которое не удалось разобрать
+var ErrMalformedResponse = errors.New("malformed music info response")
+
+// IsNotFound сообщает, что music-info не знает запрошенную песню
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// IsPermanent сообщает, что повтор запроса не изменит результат: 4xx или некорректный ответ
+func IsPermanent(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode < 500 && statusErr.StatusCode != http.StatusTooManyRequests
+	}
+	return errors.Is(err, ErrMalformedResponse)
+}
+
+// Outcome возвращает итог попытки запроса к music-info для метрик: success, not_found,
+// client_error, rate_limited, server_error, malformed, circuit_open, canceled, timeout или network_error
+func Outcome(err error) string {
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		return "success"
+	case errors.As(err, &statusErr):
+		switch {
+		case statusErr.StatusCode == http.StatusNotFound:
+			return "not_found"
+		case statusErr.StatusCode == http.StatusTooManyRequests:
+			return "rate_limited"
+		case statusErr.StatusCode >= 500:
+			return "server_error"
+		}
+		return "client_error"
+	case errors.Is(err, ErrMalformedResponse):
+		return "malformed"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), isTimeout(err):
+		return "timeout"
+	}
+	return "network_error"
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// MusicInfoClient оборачивает сгенерированный клиент music-info тайм-аутами,
+// повторами с экспоненциальной паузой и автоматическим выключателем
+type MusicInfoClient struct {
+	api     *openapiMusic.APIClient
+	opts    ClientOptions
+	breaker *breaker
+}
+
+func NewMusicInfoClient(musicAPIHost, musicBaseURL string, opts ClientOptions) *MusicInfoClient {
+	opts = opts.withDefaults()
+
+	api := MakeMusicAPIClient(musicAPIHost, musicBaseURL)
+	api.GetConfig().HTTPClient.Timeout = opts.Timeout
+
+	return &MusicInfoClient{
+		api:     api,
+		opts:    opts,
+		breaker: newBreaker(opts.BreakerThreshold, opts.BreakerCooldown, opts.OnStateChange),
+	}
+}
+
+// BreakerState возвращает текущее состояние выключателя
+func (c *MusicInfoClient) BreakerState() BreakerState {
+	return c.breaker.State()
+}
+
+// Ping проверяет, что music-info отвечает: любой ответ, кроме 5xx, считается успехом.
+// Проверка идет мимо выключателя и повторов, чтобы не влиять на их состояние.
+func (c *MusicInfoClient) Ping(ctx context.Context) error {
+	base, err := c.api.GetConfig().ServerURLWithContext(ctx, "DefaultAPIService.InfoGet")
+	if err != nil {
+		return err
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(pingCtx, http.MethodGet, base+"/info", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.api.GetConfig().HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
+
+// GetSongDetail запрашивает данные песни. 5xx, 429 и сетевые ошибки повторяются до
+// MaxRetries раз; пока выключатель открыт, сразу возвращается ErrCircuitOpen.
+func (c *MusicInfoClient) GetSongDetail(ctx context.Context, group, song string) (*openapiMusic.SongDetail, error) {
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		detail, err := c.attempt(ctx, group, song)
+		if c.opts.OnAttempt != nil {
+			c.opts.OnAttempt(ctx, time.Since(start), err)
+		}
+		if err == nil || IsPermanent(err) || errors.Is(err, ErrCircuitOpen) || ctx.Err() != nil || attempt > c.opts.MaxRetries {
+			return detail, err
+		}
+
+		delay := c.retryDelay(attempt)
+		if c.opts.OnRetry != nil {
+			c.opts.OnRetry(ctx, attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *MusicInfoClient) attempt(ctx context.Context, group, song string) (*openapiMusic.SongDetail, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	detail, resp, err := c.api.DefaultAPI.InfoGet(attemptCtx).Group(group).Song(song).Execute()
+	switch {
+	case err == nil:
+		c.breaker.done(false)
+		return detail, nil
+	case ctx.Err() != nil:
+		// Запрос отменил вызывающий, о состоянии music-info это ничего не говорит
+		c.breaker.release()
+		return nil, err
+	case resp == nil:
+		c.breaker.done(true)
+		return nil, err
+	case resp.StatusCode >= 300:
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		c.breaker.done(statusErr.StatusCode >= 500)
+		return nil, statusErr
+	default:
+		c.breaker.done(false)
+		return nil, fmt.Errorf("%w: %v", ErrMalformedResponse, err)
+	}
+}
+
+// retryDelay - пауза перед повтором после attempt неудачных попыток ("full jitter")
+func (c *MusicInfoClient) retryDelay(attempt int) time.Duration {
+	ceiling := c.opts.RetryBase
+	for i := 1; i < attempt && ceiling < c.opts.RetryMax; i++ {
+		ceiling *= 2
+	}
+	ceiling = min(ceiling, c.opts.RetryMax)
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
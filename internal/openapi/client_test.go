@@ -0,0 +1,179 @@
+package openapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient возвращает клиент music-info, который ходит в handler
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ClientOptions) *MusicInfoClient {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.RetryBase == 0 {
+		opts.RetryBase = time.Millisecond
+	}
+	return NewMusicInfoClient(u.Host, srv.URL, opts)
+}
+
+const uprisingDetail = `{"releaseDate": "07.09.2009", "text": "Paranoia is in bloom", "link": "https://example.com/uprising"}`
+
+func TestGetSongDetailRetries(t *testing.T) {
+	var calls atomic.Int32
+	var retries []int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/info" || r.URL.Query().Get("group") != "Muse" || r.URL.Query().Get("song") != "Uprising" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(uprisingDetail))
+		}
+	}, ClientOptions{
+		OnRetry: func(_ context.Context, attempt int, _ error, _ time.Duration) { retries = append(retries, attempt) },
+	})
+
+	detail, err := c.GetSongDetail(context.Background(), "Muse", "Uprising")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.ReleaseDate != "07.09.2009" || detail.Link != "https://example.com/uprising" {
+		t.Fatalf("detail: %+v", detail)
+	}
+	if calls.Load() != 3 || len(retries) != 2 {
+		t.Fatalf("%d calls, retries %v", calls.Load(), retries)
+	}
+	if c.BreakerState() != BreakerClosed {
+		t.Fatalf("breaker: %v", c.BreakerState())
+	}
+}
+
+func TestGetSongDetailPermanentErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		status  int
+		body    string
+		outcome string
+	}{
+		{"not found", http.StatusNotFound, "", "not_found"},
+		{"bad request", http.StatusBadRequest, "", "client_error"},
+		{"malformed", http.StatusOK, `{"text": 1}`, "malformed"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}, ClientOptions{})
+
+			_, err := c.GetSongDetail(context.Background(), "Muse", "Uprising")
+			if !IsPermanent(err) || Outcome(err) != tc.outcome {
+				t.Fatalf("error %v: permanent %v, outcome %s", err, IsPermanent(err), Outcome(err))
+			}
+			if IsNotFound(err) != (tc.status == http.StatusNotFound) {
+				t.Fatalf("IsNotFound(%v) = %v", err, IsNotFound(err))
+			}
+			if calls.Load() != 1 {
+				t.Fatalf("permanent error was retried: %d calls", calls.Load())
+			}
+		})
+	}
+}
+
+func TestGetSongDetailOpensBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var outcomes []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}, ClientOptions{
+		MaxRetries:       -1,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Hour,
+		OnAttempt:        func(_ context.Context, _ time.Duration, err error) { outcomes = append(outcomes, Outcome(err)) },
+	})
+
+	for i := 0; i < 3; i++ {
+		c.GetSongDetail(context.Background(), "Muse", "Uprising")
+	}
+	_, err := c.GetSongDetail(context.Background(), "Muse", "Uprising")
+	if !errors.Is(err, ErrCircuitOpen) || c.BreakerState() != BreakerOpen {
+		t.Fatalf("error %v, breaker %v", err, c.BreakerState())
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("open breaker let requests through: %d calls", calls.Load())
+	}
+	if len(outcomes) != 4 || outcomes[1] != "server_error" || outcomes[3] != "circuit_open" {
+		t.Fatalf("outcomes: %v", outcomes)
+	}
+
+	// Ping идет мимо выключателя
+	if err := c.Ping(context.Background()); err == nil || calls.Load() != 3 {
+		t.Fatalf("Ping: %v, %d calls", err, calls.Load())
+	}
+}
+
+func TestGetSongDetailCanceled(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}, ClientOptions{RetryBase: time.Hour, BreakerThreshold: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.GetSongDetail(ctx, "Muse", "Uprising")
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > time.Second {
+		t.Fatalf("error %v after %v", err, time.Since(start))
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{nil, "success"},
+		{&StatusError{StatusCode: http.StatusTooManyRequests}, "rate_limited"},
+		{&StatusError{StatusCode: http.StatusBadGateway}, "server_error"},
+		{ErrCircuitOpen, "circuit_open"},
+		{context.Canceled, "canceled"},
+		{context.DeadlineExceeded, "timeout"},
+		{errors.New("connection refused"), "network_error"},
+	} {
+		if got := Outcome(tc.err); got != tc.want {
+			t.Errorf("Outcome(%v) = %s, want %s", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	c := &MusicInfoClient{opts: ClientOptions{RetryBase: 10 * time.Millisecond, RetryMax: 25 * time.Millisecond}}
+
+	for attempt, ceiling := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 5: 25 * time.Millisecond} {
+		for i := 0; i < 100; i++ {
+			if d := c.retryDelay(attempt); d < 0 || d > ceiling {
+				t.Fatalf("retryDelay(%d) = %v, want [0, %v]", attempt, d, ceiling)
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryBackendTake(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemoryBackend()
+	m.now = func() time.Time { return now }
+
+	// Новая корзина полная: 3 токена, пополнение 1 токен в секунду
+	for want := 2.0; want >= 0; want-- {
+		allowed, tokens, err := m.Take("read:ip:1", 3, 1, 1)
+		if err != nil || !allowed || tokens != want {
+			t.Fatalf("Take = %v, %v, %v, want %v tokens", allowed, tokens, err, want)
+		}
+	}
+	if allowed, tokens, _ := m.Take("read:ip:1", 3, 1, 1); allowed || tokens != 0 {
+		t.Fatalf("Take from an empty bucket = %v, %v", allowed, tokens)
+	}
+	// Корзины разных ключей независимы
+	if allowed, _, _ := m.Take("read:ip:2", 3, 1, 1); !allowed {
+		t.Fatal("another key is limited")
+	}
+
+	now = now.Add(1500 * time.Millisecond)
+	if allowed, tokens, _ := m.Take("read:ip:1", 3, 1, 0); !allowed || tokens != 1.5 {
+		t.Fatalf("Take(0) after 1.5s = %v, %v", allowed, tokens)
+	}
+	// Пополнение не превышает емкость
+	now = now.Add(time.Hour)
+	if allowed, tokens, _ := m.Take("read:ip:1", 3, 1, 1); !allowed || tokens != 2 {
+		t.Fatalf("Take after an hour = %v, %v", allowed, tokens)
+	}
+}
+
+func TestMemoryBackendSweep(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemoryBackend()
+	m.now = func() time.Time { return now }
+
+	m.Take("old", 3, 1, 1)
+	now = now.Add(time.Minute)
+	m.Take("new", 3, 1, 1)
+
+	if swept, err := m.Sweep(30 * time.Second); err != nil || swept != 1 {
+		t.Fatalf("Sweep = %d, %v", swept, err)
+	}
+	if _, ok := m.buckets["new"]; !ok || len(m.buckets) != 1 {
+		t.Fatalf("buckets after sweep: %v", m.buckets)
+	}
+}
@@ -0,0 +1,228 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Class - группа маршрутов с общим бюджетом запросов
+type Class string
+
+const (
+	// Read - маршруты чтения
+	Read Class = "read"
+	// Write - маршруты, изменяющие данные
+	Write Class = "write"
+	// Upstream - маршруты, которые обращаются к music-info и другим источникам данных песен
+	Upstream Class = "upstream"
+	// Auth - неудачные попытки аутентификации с одного IP; ограничивает подбор ключей и токенов
+	Auth Class = "auth"
+)
+
+// Limit - бюджет Requests запросов за Period. Это же емкость корзины токенов:
+// неизрасходованный бюджет копится, но не больше Requests.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit разбирает бюджет вида "60/1m"; пустая строка означает отсутствие ограничения
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Limit{}, nil
+	}
+
+	requests, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("ratelimit: limit %q must look like 60/1m", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid number of requests in %q", s)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid period in %q", s)
+	}
+
+	return Limit{Requests: n, Period: d}, nil
+}
+
+func (l Limit) enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// rate - скорость пополнения корзины в токенах в секунду
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Backend хранит корзины токенов. Take пополняет корзину key с емкостью burst со скоростью
+// rate токенов в секунду и, если в ней есть целый токен, забирает n токенов (0 - только проверить).
+// Возвращает, был ли целый токен, и сколько токенов осталось. Sweep удаляет корзины,
+// не менявшиеся дольше idle.
+type Backend interface {
+	Take(key string, burst int, rate float64, n int) (bool, float64, error)
+	Sweep(idle time.Duration) (int, error)
+}
+
+// Result - решение по запросу и данные для заголовков RateLimit-*
+type Result struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int
+	// Reset - через сколько корзина снова будет полной
+	Reset time.Duration
+	// RetryAfter - через сколько появится токен для отклоненного запроса
+	RetryAfter time.Duration
+}
+
+// Limiter ограничивает запросы клиентов отдельно по каждому классу маршрутов
+type Limiter struct {
+	backend Backend
+	limits  map[Class]Limit
+
+	// exhausted - корзины, в которых после последнего списания этим процессом не осталось
+	// целого токена. По ним Check отвечает, не обращаясь к backend.
+	mu        sync.Mutex
+	exhausted map[string]bucket
+	now       func() time.Time
+}
+
+// NewLimiter создает ограничитель; классы без бюджета в limits не ограничиваются
+func NewLimiter(backend Backend, limits map[Class]Limit) *Limiter {
+	return &Limiter{backend: backend, limits: limits, exhausted: make(map[string]bucket), now: time.Now}
+}
+
+// Allow расходует токен клиента client в классе class. Для класса без ограничения возвращает nil.
+func (l *Limiter) Allow(class Class, client string) (*Result, error) {
+	limit, ok := l.limits[class]
+	if !ok || !limit.enabled() {
+		return nil, nil
+	}
+
+	key := string(class) + ":" + client
+	allowed, tokens, err := l.backend.Take(key, limit.Requests, limit.rate(), 1)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	if tokens < 1 {
+		l.exhausted[key] = bucket{tokens: tokens, updated: l.now()}
+	} else {
+		delete(l.exhausted, key)
+	}
+	l.mu.Unlock()
+
+	return newResult(limit, allowed, tokens), nil
+}
+
+// Check сообщает, исчерпан ли бюджет клиента в классе class, не расходуя токен. Ответ строится
+// по последнему списанию в этом процессе, без обращения к backend, поэтому проверка ничего
+// не стоит тем, кто бюджет не расходует; другие реплики узнают об исчерпанном бюджете
+// при первом своем списании. Возвращает nil, если класс не ограничен или токен есть.
+func (l *Limiter) Check(class Class, client string) *Result {
+	limit, ok := l.limits[class]
+	if !ok || !limit.enabled() {
+		return nil
+	}
+
+	key := string(class) + ":" + client
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.exhausted[key]
+	if !ok {
+		return nil
+	}
+	tokens := math.Min(float64(limit.Requests), b.tokens+l.now().Sub(b.updated).Seconds()*limit.rate())
+	if tokens >= 1 {
+		delete(l.exhausted, key)
+		return nil
+	}
+	return newResult(limit, false, tokens)
+}
+
+func newResult(limit Limit, allowed bool, tokens float64) *Result {
+	res := &Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     secondsToDuration((float64(limit.Requests) - tokens) / limit.rate()),
+	}
+	if !allowed {
+		res.RetryAfter = secondsToDuration((1 - tokens) / limit.rate())
+	}
+	return res
+}
+
+// sweepExhausted забывает корзины, которые успели пополниться до целого токена
+func (l *Limiter) sweepExhausted() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, b := range l.exhausted {
+		class, _, _ := strings.Cut(key, ":")
+		limit := l.limits[Class(class)]
+		if b.tokens+l.now().Sub(b.updated).Seconds()*limit.rate() >= 1 {
+			delete(l.exhausted, key)
+		}
+	}
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Max(0, s) * float64(time.Second))
+}
+
+// RunSweeper периодически удаляет корзины, которые успели наполниться целиком и ничем
+// не отличаются от отсутствующих. Работает до отмены ctx; возвращаемая функция
+// дожидается завершения текущей очистки.
+func (l *Limiter) RunSweeper(ctx context.Context, logger *logrus.Logger) (wait func()) {
+	// Корзина наполняется полностью за период своего бюджета
+	var idle time.Duration
+	for _, limit := range l.limits {
+		if limit.enabled() && limit.Period > idle {
+			idle = limit.Period
+		}
+	}
+
+	done := make(chan struct{})
+	if idle == 0 {
+		close(done)
+		return func() { <-done }
+	}
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(max(idle, time.Minute))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			l.sweepExhausted()
+			swept, err := l.backend.Sweep(idle)
+			if err != nil {
+				logger.Error("Rate limit sweep failed: ", err)
+				continue
+			}
+			logger.WithFields(logrus.Fields{"buckets": swept}).Debug("Rate limit buckets swept")
+		}
+	}()
+
+	return func() { <-done }
+}
@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	for s, want := range map[string]Limit{
+		"":          {},
+		"60/1m":     {Requests: 60, Period: time.Minute},
+		" 5 / 10s ": {Requests: 5, Period: 10 * time.Second},
+	} {
+		if limit, err := ParseLimit(s); err != nil || limit != want {
+			t.Errorf("ParseLimit(%q) = %+v, %v", s, limit, err)
+		}
+	}
+	for _, s := range []string{"60", "0/1m", "-1/1m", "x/1m", "60/0s", "60/minute"} {
+		if _, err := ParseLimit(s); err == nil {
+			t.Errorf("ParseLimit(%q) succeeded", s)
+		}
+	}
+}
+
+// newTestLimiter возвращает ограничитель поверх корзин в памяти и часы, общие для обоих
+func newTestLimiter(limits map[Class]Limit) (*Limiter, *time.Time) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	backend := NewMemoryBackend()
+	backend.now = clock
+	l := NewLimiter(backend, limits)
+	l.now = clock
+	return l, &now
+}
+
+func TestLimiterAllow(t *testing.T) {
+	l, now := newTestLimiter(map[Class]Limit{Read: {Requests: 2, Period: 10 * time.Second}, Write: {}})
+
+	res, err := l.Allow(Read, "ip:1")
+	if err != nil || !res.Allowed || res.Remaining != 1 || res.Reset != 5*time.Second || res.RetryAfter != 0 {
+		t.Fatalf("first request: %+v, %v", res, err)
+	}
+	if res, _ = l.Allow(Read, "ip:1"); !res.Allowed || res.Remaining != 0 || res.Reset != 10*time.Second {
+		t.Fatalf("second request: %+v", res)
+	}
+	res, _ = l.Allow(Read, "ip:1")
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != 5*time.Second {
+		t.Fatalf("request over the budget: %+v", res)
+	}
+
+	*now = now.Add(5 * time.Second)
+	if res, _ = l.Allow(Read, "ip:1"); !res.Allowed {
+		t.Fatalf("request after refill: %+v", res)
+	}
+
+	// Классы без бюджета и неизвестные классы не ограничиваются
+	for _, class := range []Class{Write, Upstream} {
+		if res, err := l.Allow(class, "ip:1"); res != nil || err != nil {
+			t.Errorf("Allow(%s) = %+v, %v", class, res, err)
+		}
+	}
+}
+
+func TestLimiterCheck(t *testing.T) {
+	l, now := newTestLimiter(map[Class]Limit{Auth: {Requests: 2, Period: 10 * time.Second}})
+
+	if res := l.Check(Auth, "ip:1"); res != nil {
+		t.Fatalf("check of an unused budget: %+v", res)
+	}
+	l.Allow(Auth, "ip:1")
+	if res := l.Check(Auth, "ip:1"); res != nil {
+		t.Fatalf("check with a token left: %+v", res)
+	}
+
+	l.Allow(Auth, "ip:1")
+	res := l.Check(Auth, "ip:1")
+	if res == nil || res.Allowed || res.RetryAfter != 5*time.Second {
+		t.Fatalf("check of a spent budget: %+v", res)
+	}
+	// Проверка не расходует токены и не затрагивает других клиентов
+	if res := l.Check(Auth, "ip:2"); res != nil {
+		t.Fatalf("check of another client: %+v", res)
+	}
+
+	*now = now.Add(4 * time.Second)
+	if res := l.Check(Auth, "ip:1"); res == nil || res.RetryAfter.Round(time.Millisecond) != time.Second {
+		t.Fatalf("check before refill: %+v", res)
+	}
+	*now = now.Add(time.Second)
+	if res := l.Check(Auth, "ip:1"); res != nil {
+		t.Fatalf("check after refill: %+v", res)
+	}
+	if len(l.exhausted) != 0 {
+		t.Fatalf("refilled bucket is still remembered: %v", l.exhausted)
+	}
+}
+
+// countingBackend считает обращения к хранилищу корзин
+type countingBackend struct {
+	Backend
+	takes int
+	err   error
+}
+
+func (b *countingBackend) Take(key string, burst int, rate float64, n int) (bool, float64, error) {
+	b.takes++
+	if b.err != nil {
+		return false, 0, b.err
+	}
+	return b.Backend.Take(key, burst, rate, n)
+}
+
+func TestLimiterCheckDoesNotReadBackend(t *testing.T) {
+	backend := &countingBackend{Backend: NewMemoryBackend()}
+	l := NewLimiter(backend, map[Class]Limit{Auth: {Requests: 1, Period: time.Minute}})
+
+	for i := 0; i < 3; i++ {
+		l.Check(Auth, "ip:1")
+	}
+	if backend.takes != 0 {
+		t.Fatalf("Check read the backend %d times", backend.takes)
+	}
+
+	l.Allow(Auth, "ip:1")
+	if res := l.Check(Auth, "ip:1"); res == nil || backend.takes != 1 {
+		t.Fatalf("check after a spent budget: %+v, %d takes", res, backend.takes)
+	}
+
+	backend.err = errors.New("database is down")
+	if _, err := l.Allow(Auth, "ip:2"); !errors.Is(err, backend.err) {
+		t.Fatalf("backend error: %v", err)
+	}
+}
+
+func TestLimiterSweepExhausted(t *testing.T) {
+	l, now := newTestLimiter(map[Class]Limit{Auth: {Requests: 1, Period: time.Minute}})
+
+	l.Allow(Auth, "ip:1")
+	*now = now.Add(30 * time.Second)
+	l.Allow(Auth, "ip:2")
+	*now = now.Add(30 * time.Second)
+
+	l.sweepExhausted()
+	if _, ok := l.exhausted["auth:ip:2"]; !ok || len(l.exhausted) != 1 {
+		t.Fatalf("exhausted after sweep: %v", l.exhausted)
+	}
+}
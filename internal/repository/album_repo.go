@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+)
+
+const albumColumns = `id, group_id, title, COALESCE(to_char(release_date, 'YYYY-MM-DD'), ''), album_type`
+
+func (r *Repository) CreateAlbum(album *models.Album) (uint, error) {
+	op := "repository.CreateAlbum"
+
+	var id uint
+	query := `INSERT INTO albums (group_id, title, release_date, album_type) VALUES ($1, $2, NULLIF($3, '')::DATE, $4) RETURNING id`
+	err := r.db.QueryRow(query, album.GroupID, album.Title, album.ReleaseDate, album.Type).Scan(&id)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return 0, ErrDuplicate
+		case isForeignKeyViolation(err):
+			return 0, ErrGroupNotFound
+		}
+		return 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *Repository) GetAlbums(filter map[string]string, limit, offset int) ([]models.Album, error) {
+	op := "repository.GetAlbums"
+
+	query := `SELECT ` + albumColumns + ` FROM albums WHERE 1=1`
+	args := []interface{}{}
+	argIndex := 1
+
+	if groupID, ok := filter["group_id"]; ok && groupID != "" {
+		query += fmt.Sprintf(" AND group_id = $%d", argIndex)
+		args = append(args, groupID)
+		argIndex++
+	}
+	if albumType, ok := filter["type"]; ok && albumType != "" {
+		query += fmt.Sprintf(" AND album_type = $%d", argIndex)
+		args = append(args, albumType)
+		argIndex++
+	}
+
+	query += fmt.Sprintf(" ORDER BY release_date DESC NULLS LAST, id LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+	defer rows.Close()
+
+	var albums []models.Album
+	for rows.Next() {
+		var album models.Album
+		if err := rows.Scan(&album.ID, &album.GroupID, &album.Title, &album.ReleaseDate, &album.Type); err != nil {
+			return nil, fmt.Errorf("%s: %v", op, err)
+		}
+		albums = append(albums, album)
+	}
+
+	return albums, nil
+}
+
+func (r *Repository) GetAlbumByID(id uint) (*models.Album, error) {
+	op := "repository.GetAlbumByID"
+
+	var album models.Album
+	query := `SELECT ` + albumColumns + ` FROM albums WHERE id = $1`
+	err := r.db.QueryRow(query, id).Scan(&album.ID, &album.GroupID, &album.Title, &album.ReleaseDate, &album.Type)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return &album, nil
+}
+
+func (r *Repository) UpdateAlbum(id uint, patch *models.AlbumPatch) error {
+	op := "repository.UpdateAlbum"
+
+	query := `UPDATE albums SET updated_at = CURRENT_TIMESTAMP`
+	var args []interface{}
+	argCount := 1
+
+	if patch.Title != nil {
+		query += fmt.Sprintf(", title = $%d", argCount)
+		args = append(args, *patch.Title)
+		argCount++
+	}
+	if patch.ReleaseDate != nil {
+		query += fmt.Sprintf(", release_date = NULLIF($%d, '')::DATE", argCount)
+		args = append(args, *patch.ReleaseDate)
+		argCount++
+	}
+	if patch.Type != nil {
+		query += fmt.Sprintf(", album_type = $%d", argCount)
+		args = append(args, *patch.Type)
+		argCount++
+	}
+
+	query += fmt.Sprintf(" WHERE id = $%d", argCount)
+	args = append(args, id)
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteAlbum(id uint) error {
+	op := "repository.DeleteAlbum"
+
+	result, err := r.db.Exec(`DELETE FROM albums WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetAlbumTracks возвращает треки альбома в порядке их номеров
+func (r *Repository) GetAlbumTracks(id uint) ([]models.AlbumTrack, error) {
+	op := "repository.GetAlbumTracks"
+
+	if _, err := r.GetAlbumByID(id); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT at.position, si.id, si.group_id, si.group_name, si.song, COALESCE(to_char(si.release_date, 'YYYY-MM-DD'), ''), si.link
+	FROM album_tracks at
+	JOIN song_info si ON si.id = at.song_id
+	WHERE at.album_id = $1 AND si.deleted_at IS NULL
+	ORDER BY at.position`
+
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+	defer rows.Close()
+
+	tracks := []models.AlbumTrack{}
+	for rows.Next() {
+		var track models.AlbumTrack
+		if err := rows.Scan(&track.Position, &track.ID, &track.GroupID, &track.Group, &track.Song.Song, &track.ReleaseDate, &track.Link); err != nil {
+			return nil, fmt.Errorf("%s: %v", op, err)
+		}
+		tracks = append(tracks, track)
+	}
+
+	return tracks, nil
+}
+
+// SetAlbumTracks заменяет список треков альбома; номер трека - позиция песни в songIDs, начиная с 1
+func (r *Repository) SetAlbumTracks(id uint, songIDs []uint) error {
+	op := "repository.SetAlbumTracks"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM albums WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.Exec(`DELETE FROM album_tracks WHERE album_id = $1`, id); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	// Песню из корзины добавить в альбом нельзя, как и несуществующую
+	query := `
+	INSERT INTO album_tracks (album_id, song_id, position)
+	SELECT $1::INT, $2::INT, $3::INT
+	WHERE EXISTS(SELECT 1 FROM song_info WHERE id = $2::INT AND deleted_at IS NULL)`
+	for i, songID := range songIDs {
+		result, err := tx.Exec(query, id, songID, i+1)
+		if err != nil {
+			switch {
+			case isForeignKeyViolation(err):
+				return ErrSongNotFound
+			case isUniqueViolation(err):
+				return ErrDuplicate
+			}
+			return fmt.Errorf("%s: %v", op, err)
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("%s: %v", op, err)
+		} else if rowsAffected == 0 {
+			return ErrSongNotFound
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	return nil
+}
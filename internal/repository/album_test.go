@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"testing"
+)
+
+func TestStoreAlbums(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		uprising := createSong(t, store, "Muse", "Uprising", "2009-09-07")
+		resistance := createSong(t, store, "Muse", "Resistance", "2009-09-14")
+		hysteria := createSong(t, store, "Muse", "Hysteria", "2003-12-01")
+
+		song, err := store.GetSongByID(uprising)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.CreateAlbum(&models.Album{GroupID: 9999, Title: "The Resistance"}); !errors.Is(err, ErrGroupNotFound) {
+			t.Fatalf("album of a missing group: %v, want ErrGroupNotFound", err)
+		}
+		id, err := store.CreateAlbum(&models.Album{GroupID: song.GroupID, Title: "The Resistance", ReleaseDate: "2009-09-14", Type: "LP"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.CreateAlbum(&models.Album{GroupID: song.GroupID, Title: "The Resistance"}); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("duplicate album: %v, want ErrDuplicate", err)
+		}
+		absolution, err := store.CreateAlbum(&models.Album{GroupID: song.GroupID, Title: "Absolution", ReleaseDate: "2003-09-15", Type: "LP"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		albums, err := store.GetAlbums(map[string]string{"group_id": fmt.Sprint(song.GroupID)}, 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(albums) != 2 || albums[0].ID != id || albums[1].ID != absolution {
+			t.Fatalf("albums are not ordered by release date: %+v", albums)
+		}
+
+		title := "Absolution"
+		if err := store.UpdateAlbum(id, &models.AlbumPatch{Title: &title}); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("rename to an existing album: %v, want ErrDuplicate", err)
+		}
+
+		if err := store.SetAlbumTracks(id, []uint{uprising, uprising}); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("repeated track: %v, want ErrDuplicate", err)
+		}
+		if err := store.SetAlbumTracks(id, []uint{uprising, 9999}); !errors.Is(err, ErrSongNotFound) {
+			t.Fatalf("missing track: %v, want ErrSongNotFound", err)
+		}
+		if err := store.SetAlbumTracks(id, []uint{resistance, uprising}); err != nil {
+			t.Fatal(err)
+		}
+
+		tracks, err := store.GetAlbumTracks(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tracks) != 2 || tracks[0].Position != 1 || tracks[0].ID != resistance || tracks[1].ID != uprising {
+			t.Fatalf("tracks: %+v", tracks)
+		}
+
+		songs, err := store.GetSongs(map[string]string{"album_id": fmt.Sprint(id)}, nil, 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(songs) != 2 {
+			t.Fatalf("songs by album_id: %+v", songs)
+		}
+
+		// Песни из корзины не видны в альбоме и не добавляются в него
+		if err := store.DeleteSongByID(uprising, testMeta); err != nil {
+			t.Fatal(err)
+		}
+		if tracks, _ = store.GetAlbumTracks(id); len(tracks) != 1 || tracks[0].ID != resistance {
+			t.Fatalf("tracks with a song in the trash: %+v", tracks)
+		}
+		if err := store.SetAlbumTracks(absolution, []uint{hysteria, uprising}); !errors.Is(err, ErrSongNotFound) {
+			t.Fatalf("track in the trash: %v, want ErrSongNotFound", err)
+		}
+
+		if err := store.DeleteAlbum(id); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetAlbumTracks(id); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("tracks of a deleted album: %v", err)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+)
+
+// songState - поля песни, изменения которых попадают в журнал аудита
+type songState struct {
+	group, song, releaseDate, link, text string
+}
+
+// changes возвращает поля, которые отличаются в after
+func (s songState) changes(after songState) []models.FieldChange {
+	changes := []models.FieldChange{}
+	for _, field := range []models.FieldChange{
+		{Field: "group", From: s.group, To: after.group},
+		{Field: "song", From: s.song, To: after.song},
+		{Field: "release_date", From: s.releaseDate, To: after.releaseDate},
+		{Field: "link", From: s.link, To: after.link},
+		{Field: "text", From: s.text, To: after.text},
+	} {
+		if field.From != field.To {
+			changes = append(changes, field)
+		}
+	}
+	return changes
+}
+
+// loadSongState читает текущее состояние песни и блокирует ее строку до конца транзакции
+func loadSongState(q queryer, songID uint) (songState, error) {
+	var state songState
+	query := `SELECT group_name, song, COALESCE(to_char(release_date, 'YYYY-MM-DD'), ''), link FROM song_info WHERE id = $1 FOR UPDATE`
+	if err := q.QueryRow(query, songID).Scan(&state.group, &state.song, &state.releaseDate, &state.link); err != nil {
+		return state, err
+	}
+
+	query = `SELECT COALESCE(STRING_AGG(` + renderedVerse + `, E'\n\n' ORDER BY position), '') FROM song_verses WHERE song_id = $1`
+	if err := q.QueryRow(query, songID).Scan(&state.text); err != nil {
+		return state, err
+	}
+
+	return state, nil
+}
+
+// recordChange записывает новую ревизию песни и запись аудита с изменениями относительно before
+func recordChange(tx *sql.Tx, songID uint, action string, meta models.ChangeMeta, before songState) error {
+	after, err := loadSongState(tx, songID)
+	if err != nil {
+		return err
+	}
+	if err := insertRevision(tx, songID, meta.Actor); err != nil {
+		return err
+	}
+	return insertAudit(tx, songID, action, meta, after, before.changes(after))
+}
+
+// insertAudit записывает изменение песни в журнал аудита; state - состояние после изменения
+func insertAudit(db execer, songID uint, action string, meta models.ChangeMeta, state songState, changes []models.FieldChange) error {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO audit_log (song_id, group_name, song, action, actor, request_id, source, changes)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err = db.Exec(query, songID, state.group, state.song, action, meta.Actor, meta.RequestID, meta.Source, string(data))
+	return err
+}
+
+// execAudited выполняет изменяющий запрос с RETURNING id, group_name, song и тем же запросом
+// записывает в журнал аудита по строке на каждую затронутую песню. Возвращает число песен.
+func execAudited(db execer, query, action string, meta models.ChangeMeta, args ...interface{}) (int64, error) {
+	n := len(args)
+	query = fmt.Sprintf(`
+	WITH changed AS (%s RETURNING id, group_name, song)
+	INSERT INTO audit_log (song_id, group_name, song, action, actor, request_id, source)
+	SELECT id, group_name, song, $%d, $%d, $%d, $%d FROM changed`, query, n+1, n+2, n+3, n+4)
+
+	args = append(args[:n:n], action, meta.Actor, meta.RequestID, meta.Source)
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetAuditLog возвращает записи журнала аудита по фильтру, сначала новые
+func (r *Repository) GetAuditLog(filter models.AuditFilter, limit, offset int) ([]models.AuditEntry, error) {
+	op := "repository.GetAuditLog"
+
+	query := `
+	SELECT id, song_id, group_name, song, action, actor, request_id, source, changes,
+		to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US')
+	FROM audit_log WHERE 1=1`
+	var args []interface{}
+	argIndex := 1
+
+	add := func(cond string, arg interface{}) {
+		query += fmt.Sprintf(" AND "+cond, argIndex)
+		args = append(args, arg)
+		argIndex++
+	}
+	if filter.SongID != 0 {
+		add("song_id = $%d", filter.SongID)
+	}
+	if filter.Actor != "" {
+		add("actor = $%d", filter.Actor)
+	}
+	if !filter.From.IsZero() {
+		add("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("created_at < $%d", filter.To)
+	}
+
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var changes []byte
+		err := rows.Scan(&entry.ID, &entry.SongID, &entry.Group, &entry.Song, &entry.Action, &entry.Actor,
+			&entry.RequestID, &entry.Source, &changes, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", op, err)
+		}
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, fmt.Errorf("%s: %v", op, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStoreAuditLog(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		start := time.Now().UTC().Add(-time.Second)
+		id := createSong(t, store, "Muse", "Hysteria", "2003-12-01", "It's bugging me")
+		other := createSong(t, store, "Muse", "Uprising", "")
+
+		link := "https://example.com/hysteria-live"
+		editor := models.ChangeMeta{Actor: "ed", RequestID: "req-2", Source: models.AuditSourceAPI}
+		if err := store.UpdateSongByID(id, &models.SongPatch{Link: &link}, nil, editor); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.DeleteSongByID(id, editor); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.RestoreSong(id, testMeta); err != nil {
+			t.Fatal(err)
+		}
+
+		entries, err := store.GetAuditLog(models.AuditFilter{SongID: id}, 100, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actions []string
+		for _, e := range entries {
+			actions = append(actions, e.Action)
+		}
+		want := []string{models.AuditRestore, models.AuditDelete, models.AuditUpdate, models.AuditCreate}
+		if !reflect.DeepEqual(actions, want) {
+			t.Fatalf("actions: %q, want %q", actions, want)
+		}
+
+		update := entries[2]
+		if update.Actor != "ed" || update.RequestID != "req-2" || update.Source != models.AuditSourceAPI ||
+			update.Group != "Muse" || update.Song != "Hysteria" || update.CreatedAt == "" {
+			t.Fatalf("update entry: %+v", update)
+		}
+		if !reflect.DeepEqual(update.Changes, []models.FieldChange{{Field: "link", From: "https://example.com/Hysteria", To: link}}) {
+			t.Fatalf("update changes: %+v", update.Changes)
+		}
+		// Создание записывается изменениями от пустой песни, удаление - без изменений
+		if len(entries[3].Changes) != 5 || entries[1].Changes == nil || len(entries[1].Changes) != 0 {
+			t.Fatalf("create and delete changes: %+v, %+v", entries[3].Changes, entries[1].Changes)
+		}
+
+		if entries, err = store.GetAuditLog(models.AuditFilter{Actor: "ed"}, 100, 0); err != nil || len(entries) != 2 {
+			t.Fatalf("entries by actor: %+v, %v", entries, err)
+		}
+		if entries, err = store.GetAuditLog(models.AuditFilter{}, 2, 3); err != nil || len(entries) != 2 || entries[0].SongID != other || entries[1].SongID != id {
+			t.Fatalf("audit page: %+v, %v", entries, err)
+		}
+
+		// Период включает from и не включает to
+		if entries, err = store.GetAuditLog(models.AuditFilter{From: start, To: start.Add(time.Hour)}, 100, 0); err != nil || len(entries) != 5 {
+			t.Fatalf("entries in the period: %d, %v", len(entries), err)
+		}
+		if entries, err = store.GetAuditLog(models.AuditFilter{To: start}, 100, 0); err != nil || entries == nil || len(entries) != 0 {
+			t.Fatalf("entries before the period: %+v, %v", entries, err)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// exportFetchSize - сколько строк читается из курсора выгрузки за один FETCH
+const exportFetchSize = 500
+
+// ExportSongs читает песни серверным курсором порциями по exportFetchSize и передает
+// их в fn по одной. Курсор живет в транзакции, поэтому вся выгрузка видит один снимок
+// данных. Ошибка fn прерывает выгрузку и возвращается как есть, отмена ctx прерывает
+// запросы к базе.
+func (r *Repository) ExportSongs(ctx context.Context, filter map[string]string, sort []models.SortKey, withText bool, fn func(song models.Song, verses []string) error) error {
+	op := "repository.ExportSongs"
+
+	f := buildSongFilter(filter)
+
+	order, err := songOrderBy(sort, false)
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	if f.score != "" && len(sort) == 0 {
+		order = "(" + f.score + ") DESC, " + order
+	}
+
+	columns := songColumns
+	if withText {
+		columns += `, ARRAY(SELECT ` + renderedVerse + ` FROM song_verses WHERE song_verses.song_id = song_info.id ORDER BY song_verses.position)`
+	}
+	query := `SELECT ` + columns + ` FROM song_info WHERE deleted_at IS NULL` + f.where + ` ORDER BY ` + order
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	defer tx.Rollback()
+
+	if f.threshold != "" {
+		if _, err := tx.ExecContext(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, f.threshold); err != nil {
+			return fmt.Errorf("%s: %v", op, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DECLARE song_export NO SCROLL CURSOR FOR `+query, f.args...); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	fetch := fmt.Sprintf(`FETCH FORWARD %d FROM song_export`, exportFetchSize)
+	for {
+		n, err := fetchExportBatch(ctx, tx, fetch, withText, fn)
+		if err != nil {
+			return err
+		}
+		if n < exportFetchSize {
+			break
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `CLOSE song_export`); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	return tx.Commit()
+}
+
+// fetchExportBatch читает одну порцию курсора и возвращает число прочитанных строк
+func fetchExportBatch(ctx context.Context, tx *sql.Tx, fetch string, withText bool, fn func(song models.Song, verses []string) error) (int, error) {
+	op := "repository.ExportSongs"
+
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", op, err)
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var verses []string
+		var row rowScanner = rows
+		if withText {
+			row = versesScanner{rows: rows, verses: &verses}
+		}
+
+		song, err := scanSong(row)
+		if err != nil {
+			return n, fmt.Errorf("%s: %v", op, err)
+		}
+		n++
+
+		if err := fn(song, verses); err != nil {
+			return n, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return n, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return n, nil
+}
+
+// versesScanner дочитывает после колонок песни массив куплетов
+type versesScanner struct {
+	rows   *sql.Rows
+	verses *[]string
+}
+
+func (v versesScanner) Scan(dest ...interface{}) error {
+	return v.rows.Scan(append(dest, pq.Array(v.verses))...)
+}
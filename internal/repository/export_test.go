@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestStoreExportSongs(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		createSong(t, store, "Muse", "Uprising", "2009-09-07", "Paranoia is in bloom", "They will not force us")
+		createSong(t, store, "Muse", "Hysteria", "2003-12-01", "It's bugging me")
+		createSong(t, store, "Queen", "Bohemian Rhapsody", "1975-10-31")
+		ctx := context.Background()
+
+		sort := []models.SortKey{{Field: models.SortReleaseDate}, {Field: models.SortID}}
+		var names []string
+		var verses [][]string
+		err := store.ExportSongs(ctx, map[string]string{}, sort, true, func(song models.Song, v []string) error {
+			names = append(names, song.Song)
+			verses = append(verses, v)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(names, []string{"Bohemian Rhapsody", "Hysteria", "Uprising"}) {
+			t.Fatalf("export order: %v", names)
+		}
+		if len(verses[0]) != 0 || !slices.Equal(verses[2], []string{"Paranoia is in bloom", "They will not force us"}) {
+			t.Fatalf("verses: %q", verses)
+		}
+
+		names = nil
+		err = store.ExportSongs(ctx, map[string]string{"group_name": "Muse"}, nil, false, func(song models.Song, v []string) error {
+			if v != nil {
+				t.Errorf("verses without withText: %q", v)
+			}
+			names = append(names, song.Song)
+			return nil
+		})
+		if err != nil || !slices.Equal(names, []string{"Uprising", "Hysteria"}) {
+			t.Fatalf("filtered export: %v, %v", names, err)
+		}
+
+		// Ошибка получателя прерывает выгрузку и возвращается без обертки
+		stop := errors.New("client gone")
+		calls := 0
+		err = store.ExportSongs(ctx, map[string]string{}, nil, false, func(models.Song, []string) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Fatalf("export after fn error: %v, %d calls", err, calls)
+		}
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		err = store.ExportSongs(cancelled, map[string]string{}, nil, false, func(models.Song, []string) error {
+			t.Error("fn called after ctx was cancelled")
+			return nil
+		})
+		if err == nil {
+			t.Fatal("export with a cancelled ctx succeeded")
+		}
+	})
+}
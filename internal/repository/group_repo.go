@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (r *Repository) CreateGroup(group *models.Group) (uint, error) {
+	op := "repository.CreateGroup"
+
+	var id uint
+	query := `INSERT INTO groups (name, country, formed_year, description) VALUES ($1, $2, $3, $4) RETURNING id`
+	err := r.db.QueryRow(query, group.Name, group.Country, group.FormedYear, group.Description).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, ErrDuplicate
+		}
+		return 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *Repository) GetGroups(filter map[string]string, limit, offset int) ([]models.Group, error) {
+	op := "repository.GetGroups"
+
+	query := `SELECT id, name, country, formed_year, description FROM groups WHERE 1=1`
+	args := []interface{}{}
+	argIndex := 1
+
+	if name, ok := filter["name"]; ok && name != "" {
+		query += fmt.Sprintf(" AND LOWER(name) = LOWER($%d)", argIndex)
+		args = append(args, name)
+		argIndex++
+	}
+	if country, ok := filter["country"]; ok && country != "" {
+		query += fmt.Sprintf(" AND LOWER(country) = LOWER($%d)", argIndex)
+		args = append(args, country)
+		argIndex++
+	}
+
+	query += fmt.Sprintf(" ORDER BY name, id LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+	defer rows.Close()
+
+	var groups []models.Group
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", op, err)
+		}
+		groups = append(groups, *group)
+	}
+
+	return groups, nil
+}
+
+func (r *Repository) GetGroupByID(id uint) (*models.Group, error) {
+	op := "repository.GetGroupByID"
+
+	query := `SELECT id, name, country, formed_year, description FROM groups WHERE id = $1`
+	group, err := scanGroup(r.db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return group, nil
+}
+
+// UpdateGroup изменяет данные группы. При переименовании обновляется и название группы,
+// сохраненное в песнях; для каждой песни записываются ревизия и запись аудита.
+func (r *Repository) UpdateGroup(id uint, patch *models.GroupPatch, meta models.ChangeMeta) error {
+	op := "repository.UpdateGroup"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	defer tx.Rollback()
+
+	// Состояние песен группы до переименования, строки песен блокируются до конца транзакции
+	var songIDs []uint
+	var before []songState
+	if patch.Name != nil {
+		if songIDs, err = groupSongIDs(tx, id); err != nil {
+			return fmt.Errorf("%s: %v", op, err)
+		}
+		for _, songID := range songIDs {
+			state, err := loadSongState(tx, songID)
+			if err != nil {
+				return fmt.Errorf("%s: %v", op, err)
+			}
+			before = append(before, state)
+		}
+	}
+
+	query := `UPDATE groups SET updated_at = CURRENT_TIMESTAMP`
+	var args []interface{}
+	argCount := 1
+
+	if patch.Name != nil {
+		query += fmt.Sprintf(", name = $%d", argCount)
+		args = append(args, *patch.Name)
+		argCount++
+	}
+	if patch.Country != nil {
+		query += fmt.Sprintf(", country = $%d", argCount)
+		args = append(args, *patch.Country)
+		argCount++
+	}
+	if patch.FormedYear != nil {
+		query += fmt.Sprintf(", formed_year = $%d", argCount)
+		args = append(args, *patch.FormedYear)
+		argCount++
+	}
+	if patch.Description != nil {
+		query += fmt.Sprintf(", description = $%d", argCount)
+		args = append(args, *patch.Description)
+		argCount++
+	}
+
+	query += fmt.Sprintf(" WHERE id = $%d", argCount)
+	args = append(args, id)
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if patch.Name != nil {
+		_, err := tx.Exec(`UPDATE song_info SET group_name = $1, updated_at = CURRENT_TIMESTAMP WHERE group_id = $2`, *patch.Name, id)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrDuplicate
+			}
+			return fmt.Errorf("%s: %v", op, err)
+		}
+
+		for i, songID := range songIDs {
+			if before[i].group == *patch.Name {
+				continue
+			}
+			if err := recordChange(tx, songID, models.AuditUpdate, meta, before[i]); err != nil {
+				return fmt.Errorf("%s: %v", op, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteGroup(id uint) error {
+	op := "repository.DeleteGroup"
+
+	result, err := r.db.Exec(`DELETE FROM groups WHERE id = $1`, id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrInUse
+		}
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// groupSongIDs возвращает id всех песен группы, включая песни в корзине
+func groupSongIDs(tx *sql.Tx, groupID uint) ([]uint, error) {
+	rows, err := tx.Query(`SELECT id FROM song_info WHERE group_id = $1 ORDER BY id`, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uint
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ensureGroup находит группу по названию без учета регистра или создает новую.
+// Возвращает id группы и ее каноническое написание.
+func ensureGroup(q queryer, name string) (uint, string, error) {
+	var id uint
+	var canonical string
+
+	query := `
+	INSERT INTO groups (name) VALUES ($1)
+	ON CONFLICT ((LOWER(name))) DO UPDATE SET name = groups.name
+	RETURNING id, name`
+
+	if err := q.QueryRow(query, name).Scan(&id, &canonical); err != nil {
+		return 0, "", err
+	}
+
+	return id, canonical, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanGroup(row rowScanner) (*models.Group, error) {
+	var group models.Group
+	var formedYear sql.NullInt64
+
+	if err := row.Scan(&group.ID, &group.Name, &group.Country, &formedYear, &group.Description); err != nil {
+		return nil, err
+	}
+
+	if formedYear.Valid {
+		year := int(formedYear.Int64)
+		group.FormedYear = &year
+	}
+
+	return &group, nil
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
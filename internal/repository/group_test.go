@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"testing"
+
+	"github.com/golang-migrate/migrate"
+	"github.com/golang-migrate/migrate/database/postgres"
+)
+
+func TestStoreGroups(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		year := 1994
+		id, err := store.CreateGroup(&models.Group{Name: "Muse", Country: "UK", FormedYear: &year})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.CreateGroup(&models.Group{Name: "MUSE"}); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("CreateGroup with another case: %v, want ErrDuplicate", err)
+		}
+
+		// Песни привязываются к существующей группе независимо от регистра
+		songID := createSong(t, store, "muse", "Uprising", "2009-09-07", "Paranoia is in bloom")
+		if _, err := store.SaveSongInfo("MUSE", "Uprising", "", ""); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("same song with another group case: %v, want ErrDuplicate", err)
+		}
+		song, err := store.GetSongByID(songID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if song.GroupID != id || song.Group != "Muse" {
+			t.Fatalf("song group: %d %q, want %d Muse", song.GroupID, song.Group, id)
+		}
+
+		groups, err := store.GetGroups(map[string]string{"name": "muse"}, 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(groups) != 1 || groups[0].Country != "UK" || groups[0].FormedYear == nil || *groups[0].FormedYear != year {
+			t.Fatalf("groups: %+v", groups)
+		}
+
+		createSong(t, store, "Queen", "Bohemian Rhapsody", "1975-10-31")
+		taken := "queen"
+		if err := store.UpdateGroup(id, &models.GroupPatch{Name: &taken}, testMeta); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("rename to an existing group: %v, want ErrDuplicate", err)
+		}
+
+		name := "MUSE"
+		if err := store.UpdateGroup(id, &models.GroupPatch{Name: &name}, testMeta); err != nil {
+			t.Fatal(err)
+		}
+		if song, _ = store.GetSongByID(songID); song.Group != "MUSE" {
+			t.Fatalf("song group after rename: %q", song.Group)
+		}
+		text, err := store.GetSongTextByGroup("muse", "Uprising", models.TextByVerse, 10, 0)
+		if err != nil || len(text) != 1 {
+			t.Fatalf("lookup by the renamed group: %q, %v", text, err)
+		}
+
+		if err := store.DeleteGroup(id); !errors.Is(err, ErrInUse) {
+			t.Fatalf("DeleteGroup with songs: %v, want ErrInUse", err)
+		}
+		if err := store.UpdateGroup(9999, &models.GroupPatch{Name: &name}, testMeta); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("UpdateGroup of a missing group: %v", err)
+		}
+
+		emptyID, err := store.CreateGroup(&models.Group{Name: "Placebo"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.DeleteGroup(emptyID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetGroupByID(emptyID); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("GetGroupByID after delete: %v", err)
+		}
+	})
+}
+
+// Миграция групп объединяет написания одной группы и не оставляет двух одинаковых песен в ней
+func TestMigrationGroupsBackfill(t *testing.T) {
+	db := newTestDB(t)
+
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://"+testMigrationsPath(t), "postgres", driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Migrate(2); err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+	INSERT INTO song_info (group_name, song, release_date, link) VALUES
+		('Muse', 'Uprising', '2009-09-07', ''),
+		('muse', 'Uprising', '2009-09-07', ''),
+		('MUSE', 'Hysteria', '2003-12-01', '')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(3); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Query(`SELECT si.id, si.group_name, si.song, g.name FROM song_info si JOIN groups g ON g.id = si.group_id ORDER BY si.id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var id int
+		var groupName, song, canonical string
+		if err := rows.Scan(&id, &groupName, &song, &canonical); err != nil {
+			t.Fatal(err)
+		}
+		if groupName != canonical {
+			t.Errorf("song %d keeps group_name %q, group is %q", id, groupName, canonical)
+		}
+		got = append(got, groupName+"/"+song)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Muse/Uprising", "Muse/Uprising (2)", "Muse/Hysteria"}
+	if len(got) != len(want) {
+		t.Fatalf("songs %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("songs %v, want %v", got, want)
+		}
+	}
+
+	_, err = db.Exec(`INSERT INTO song_info (group_id, group_name, song, release_date, link) SELECT group_id, 'muse', 'Hysteria', '2003-12-01', '' FROM song_info LIMIT 1`)
+	if !isUniqueViolation(err) {
+		t.Fatalf("duplicate song in the same group: %v, want a unique violation", err)
+	}
+}
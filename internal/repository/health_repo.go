@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Ping проверяет соединение с базой
+func (r *Repository) Ping(ctx context.Context) error {
+	op := "repository.Ping"
+
+	if err := r.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	return nil
+}
+
+// MigrationVersion возвращает примененную версию схемы из таблицы golang-migrate;
+// dirty - последняя миграция завершилась ошибкой
+func (r *Repository) MigrationVersion(ctx context.Context) (version uint, dirty bool, err error) {
+	op := "repository.MigrationVersion"
+
+	err = r.db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return version, dirty, nil
+}
+
+// LatestMigrationVersion возвращает номер последней миграции в каталоге (файлы NNNN_name.up.sql) -
+// версию схемы, которую ожидает эта сборка
+func LatestMigrationVersion(migrationsPath string) (uint, error) {
+	op := "repository.LatestMigrationVersion"
+
+	entries, err := os.ReadDir(migrationsPath)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, _, found := strings.Cut(name, "_")
+		if entry.IsDir() || !found || !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		latest = max(latest, uint(version))
+	}
+	if latest == 0 {
+		return 0, fmt.Errorf("%s: no migrations found in %s", op, migrationsPath)
+	}
+
+	return latest, nil
+}
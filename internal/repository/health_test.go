@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLatestMigrationVersion(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"0001_init.up.sql", "0001_init.down.sql", "0012_trash.up.sql", "0013_next.down.sql", "notes.txt", "x_broken.up.sql"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "0099_dir.up.sql"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if version, err := LatestMigrationVersion(dir); err != nil || version != 12 {
+		t.Fatalf("LatestMigrationVersion = %d, %v, want 12", version, err)
+	}
+	if _, err := LatestMigrationVersion(t.TempDir()); err == nil {
+		t.Fatal("empty directory accepted")
+	}
+	if _, err := LatestMigrationVersion(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("missing directory accepted")
+	}
+}
+
+func TestRepositoryHealth(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	if err := repo.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := LatestMigrationVersion(testMigrationsPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, dirty, err := repo.MigrationVersion(ctx)
+	if err != nil || dirty || version != latest {
+		t.Fatalf("MigrationVersion = %d, %v, %v, want %d", version, dirty, err, latest)
+	}
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"time"
+)
+
+// ObserveFunc получает имя метода хранилища, время его выполнения и результат
+type ObserveFunc func(method string, duration time.Duration, err error)
+
+// instrumentedStore замеряет методы чтения и изменения песен (song_repo.go);
+// остальные методы передаются хранилищу без замеров
+type instrumentedStore struct {
+	SongStore
+	observe ObserveFunc
+}
+
+// Instrument оборачивает хранилище так, что каждый вызов методов песен передается в observe
+func Instrument(store SongStore, observe ObserveFunc) SongStore {
+	return &instrumentedStore{SongStore: store, observe: observe}
+}
+
+func (s *instrumentedStore) track(method string, start time.Time, err error) {
+	s.observe(method, time.Since(start), err)
+}
+
+func (s *instrumentedStore) SaveSongInfo(group, song, releaseDate, link string) (id int, err error) {
+	defer func(start time.Time) { s.track("SaveSongInfo", start, err) }(time.Now())
+	return s.SongStore.SaveSongInfo(group, song, releaseDate, link)
+}
+
+func (s *instrumentedStore) SaveSongText(songID uint, verses []models.Verse, meta models.ChangeMeta) (err error) {
+	defer func(start time.Time) { s.track("SaveSongText", start, err) }(time.Now())
+	return s.SongStore.SaveSongText(songID, verses, meta)
+}
+
+func (s *instrumentedStore) SongExists(group, song string) (exists bool, err error) {
+	defer func(start time.Time) { s.track("SongExists", start, err) }(time.Now())
+	return s.SongStore.SongExists(group, song)
+}
+
+func (s *instrumentedStore) GetSongs(filter map[string]string, sort []models.SortKey, limit, offset int) (songs []models.Song, err error) {
+	defer func(start time.Time) { s.track("GetSongs", start, err) }(time.Now())
+	return s.SongStore.GetSongs(filter, sort, limit, offset)
+}
+
+func (s *instrumentedStore) GetSongsAfter(filter map[string]string, sort []models.SortKey, cursor *models.SongCursor, limit int) (songs []models.Song, more bool, err error) {
+	defer func(start time.Time) { s.track("GetSongsAfter", start, err) }(time.Now())
+	return s.SongStore.GetSongsAfter(filter, sort, cursor, limit)
+}
+
+func (s *instrumentedStore) CountSongs(filter map[string]string) (count int, err error) {
+	defer func(start time.Time) { s.track("CountSongs", start, err) }(time.Now())
+	return s.SongStore.CountSongs(filter)
+}
+
+func (s *instrumentedStore) GetSongTextByGroup(groupName, songName, by string, limit, offset int) (text []string, err error) {
+	defer func(start time.Time) { s.track("GetSongTextByGroup", start, err) }(time.Now())
+	return s.SongStore.GetSongTextByGroup(groupName, songName, by, limit, offset)
+}
+
+func (s *instrumentedStore) UpdateSong(groupName, songName, newReleaseDate, newLink string, newVerses []models.Verse, meta models.ChangeMeta) (err error) {
+	defer func(start time.Time) { s.track("UpdateSong", start, err) }(time.Now())
+	return s.SongStore.UpdateSong(groupName, songName, newReleaseDate, newLink, newVerses, meta)
+}
+
+func (s *instrumentedStore) DeleteSong(groupName, songName string, meta models.ChangeMeta) (err error) {
+	defer func(start time.Time) { s.track("DeleteSong", start, err) }(time.Now())
+	return s.SongStore.DeleteSong(groupName, songName, meta)
+}
+
+func (s *instrumentedStore) GetSongByID(id uint) (song *models.Song, err error) {
+	defer func(start time.Time) { s.track("GetSongByID", start, err) }(time.Now())
+	return s.SongStore.GetSongByID(id)
+}
+
+func (s *instrumentedStore) GetSongTextByID(id uint, by string, limit, offset int) (text *models.SongTextResp, err error) {
+	defer func(start time.Time) { s.track("GetSongTextByID", start, err) }(time.Now())
+	return s.SongStore.GetSongTextByID(id, by, limit, offset)
+}
+
+func (s *instrumentedStore) GetSongVerses(id uint, limit, offset int) (verses *models.SongVersesResp, err error) {
+	defer func(start time.Time) { s.track("GetSongVerses", start, err) }(time.Now())
+	return s.SongStore.GetSongVerses(id, limit, offset)
+}
+
+func (s *instrumentedStore) GetVerse(id uint, n int) (verse *models.Verse, err error) {
+	defer func(start time.Time) { s.track("GetVerse", start, err) }(time.Now())
+	return s.SongStore.GetVerse(id, n)
+}
+
+func (s *instrumentedStore) UpdateSongByID(id uint, patch *models.SongPatch, newVerses []models.Verse, meta models.ChangeMeta) (err error) {
+	defer func(start time.Time) { s.track("UpdateSongByID", start, err) }(time.Now())
+	return s.SongStore.UpdateSongByID(id, patch, newVerses, meta)
+}
+
+func (s *instrumentedStore) DeleteSongByID(id uint, meta models.ChangeMeta) (err error) {
+	defer func(start time.Time) { s.track("DeleteSongByID", start, err) }(time.Now())
+	return s.SongStore.DeleteSongByID(id, meta)
+}
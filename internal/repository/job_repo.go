@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"time"
+)
+
+// jobColumns - колонки задачи обогащения в порядке, который ожидает scanJob
+const jobColumns = `id, song_id, status, attempts, max_attempts, last_error, sources::TEXT,
+	to_char(run_at, 'YYYY-MM-DD"T"HH24:MI:SS.US'), to_char(created_at, 'YYYY-MM-DD"T"HH24:MI:SS.US'), to_char(updated_at, 'YYYY-MM-DD"T"HH24:MI:SS.US')`
+
+// leaseExpiredError - ошибка задачи, у которой истекла аренда последней попытки
+const leaseExpiredError = "lease of the last attempt expired"
+
+func scanJob(row rowScanner) (*models.EnrichmentJob, error) {
+	var job models.EnrichmentJob
+	var sources string
+	err := row.Scan(&job.ID, &job.SongID, &job.Status, &job.Attempts, &job.MaxAttempts, &job.LastError, &sources, &job.RunAt, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(sources), &job.Sources); err != nil {
+		return nil, err
+	}
+	if len(job.Sources) == 0 {
+		job.Sources = nil
+	}
+	return &job, nil
+}
+
+// CreatePendingSong сохраняет песню без даты выхода и ссылки в статусе pending и ставит
+// в очередь задачу их загрузки. Текст сохраняется сразу, если verses не пусты, иначе
+// он тоже загружается задачей. Создание записывается первой ревизией и в журнал аудита.
+// Возвращает id песни и id задачи.
+func (r *Repository) CreatePendingSong(group, song string, verses []models.Verse, maxAttempts int, meta models.ChangeMeta) (uint, uint, error) {
+	op := "repository.CreatePendingSong"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %v", op, err)
+	}
+	defer tx.Rollback()
+
+	groupID, groupName, err := ensureGroup(tx, group)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	var songID uint
+	query := `
+	INSERT INTO song_info (group_id, group_name, song, link, enrichment_status)
+	VALUES ($1, $2, $3, '', $4) RETURNING id`
+	err = tx.QueryRow(query, groupID, groupName, song, models.EnrichmentPending).Scan(&songID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, 0, ErrDuplicate
+		}
+		return 0, 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	if err := insertVerses(tx, songID, verses); err != nil {
+		return 0, 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	if err := recordChange(tx, songID, models.AuditCreate, meta, songState{}); err != nil {
+		return 0, 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	var jobID uint
+	query = `INSERT INTO enrichment_jobs (song_id, max_attempts) VALUES ($1, $2) RETURNING id`
+	if err := tx.QueryRow(query, songID, maxAttempts).Scan(&jobID); err != nil {
+		return 0, 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("%s: %v", op, err)
+	}
+	return songID, jobID, nil
+}
+
+func (r *Repository) GetEnrichmentJob(id uint) (*models.EnrichmentJob, error) {
+	op := "repository.GetEnrichmentJob"
+
+	query := `SELECT ` + jobColumns + ` FROM enrichment_jobs WHERE id = $1`
+	job, err := scanJob(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return job, nil
+}
+
+// ClaimEnrichmentJob берет в работу самую раннюю готовую к запуску задачу на время lease.
+// Задачи running с истекшей арендой (например, после падения процесса) берутся повторно,
+// а если истекла аренда последней попытки, задача завершается с ошибкой вместе с песней.
+// Задачи песен в корзине пропускаются до восстановления песни.
+// Если готовых задач нет, возвращает sql.ErrNoRows.
+func (r *Repository) ClaimEnrichmentJob(lease time.Duration) (*models.EnrichmentJob, error) {
+	op := "repository.ClaimEnrichmentJob"
+
+	query := `
+	WITH expired AS (
+		UPDATE enrichment_jobs
+		SET status = 'failed', last_error = $1, updated_at = NOW()
+		WHERE status IN ('queued', 'running') AND run_at <= NOW() AND attempts >= max_attempts
+		RETURNING song_id
+	)
+	UPDATE song_info SET enrichment_status = $2, updated_at = NOW()
+	WHERE id IN (SELECT song_id FROM expired)`
+	if _, err := r.db.Exec(query, leaseExpiredError, models.EnrichmentFailed); err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	query = `
+	UPDATE enrichment_jobs
+	SET status = 'running', attempts = attempts + 1,
+		run_at = NOW() + $1 * INTERVAL '1 millisecond', updated_at = NOW()
+	WHERE id = (
+		SELECT j.id FROM enrichment_jobs j
+		JOIN song_info si ON si.id = j.song_id
+		WHERE j.status IN ('queued', 'running') AND j.run_at <= NOW() AND j.attempts < j.max_attempts
+			AND si.deleted_at IS NULL
+		ORDER BY j.run_at, j.id
+		LIMIT 1
+		FOR UPDATE OF j SKIP LOCKED
+	)
+	RETURNING ` + jobColumns
+
+	job, err := scanJob(r.db.QueryRow(query, lease.Milliseconds()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return job, nil
+}
+
+// CompleteEnrichmentJob заполняет данные песни, записывает в задачу источники полей и завершает ее.
+// Поля, которые пользователь успел задать сам, пока песня ожидала обогащения, не перезаписываются.
+// Результат записывается ревизией и в журнал аудита. Если аренда потеряна, возвращает ErrLeaseLost,
+// если песня тем временем попала в корзину - sql.ErrNoRows; в обоих случаях ничего не меняется.
+func (r *Repository) CompleteEnrichmentJob(jobID, songID uint, attempt int, details *models.SongDetails, verses []models.Verse, meta models.ChangeMeta) error {
+	op := "repository.CompleteEnrichmentJob"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := lockOwnedJob(tx, jobID, attempt); err != nil {
+		if errors.Is(err, ErrLeaseLost) {
+			return err
+		}
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	var songLive bool
+	query := `SELECT deleted_at IS NULL FROM song_info WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(query, songID).Scan(&songLive); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	if !songLive {
+		return sql.ErrNoRows
+	}
+
+	before, err := loadSongState(tx, songID)
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	query = `
+	UPDATE song_info
+	SET release_date = COALESCE(release_date, $1::DATE),
+		link = CASE WHEN link = '' THEN $2 ELSE link END,
+		enrichment_status = $3, updated_at = NOW()
+	WHERE id = $4`
+	if _, err := tx.Exec(query, details.ReleaseDate, details.Link, models.EnrichmentDone, songID); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	var hasText bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM song_text WHERE song_id = $1)`, songID).Scan(&hasText); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	if !hasText {
+		if err := insertVerses(tx, songID, verses); err != nil {
+			return fmt.Errorf("%s: %v", op, err)
+		}
+	}
+
+	if err := recordChange(tx, songID, models.AuditUpdate, meta, before); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	sources, err := json.Marshal(details.Sources)
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	if _, err := tx.Exec(`UPDATE enrichment_jobs SET sources = $1 WHERE id = $2`, string(sources), jobID); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if err := finishJob(tx, jobID, models.JobDone, ""); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	return tx.Commit()
+}
+
+// RetryEnrichmentJob возвращает задачу в очередь со следующей попыткой через delay.
+// Время считается на стороне базы, как и в ClaimEnrichmentJob. Если аренда потеряна, возвращает ErrLeaseLost.
+func (r *Repository) RetryEnrichmentJob(jobID uint, attempt int, delay time.Duration, lastError string) error {
+	op := "repository.RetryEnrichmentJob"
+
+	query := `
+	UPDATE enrichment_jobs
+	SET status = 'queued', run_at = NOW() + $1 * INTERVAL '1 millisecond', last_error = $2, updated_at = NOW()
+	WHERE id = $3 AND status = 'running' AND attempts = $4`
+	result, err := r.db.Exec(query, delay.Milliseconds(), lastError, jobID, attempt)
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	if rowsAffected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// PostponeEnrichmentJob сразу возвращает задачу в очередь и не засчитывает попытку attempt:
+// она прервана не по вине источника данных (песня в корзине или остановка сервиса).
+// Если аренда потеряна, возвращает ErrLeaseLost.
+func (r *Repository) PostponeEnrichmentJob(jobID uint, attempt int, lastError string) error {
+	op := "repository.PostponeEnrichmentJob"
+
+	query := `
+	UPDATE enrichment_jobs
+	SET status = 'queued', attempts = attempts - 1, run_at = NOW(), last_error = $1, updated_at = NOW()
+	WHERE id = $2 AND status = 'running' AND attempts = $3`
+	result, err := r.db.Exec(query, lastError, jobID, attempt)
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	if rowsAffected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// FailEnrichmentJob окончательно завершает задачу с ошибкой и помечает песню как failed.
+// Если аренда потеряна, возвращает ErrLeaseLost.
+func (r *Repository) FailEnrichmentJob(jobID, songID uint, attempt int, lastError string) error {
+	op := "repository.FailEnrichmentJob"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := lockOwnedJob(tx, jobID, attempt); err != nil {
+		if errors.Is(err, ErrLeaseLost) {
+			return err
+		}
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	query := `UPDATE song_info SET enrichment_status = $1, updated_at = NOW() WHERE id = $2`
+	if _, err := tx.Exec(query, models.EnrichmentFailed, songID); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if err := finishJob(tx, jobID, models.JobFailed, lastError); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	return tx.Commit()
+}
+
+// lockOwnedJob блокирует задачу, если она все еще выполняется попыткой attempt. После истечения
+// аренды ClaimEnrichmentJob отдает задачу другому обработчику и увеличивает attempts, поэтому
+// устаревший обработчик получает ErrLeaseLost и не меняет ни задачу, ни песню.
+func lockOwnedJob(tx *sql.Tx, jobID uint, attempt int) error {
+	var id uint
+	query := `SELECT id FROM enrichment_jobs WHERE id = $1 AND status = 'running' AND attempts = $2 FOR UPDATE`
+	err := tx.QueryRow(query, jobID, attempt).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrLeaseLost
+	}
+	return err
+}
+
+func finishJob(tx *sql.Tx, jobID uint, status, lastError string) error {
+	query := `UPDATE enrichment_jobs SET status = $1, last_error = $2, updated_at = NOW() WHERE id = $3`
+	_, err := tx.Exec(query, status, lastError, jobID)
+	return err
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"testing"
+	"time"
+)
+
+// claimJob берет задачу в работу и проверяет, что это задача jobID с попыткой attempt
+func claimJob(t *testing.T, store SongStore, lease time.Duration, jobID uint, attempt int) *models.EnrichmentJob {
+	t.Helper()
+
+	job, err := store.ClaimEnrichmentJob(lease)
+	if err != nil {
+		t.Fatalf("ClaimEnrichmentJob: %v", err)
+	}
+	if job.ID != jobID || job.Status != models.JobRunning || job.Attempts != attempt {
+		t.Fatalf("claimed job: %+v, want job %d attempt %d", job, jobID, attempt)
+	}
+	return job
+}
+
+func expectNoJob(t *testing.T, store SongStore) {
+	t.Helper()
+
+	if job, err := store.ClaimEnrichmentJob(time.Minute); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("ClaimEnrichmentJob = %+v, %v, want sql.ErrNoRows", job, err)
+	}
+}
+
+func TestStoreEnrichmentJobLifecycle(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		songID, jobID, err := store.CreatePendingSong("Muse", "Uprising", []models.Verse{{Text: "Paranoia is in bloom"}}, 3, testMeta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := store.CreatePendingSong("Muse", "Uprising", nil, 3, testMeta); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("duplicate CreatePendingSong: %v", err)
+		}
+
+		job := claimJob(t, store, time.Minute, jobID, 1)
+		// Задача в аренде не выдается второму обработчику
+		expectNoJob(t, store)
+
+		if err := store.RetryEnrichmentJob(jobID, job.Attempts, 0, "timeout"); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.RetryEnrichmentJob(jobID, job.Attempts, 0, "timeout"); !errors.Is(err, ErrLeaseLost) {
+			t.Fatalf("second RetryEnrichmentJob: %v", err)
+		}
+
+		job = claimJob(t, store, time.Minute, jobID, 2)
+		if job.LastError != "timeout" {
+			t.Fatalf("last error: %q", job.LastError)
+		}
+
+		// Пользователь задал ссылку, пока песня ждала обогащения
+		if err := store.UpdateSong("Muse", "Uprising", "", "https://example.com/mine", nil, testMeta); err != nil {
+			t.Fatal(err)
+		}
+
+		details := &models.SongDetails{
+			ReleaseDate: "2009-09-07",
+			Text:        "ignored",
+			Link:        "https://example.com/uprising",
+			Sources:     map[string]string{models.DetailReleaseDate: "file"},
+		}
+		if err := store.CompleteEnrichmentJob(jobID, songID, 1, details, nil, models.ChangeMeta{Actor: "enrichment"}); !errors.Is(err, ErrLeaseLost) {
+			t.Fatalf("CompleteEnrichmentJob with a stale attempt: %v", err)
+		}
+		if err := store.CompleteEnrichmentJob(jobID, songID, job.Attempts, details, []models.Verse{{Text: "ignored"}}, models.ChangeMeta{Actor: "enrichment"}); err != nil {
+			t.Fatal(err)
+		}
+
+		song, err := store.GetSongByID(songID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if song.EnrichmentStatus != models.EnrichmentDone || song.ReleaseDate != "2009-09-07" ||
+			song.Link != "https://example.com/mine" || song.Text != "Paranoia is in bloom" {
+			t.Fatalf("enriched song: %+v", song)
+		}
+
+		done, err := store.GetEnrichmentJob(jobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if done.Status != models.JobDone || done.Attempts != 2 || done.Sources[models.DetailReleaseDate] != "file" {
+			t.Fatalf("finished job: %+v", done)
+		}
+		if _, err := store.GetEnrichmentJob(jobID + 100); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("missing job: %v", err)
+		}
+		expectNoJob(t, store)
+	})
+}
+
+func TestStoreEnrichmentJobAttempts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		songID, jobID, err := store.CreatePendingSong("Muse", "Uprising", nil, 2, testMeta)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Отложенная попытка не засчитывается
+		job := claimJob(t, store, time.Minute, jobID, 1)
+		if err := store.PostponeEnrichmentJob(jobID, job.Attempts, "shutdown"); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.PostponeEnrichmentJob(jobID, job.Attempts, "shutdown"); !errors.Is(err, ErrLeaseLost) {
+			t.Fatalf("second PostponeEnrichmentJob: %v", err)
+		}
+		claimJob(t, store, time.Minute, jobID, 1)
+		if err := store.PostponeEnrichmentJob(jobID, 1, "song is in the trash"); err != nil {
+			t.Fatal(err)
+		}
+
+		// Задачи песен в корзине не выдаются до восстановления
+		if err := store.DeleteSong("Muse", "Uprising", testMeta); err != nil {
+			t.Fatal(err)
+		}
+		expectNoJob(t, store)
+		if err := store.RestoreSong(songID, testMeta); err != nil {
+			t.Fatal(err)
+		}
+
+		// Истекшая аренда: задачу берет следующий обработчик, прежний теряет ее
+		stale := claimJob(t, store, 0, jobID, 1)
+		job = claimJob(t, store, 0, jobID, 2)
+		if err := store.FailEnrichmentJob(jobID, songID, stale.Attempts, "late"); !errors.Is(err, ErrLeaseLost) {
+			t.Fatalf("FailEnrichmentJob with a stale attempt: %v", err)
+		}
+
+		// Аренда последней попытки истекла: задача завершается с ошибкой, а не берется снова
+		expectNoJob(t, store)
+		failed, err := store.GetEnrichmentJob(jobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed.Status != models.JobFailed || failed.Attempts != 2 || failed.LastError != leaseExpiredError {
+			t.Fatalf("expired job: %+v", failed)
+		}
+		song, err := store.GetSongByID(songID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if song.EnrichmentStatus != models.EnrichmentFailed {
+			t.Fatalf("song of the expired job: %+v", song)
+		}
+		if err := store.RetryEnrichmentJob(jobID, job.Attempts, 0, "late"); !errors.Is(err, ErrLeaseLost) {
+			t.Fatalf("RetryEnrichmentJob of a failed job: %v", err)
+		}
+	})
+}
+
+func TestStoreFailEnrichmentJob(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		songID, jobID, err := store.CreatePendingSong("Muse", "Uprising", nil, 5, testMeta)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		job := claimJob(t, store, time.Minute, jobID, 1)
+		if err := store.FailEnrichmentJob(jobID, songID, job.Attempts, "not found"); err != nil {
+			t.Fatal(err)
+		}
+		expectNoJob(t, store)
+
+		failed, err := store.GetEnrichmentJob(jobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed.Status != models.JobFailed || failed.LastError != "not found" {
+			t.Fatalf("failed job: %+v", failed)
+		}
+		song, err := store.GetSongByID(songID)
+		if err != nil || song.EnrichmentStatus != models.EnrichmentFailed {
+			t.Fatalf("song of the failed job: %+v, %v", song, err)
+		}
+	})
+}
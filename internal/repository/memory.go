@@ -0,0 +1,521 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memorySong хранит куплеты с уже подставленными текстом и типом повторов.
+// Песня в корзине остается в map, но не видна через обычные методы чтения.
+type memorySong struct {
+	info      models.Song
+	verses    []models.Verse
+	revisions []models.SongRevision
+	deletedAt time.Time
+}
+
+func (s *memorySong) deleted() bool {
+	return !s.deletedAt.IsZero()
+}
+
+// MemoryStore - потокобезопасная реализация SongStore в памяти.
+// Повторяет поведение Repository, но не требует PostgreSQL.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	nextID      uint
+	songs       map[uint]*memorySong
+	nextGroupID uint
+	groups      map[uint]*models.Group
+	nextAlbumID uint
+	albums      map[uint]*memoryAlbum
+	nextJobID   uint
+	jobs        map[uint]*memoryJob
+	audit       []memoryAuditEntry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		nextID:      1,
+		songs:       make(map[uint]*memorySong),
+		nextGroupID: 1,
+		groups:      make(map[uint]*models.Group),
+		nextAlbumID: 1,
+		albums:      make(map[uint]*memoryAlbum),
+		nextJobID:   1,
+		jobs:        make(map[uint]*memoryJob),
+	}
+}
+
+func (m *MemoryStore) SaveSongInfo(group, song, releaseDate, link string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.songExistsLocked(group, song) {
+		return 0, ErrDuplicate
+	}
+
+	g := m.ensureGroupLocked(group)
+	now := memoryNow()
+
+	id := m.nextID
+	m.nextID++
+
+	m.songs[id] = &memorySong{
+		info: models.Song{
+			ID:               id,
+			GroupID:          g.ID,
+			Group:            g.Name,
+			Song:             song,
+			ReleaseDate:      releaseDate,
+			Link:             link,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+			EnrichmentStatus: models.EnrichmentDone,
+		},
+	}
+
+	return int(id), nil
+}
+
+func (m *MemoryStore) SaveSongText(songID uint, verses []models.Verse, meta models.ChangeMeta) error {
+	op := "memory.SaveSongText"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.songs[songID]
+	if !ok {
+		return fmt.Errorf("%s: song %d does not exist", op, songID)
+	}
+
+	s.verses = resolveVerses(verses)
+	m.recordChangeLocked(s, models.AuditCreate, meta, songState{})
+	return nil
+}
+
+func (m *MemoryStore) SongExists(group, song string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.songExistsLocked(group, song), nil
+}
+
+func (m *MemoryStore) songExistsLocked(group, song string) bool {
+	g := m.findGroupLocked(group)
+	if g == nil {
+		return false
+	}
+	for _, s := range m.songs {
+		if s.info.GroupID == g.ID && s.info.Song == song && !s.deleted() {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryStore) GetSongs(filter map[string]string, sort []models.SortKey, limit, offset int) ([]models.Song, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return paginate(m.filterSongsLocked(filter, sort), limit, offset), nil
+}
+
+func (m *MemoryStore) GetSongsAfter(filter map[string]string, sort []models.SortKey, cursor *models.SongCursor, limit int) ([]models.Song, bool, error) {
+	op := "memory.GetSongsAfter"
+
+	if len(sort) == 0 {
+		sort = DefaultSongSort
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	matched := m.filterSongsLocked(filter, sort)
+
+	if cursor != nil {
+		if len(cursor.Values) != len(sort) {
+			return nil, false, fmt.Errorf("%s: cursor has %d values for %d sort keys", op, len(cursor.Values), len(sort))
+		}
+
+		var page []models.Song
+		for _, s := range matched {
+			c := compareSongKey(s, sort, cursor.Values)
+			if (!cursor.Backward && c > 0) || (cursor.Backward && c < 0) {
+				page = append(page, s)
+			}
+		}
+
+		if cursor.Backward {
+			hasMore := len(page) > limit
+			if hasMore {
+				page = page[len(page)-limit:]
+			}
+			return append([]models.Song{}, page...), hasMore, nil
+		}
+		matched = page
+	}
+
+	hasMore := len(matched) > limit
+	return append([]models.Song{}, paginate(matched, limit, 0)...), hasMore, nil
+}
+
+func (m *MemoryStore) CountSongs(filter map[string]string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.filterSongsLocked(filter, nil)), nil
+}
+
+// filterSongsLocked возвращает песни, подходящие под фильтр, в порядке sort.
+// Без явной сортировки fuzzy-результаты идут по убыванию сходства.
+func (m *MemoryStore) filterSongsLocked(filter map[string]string, sortKeys []models.SortKey) []models.Song {
+	var matched []models.Song
+	scores := make(map[uint]float64)
+	for _, s := range m.songs {
+		if s.deleted() {
+			continue
+		}
+		if albumID := filter["album_id"]; albumID != "" {
+			id, _ := strconv.ParseUint(albumID, 10, 32)
+			if !m.albumHasSongLocked(uint(id), s.info.ID) {
+				continue
+			}
+		}
+		score, ok := matchSongFilter(s.info, filter)
+		if ok {
+			matched = append(matched, s.info)
+			scores[s.info.ID] = score
+		}
+	}
+
+	byScore := len(sortKeys) == 0
+	if byScore {
+		sortKeys = DefaultSongSort
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		if si, sj := scores[matched[i].ID], scores[matched[j].ID]; byScore && si != sj {
+			return si > sj
+		}
+		return compareSongKey(matched[i], sortKeys, songKeyValues(matched[j], sortKeys)) < 0
+	})
+
+	return matched
+}
+
+func (m *MemoryStore) GetSongTextByGroup(groupName, songName, by string, limit, offset int) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s := m.findLocked(groupName, songName)
+	if s == nil {
+		return nil, nil
+	}
+
+	return paginate(verseTexts(s.verses, by), limit, offset), nil
+}
+
+func (m *MemoryStore) UpdateSong(groupName, songName, newReleaseDate, newLink string, newVerses []models.Verse, meta models.ChangeMeta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.findLocked(groupName, songName)
+	if s == nil {
+		return sql.ErrNoRows
+	}
+	before := memorySongState(s)
+
+	s.info.UpdatedAt = memoryNow()
+	if newReleaseDate != "" {
+		s.info.ReleaseDate = newReleaseDate
+	}
+	if newLink != "" {
+		s.info.Link = newLink
+	}
+	if len(newVerses) > 0 {
+		s.verses = resolveVerses(newVerses)
+	}
+	m.recordChangeLocked(s, models.AuditUpdate, meta, before)
+
+	return nil
+}
+
+func (m *MemoryStore) DeleteSong(groupName, songName string, meta models.ChangeMeta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.findLocked(groupName, songName)
+	if s == nil {
+		return sql.ErrNoRows
+	}
+
+	s.deletedAt = time.Now().UTC()
+	m.auditLocked(s, models.AuditDelete, meta, nil)
+	return nil
+}
+
+func (m *MemoryStore) GetSongByID(id uint) (*models.Song, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.liveSongLocked(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	song := s.info
+	song.Text = strings.Join(renderVerses(s.verses), "\n\n")
+	return &song, nil
+}
+
+func (m *MemoryStore) GetSongTextByID(id uint, by string, limit, offset int) (*models.SongTextResp, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.liveSongLocked(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	text := paginate(verseTexts(s.verses, by), limit, offset)
+	if text == nil {
+		text = []string{}
+	}
+
+	return &models.SongTextResp{
+		ID:    id,
+		Group: s.info.Group,
+		Song:  s.info.Song,
+		Text:  text,
+	}, nil
+}
+
+func (m *MemoryStore) GetSongVerses(id uint, limit, offset int) (*models.SongVersesResp, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.liveSongLocked(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	verses := paginate(append([]models.Verse(nil), s.verses...), limit, offset)
+	if verses == nil {
+		verses = []models.Verse{}
+	}
+
+	return &models.SongVersesResp{
+		ID:     id,
+		Group:  s.info.Group,
+		Song:   s.info.Song,
+		Verses: verses,
+	}, nil
+}
+
+func (m *MemoryStore) GetVerse(id uint, n int) (*models.Verse, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.liveSongLocked(id)
+	if !ok || n < 1 || n > len(s.verses) {
+		return nil, sql.ErrNoRows
+	}
+
+	verse := s.verses[n-1]
+	return &verse, nil
+}
+
+func (m *MemoryStore) UpdateSongByID(id uint, patch *models.SongPatch, newVerses []models.Verse, meta models.ChangeMeta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.liveSongLocked(id)
+	if !ok {
+		return sql.ErrNoRows
+	}
+	before := memorySongState(s)
+
+	updated := s.info
+	updated.UpdatedAt = memoryNow()
+	switch {
+	case patch.GroupID != nil:
+		g, ok := m.groups[*patch.GroupID]
+		if !ok {
+			return ErrGroupNotFound
+		}
+		updated.GroupID, updated.Group = g.ID, g.Name
+	case patch.Group != nil:
+		// Новая группа создается только после проверки на дубликат
+		updated.GroupID, updated.Group = 0, *patch.Group
+		if g := m.findGroupLocked(*patch.Group); g != nil {
+			updated.GroupID, updated.Group = g.ID, g.Name
+		}
+	}
+	if patch.Song != nil {
+		updated.Song = *patch.Song
+	}
+	if patch.ReleaseDate != nil {
+		updated.ReleaseDate = *patch.ReleaseDate
+	}
+	if patch.Link != nil {
+		updated.Link = *patch.Link
+	}
+
+	if other := m.findLocked(updated.Group, updated.Song); other != nil && other != s {
+		return ErrDuplicate
+	}
+
+	if updated.GroupID == 0 {
+		updated.GroupID = m.ensureGroupLocked(updated.Group).ID
+	}
+
+	s.info = updated
+	if newVerses != nil {
+		s.verses = resolveVerses(newVerses)
+	}
+	m.recordChangeLocked(s, models.AuditUpdate, meta, before)
+
+	return nil
+}
+
+func (m *MemoryStore) DeleteSongByID(id uint, meta models.ChangeMeta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.liveSongLocked(id)
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	s.deletedAt = time.Now().UTC()
+	m.auditLocked(s, models.AuditDelete, meta, nil)
+	return nil
+}
+
+// liveSongLocked возвращает песню по id, если она не в корзине
+func (m *MemoryStore) liveSongLocked(id uint) (*memorySong, bool) {
+	s, ok := m.songs[id]
+	if !ok || s.deleted() {
+		return nil, false
+	}
+	return s, true
+}
+
+// findLocked ищет песню по названию группы без учета регистра, как SongExists
+func (m *MemoryStore) findLocked(groupName, songName string) *memorySong {
+	g := m.findGroupLocked(groupName)
+	if g == nil {
+		return nil
+	}
+	for _, s := range m.songs {
+		if s.info.GroupID == g.ID && s.info.Song == songName && !s.deleted() {
+			return s
+		}
+	}
+	return nil
+}
+
+// matchSongFilter проверяет песню по фильтру; score - суммарное сходство
+// по fuzzy-фильтрам (0, если их нет)
+func matchSongFilter(song models.Song, filter map[string]string) (float64, bool) {
+	if groupID := filter["group_id"]; groupID != "" && strconv.FormatUint(uint64(song.GroupID), 10) != groupID {
+		return 0, false
+	}
+
+	threshold, err := strconv.ParseFloat(filter["similarity"], 64)
+	if err != nil {
+		threshold, _ = strconv.ParseFloat(models.DefaultSimilarity, 64)
+	}
+
+	var score float64
+	for _, field := range []struct{ value, key, matchKey string }{
+		{song.Group, "group_name", "groupMatch"},
+		{song.Song, "song", "songMatch"},
+	} {
+		want := filter[field.key]
+		if want == "" {
+			continue
+		}
+
+		got := strings.ToLower(field.value)
+		switch filter[field.matchKey] {
+		case models.MatchPrefix:
+			if !strings.HasPrefix(got, strings.ToLower(want)) {
+				return 0, false
+			}
+		case models.MatchContains:
+			if !strings.Contains(got, strings.ToLower(want)) {
+				return 0, false
+			}
+		case models.MatchFuzzy:
+			similarity := trigramSimilarity(field.value, want)
+			if similarity < threshold {
+				return 0, false
+			}
+			score += similarity
+		default:
+			if field.value != want {
+				return 0, false
+			}
+		}
+	}
+
+	if link := filter["link"]; link != "" && song.Link != link {
+		return 0, false
+	}
+	if releaseDate := filter["releaseDate"]; releaseDate != "" && song.ReleaseDate != releaseDate {
+		return 0, false
+	}
+	// Даты хранятся в формате YYYY-MM-DD, поэтому строковое сравнение совпадает с хронологическим
+	if startDate := filter["startDate"]; startDate != "" && song.ReleaseDate < startDate {
+		return 0, false
+	}
+	if endDate := filter["endDate"]; endDate != "" && song.ReleaseDate > endDate {
+		return 0, false
+	}
+	return score, true
+}
+
+// paginate возвращает часть items; limit < 0 означает без ограничения
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// ExportSongs копирует выборку под блокировкой и передает ее в fn уже без блокировки,
+// чтобы медленный получатель не задерживал запись в хранилище; отмена ctx прерывает выгрузку
+func (m *MemoryStore) ExportSongs(ctx context.Context, filter map[string]string, sort []models.SortKey, withText bool, fn func(song models.Song, verses []string) error) error {
+	m.mu.RLock()
+	songs := m.filterSongsLocked(filter, sort)
+	verses := make([][]string, len(songs))
+	if withText {
+		for i, s := range songs {
+			verses[i] = renderVerses(m.songs[s.ID].verses)
+		}
+	}
+	m.mu.RUnlock()
+
+	for i, s := range songs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(s, verses[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
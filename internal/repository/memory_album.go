@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"sort"
+	"strconv"
+)
+
+type memoryAlbum struct {
+	info   models.Album
+	tracks []uint
+}
+
+func (m *MemoryStore) CreateAlbum(album *models.Album) (uint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.groups[album.GroupID]; !ok {
+		return 0, ErrGroupNotFound
+	}
+	if m.findAlbumLocked(album.GroupID, album.Title) != nil {
+		return 0, ErrDuplicate
+	}
+
+	a := &memoryAlbum{info: *album}
+	a.info.ID = m.nextAlbumID
+	m.nextAlbumID++
+	m.albums[a.info.ID] = a
+
+	return a.info.ID, nil
+}
+
+func (m *MemoryStore) GetAlbums(filter map[string]string, limit, offset int) ([]models.Album, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var albums []models.Album
+	for _, a := range m.albums {
+		if groupID := filter["group_id"]; groupID != "" && strconv.FormatUint(uint64(a.info.GroupID), 10) != groupID {
+			continue
+		}
+		if albumType := filter["type"]; albumType != "" && a.info.Type != albumType {
+			continue
+		}
+		albums = append(albums, a.info)
+	}
+
+	// ORDER BY release_date DESC NULLS LAST, id
+	sort.Slice(albums, func(i, j int) bool {
+		if albums[i].ReleaseDate != albums[j].ReleaseDate {
+			if albums[i].ReleaseDate == "" || albums[j].ReleaseDate == "" {
+				return albums[j].ReleaseDate == ""
+			}
+			return albums[i].ReleaseDate > albums[j].ReleaseDate
+		}
+		return albums[i].ID < albums[j].ID
+	})
+
+	return paginate(albums, limit, offset), nil
+}
+
+func (m *MemoryStore) GetAlbumByID(id uint) (*models.Album, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	a, ok := m.albums[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	album := a.info
+	return &album, nil
+}
+
+func (m *MemoryStore) UpdateAlbum(id uint, patch *models.AlbumPatch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.albums[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	if patch.Title != nil {
+		if other := m.findAlbumLocked(a.info.GroupID, *patch.Title); other != nil && other != a {
+			return ErrDuplicate
+		}
+		a.info.Title = *patch.Title
+	}
+	if patch.ReleaseDate != nil {
+		a.info.ReleaseDate = *patch.ReleaseDate
+	}
+	if patch.Type != nil {
+		a.info.Type = *patch.Type
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) DeleteAlbum(id uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.albums[id]; !ok {
+		return sql.ErrNoRows
+	}
+
+	delete(m.albums, id)
+	return nil
+}
+
+func (m *MemoryStore) GetAlbumTracks(id uint) ([]models.AlbumTrack, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	a, ok := m.albums[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	tracks := []models.AlbumTrack{}
+	for i, songID := range a.tracks {
+		if s, ok := m.liveSongLocked(songID); ok {
+			tracks = append(tracks, models.AlbumTrack{Position: i + 1, Song: s.info})
+		}
+	}
+
+	return tracks, nil
+}
+
+func (m *MemoryStore) SetAlbumTracks(id uint, songIDs []uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.albums[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	seen := make(map[uint]bool, len(songIDs))
+	for _, songID := range songIDs {
+		if _, ok := m.liveSongLocked(songID); !ok {
+			return ErrSongNotFound
+		}
+		if seen[songID] {
+			return ErrDuplicate
+		}
+		seen[songID] = true
+	}
+
+	a.tracks = append([]uint(nil), songIDs...)
+	return nil
+}
+
+// removeTrackLocked убирает окончательно удаленную песню из всех альбомов (аналог ON DELETE CASCADE).
+// Номера остальных треков не меняются, на месте удаленного остается пропуск.
+func (m *MemoryStore) removeTrackLocked(songID uint) {
+	for _, a := range m.albums {
+		for i, id := range a.tracks {
+			if id == songID {
+				a.tracks[i] = 0
+			}
+		}
+	}
+}
+
+func (m *MemoryStore) albumHasSongLocked(albumID, songID uint) bool {
+	a, ok := m.albums[albumID]
+	if !ok {
+		return false
+	}
+	for _, id := range a.tracks {
+		if id == songID {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryStore) findAlbumLocked(groupID uint, title string) *memoryAlbum {
+	for _, a := range m.albums {
+		if a.info.GroupID == groupID && a.info.Title == title {
+			return a
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"slices"
+	"strings"
+	"time"
+)
+
+type memoryAuditEntry struct {
+	entry models.AuditEntry
+	at    time.Time
+}
+
+func memorySongState(s *memorySong) songState {
+	return songState{
+		group:       s.info.Group,
+		song:        s.info.Song,
+		releaseDate: s.info.ReleaseDate,
+		link:        s.info.Link,
+		text:        strings.Join(renderVerses(s.verses), "\n\n"),
+	}
+}
+
+// recordChangeLocked записывает новую ревизию песни и запись аудита с изменениями относительно before
+func (m *MemoryStore) recordChangeLocked(s *memorySong, action string, meta models.ChangeMeta, before songState) {
+	m.addRevisionLocked(s, meta.Actor)
+	m.auditLocked(s, action, meta, before.changes(memorySongState(s)))
+}
+
+// auditLocked записывает изменение песни в журнал аудита
+func (m *MemoryStore) auditLocked(s *memorySong, action string, meta models.ChangeMeta, changes []models.FieldChange) {
+	if changes == nil {
+		changes = []models.FieldChange{}
+	}
+	now := time.Now().UTC()
+	m.audit = append(m.audit, memoryAuditEntry{
+		entry: models.AuditEntry{
+			ID:        uint(len(m.audit) + 1),
+			SongID:    s.info.ID,
+			Group:     s.info.Group,
+			Song:      s.info.Song,
+			Action:    action,
+			Actor:     meta.Actor,
+			RequestID: meta.RequestID,
+			Source:    meta.Source,
+			Changes:   changes,
+			CreatedAt: now.Format(memoryTimeFormat),
+		},
+		at: now,
+	})
+}
+
+func (m *MemoryStore) GetAuditLog(filter models.AuditFilter, limit, offset int) ([]models.AuditEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := []models.AuditEntry{}
+	for _, e := range slices.Backward(m.audit) {
+		switch {
+		case filter.SongID != 0 && e.entry.SongID != filter.SongID,
+			filter.Actor != "" && e.entry.Actor != filter.Actor,
+			!filter.From.IsZero() && e.at.Before(filter.From),
+			!filter.To.IsZero() && !e.at.Before(filter.To):
+			continue
+		}
+		entry := e.entry
+		entry.Changes = slices.Clone(entry.Changes)
+		entries = append(entries, entry)
+	}
+
+	entries = paginate(entries, limit, offset)
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"database/sql"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"sort"
+	"strings"
+)
+
+func (m *MemoryStore) CreateGroup(group *models.Group) (uint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.findGroupLocked(group.Name) != nil {
+		return 0, ErrDuplicate
+	}
+
+	g := *group
+	g.ID = m.nextGroupID
+	m.nextGroupID++
+	m.groups[g.ID] = &g
+
+	return g.ID, nil
+}
+
+func (m *MemoryStore) GetGroups(filter map[string]string, limit, offset int) ([]models.Group, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var groups []models.Group
+	for _, g := range m.groups {
+		if name := filter["name"]; name != "" && !strings.EqualFold(g.Name, name) {
+			continue
+		}
+		if country := filter["country"]; country != "" && !strings.EqualFold(g.Country, country) {
+			continue
+		}
+		groups = append(groups, *g)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Name != groups[j].Name {
+			return groups[i].Name < groups[j].Name
+		}
+		return groups[i].ID < groups[j].ID
+	})
+
+	return paginate(groups, limit, offset), nil
+}
+
+func (m *MemoryStore) GetGroupByID(id uint) (*models.Group, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	g, ok := m.groups[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	group := *g
+	return &group, nil
+}
+
+func (m *MemoryStore) UpdateGroup(id uint, patch *models.GroupPatch, meta models.ChangeMeta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g, ok := m.groups[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	if patch.Name != nil {
+		if other := m.findGroupLocked(*patch.Name); other != nil && other != g {
+			return ErrDuplicate
+		}
+		g.Name = *patch.Name
+		for _, s := range m.songs {
+			if s.info.GroupID == id && s.info.Group != g.Name {
+				before := memorySongState(s)
+				s.info.Group = g.Name
+				s.info.UpdatedAt = memoryNow()
+				m.recordChangeLocked(s, models.AuditUpdate, meta, before)
+			}
+		}
+	}
+	if patch.Country != nil {
+		g.Country = *patch.Country
+	}
+	if patch.FormedYear != nil {
+		year := *patch.FormedYear
+		g.FormedYear = &year
+	}
+	if patch.Description != nil {
+		g.Description = *patch.Description
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) DeleteGroup(id uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.groups[id]; !ok {
+		return sql.ErrNoRows
+	}
+
+	for _, s := range m.songs {
+		if s.info.GroupID == id {
+			return ErrInUse
+		}
+	}
+	for _, a := range m.albums {
+		if a.info.GroupID == id {
+			return ErrInUse
+		}
+	}
+
+	delete(m.groups, id)
+	return nil
+}
+
+// ensureGroupLocked находит группу по названию без учета регистра или создает новую
+func (m *MemoryStore) ensureGroupLocked(name string) *models.Group {
+	if g := m.findGroupLocked(name); g != nil {
+		return g
+	}
+
+	g := &models.Group{ID: m.nextGroupID, Name: name}
+	m.nextGroupID++
+	m.groups[g.ID] = g
+
+	return g
+}
+
+func (m *MemoryStore) findGroupLocked(name string) *models.Group {
+	for _, g := range m.groups {
+		if strings.EqualFold(g.Name, name) {
+			return g
+		}
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"database/sql"
+	"maps"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"sort"
+	"time"
+)
+
+type memoryJob struct {
+	job   models.EnrichmentJob
+	runAt time.Time
+}
+
+func (m *MemoryStore) CreatePendingSong(group, song string, verses []models.Verse, maxAttempts int, meta models.ChangeMeta) (uint, uint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.songExistsLocked(group, song) {
+		return 0, 0, ErrDuplicate
+	}
+
+	g := m.ensureGroupLocked(group)
+	now := time.Now().UTC()
+	ts := now.Format(memoryTimeFormat)
+
+	songID := m.nextID
+	m.nextID++
+	m.songs[songID] = &memorySong{
+		info: models.Song{
+			ID:               songID,
+			GroupID:          g.ID,
+			Group:            g.Name,
+			Song:             song,
+			CreatedAt:        ts,
+			UpdatedAt:        ts,
+			EnrichmentStatus: models.EnrichmentPending,
+		},
+		verses: resolveVerses(verses),
+	}
+	m.recordChangeLocked(m.songs[songID], models.AuditCreate, meta, songState{})
+
+	jobID := m.nextJobID
+	m.nextJobID++
+	m.jobs[jobID] = &memoryJob{
+		job: models.EnrichmentJob{
+			ID:          jobID,
+			SongID:      songID,
+			Status:      models.JobQueued,
+			MaxAttempts: maxAttempts,
+			RunAt:       ts,
+			CreatedAt:   ts,
+			UpdatedAt:   ts,
+		},
+		runAt: now,
+	}
+
+	return songID, jobID, nil
+}
+
+func (m *MemoryStore) GetEnrichmentJob(id uint) (*models.EnrichmentJob, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	job := j.job
+	return &job, nil
+}
+
+func (m *MemoryStore) ClaimEnrichmentJob(lease time.Duration) (*models.EnrichmentJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+
+	var due []*memoryJob
+	for _, j := range m.jobs {
+		if (j.job.Status != models.JobQueued && j.job.Status != models.JobRunning) || j.runAt.After(now) {
+			continue
+		}
+		// Аренда последней попытки истекла: задача завершается с ошибкой, как в ClaimEnrichmentJob
+		if j.job.Attempts >= j.job.MaxAttempts {
+			m.finishJobLocked(j.job.ID, models.JobFailed, leaseExpiredError)
+			if s, ok := m.songs[j.job.SongID]; ok {
+				s.info.EnrichmentStatus = models.EnrichmentFailed
+				s.info.UpdatedAt = memoryNow()
+			}
+			continue
+		}
+		if s, ok := m.songs[j.job.SongID]; ok && s.deleted() {
+			continue
+		}
+		due = append(due, j)
+	}
+	if len(due) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	sort.Slice(due, func(a, b int) bool {
+		if !due[a].runAt.Equal(due[b].runAt) {
+			return due[a].runAt.Before(due[b].runAt)
+		}
+		return due[a].job.ID < due[b].job.ID
+	})
+
+	j := due[0]
+	j.job.Status = models.JobRunning
+	j.job.Attempts++
+	j.setRunAt(now.Add(lease))
+	j.job.UpdatedAt = now.Format(memoryTimeFormat)
+
+	job := j.job
+	return &job, nil
+}
+
+func (m *MemoryStore) CompleteEnrichmentJob(jobID, songID uint, attempt int, details *models.SongDetails, verses []models.Verse, meta models.ChangeMeta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.ownsJobLocked(jobID, attempt) {
+		return ErrLeaseLost
+	}
+	s, ok := m.songs[songID]
+	if !ok || s.deleted() {
+		return sql.ErrNoRows
+	}
+
+	before := memorySongState(s)
+	if s.info.ReleaseDate == "" {
+		s.info.ReleaseDate = details.ReleaseDate
+	}
+	if s.info.Link == "" {
+		s.info.Link = details.Link
+	}
+	if len(s.verses) == 0 {
+		s.verses = resolveVerses(verses)
+	}
+	s.info.EnrichmentStatus = models.EnrichmentDone
+	s.info.UpdatedAt = memoryNow()
+	m.recordChangeLocked(s, models.AuditUpdate, meta, before)
+
+	if len(details.Sources) > 0 {
+		m.jobs[jobID].job.Sources = maps.Clone(details.Sources)
+	}
+	m.finishJobLocked(jobID, models.JobDone, "")
+	return nil
+}
+
+func (m *MemoryStore) RetryEnrichmentJob(jobID uint, attempt int, delay time.Duration, lastError string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.ownsJobLocked(jobID, attempt) {
+		return ErrLeaseLost
+	}
+
+	j := m.jobs[jobID]
+	j.job.Status = models.JobQueued
+	j.job.LastError = lastError
+	j.setRunAt(time.Now().UTC().Add(delay))
+	j.job.UpdatedAt = memoryNow()
+
+	return nil
+}
+
+func (m *MemoryStore) PostponeEnrichmentJob(jobID uint, attempt int, lastError string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.ownsJobLocked(jobID, attempt) {
+		return ErrLeaseLost
+	}
+
+	j := m.jobs[jobID]
+	j.job.Status = models.JobQueued
+	j.job.Attempts--
+	j.job.LastError = lastError
+	j.setRunAt(time.Now().UTC())
+	j.job.UpdatedAt = memoryNow()
+
+	return nil
+}
+
+func (m *MemoryStore) FailEnrichmentJob(jobID, songID uint, attempt int, lastError string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.ownsJobLocked(jobID, attempt) {
+		return ErrLeaseLost
+	}
+
+	if s, ok := m.songs[songID]; ok {
+		s.info.EnrichmentStatus = models.EnrichmentFailed
+		s.info.UpdatedAt = memoryNow()
+	}
+
+	m.finishJobLocked(jobID, models.JobFailed, lastError)
+	return nil
+}
+
+// ownsJobLocked сообщает, что задача все еще выполняется попыткой attempt, как lockOwnedJob
+func (m *MemoryStore) ownsJobLocked(jobID uint, attempt int) bool {
+	j, ok := m.jobs[jobID]
+	return ok && j.job.Status == models.JobRunning && j.job.Attempts == attempt
+}
+
+func (m *MemoryStore) finishJobLocked(jobID uint, status, lastError string) {
+	if j, ok := m.jobs[jobID]; ok {
+		j.job.Status = status
+		j.job.LastError = lastError
+		j.job.UpdatedAt = memoryNow()
+	}
+}
+
+// removeJobsLocked удаляет задачи песни, как ON DELETE CASCADE в PostgreSQL
+func (m *MemoryStore) removeJobsLocked(songID uint) {
+	for id, j := range m.jobs {
+		if j.job.SongID == songID {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func (j *memoryJob) setRunAt(t time.Time) {
+	j.runAt = t
+	j.job.RunAt = t.Format(memoryTimeFormat)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+)
+
+// addRevisionLocked записывает текущее состояние песни следующей ревизией
+func (m *MemoryStore) addRevisionLocked(s *memorySong, author string) {
+	s.revisions = append(s.revisions, models.SongRevision{
+		SongID:      s.info.ID,
+		Rev:         len(s.revisions) + 1,
+		Author:      author,
+		CreatedAt:   memoryNow(),
+		GroupID:     s.info.GroupID,
+		Group:       s.info.Group,
+		Song:        s.info.Song,
+		ReleaseDate: s.info.ReleaseDate,
+		Link:        s.info.Link,
+		Verses:      resolveVerses(s.verses),
+	})
+}
+
+func (m *MemoryStore) GetSongRevisions(songID uint, limit, offset int) ([]models.SongRevision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.liveSongLocked(songID)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	revisions := make([]models.SongRevision, 0, len(s.revisions))
+	for i := len(s.revisions) - 1; i >= 0; i-- {
+		rev := s.revisions[i]
+		rev.Verses = nil
+		revisions = append(revisions, rev)
+	}
+
+	return paginate(revisions, limit, offset), nil
+}
+
+func (m *MemoryStore) GetSongRevision(songID uint, rev int) (*models.SongRevision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.liveSongLocked(songID)
+	if !ok || rev < 1 || rev > len(s.revisions) {
+		return nil, sql.ErrNoRows
+	}
+
+	revision := s.revisions[rev-1]
+	revision.Verses = resolveVerses(revision.Verses)
+	return &revision, nil
+}
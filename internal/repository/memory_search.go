@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SearchLyrics в памяти ищет термины запроса как подстроки без учета регистра.
+// Морфология языков не поддерживается, язык запроса игнорируется.
+func (m *MemoryStore) SearchLyrics(q models.SearchQuery, limit, offset int) ([]models.SearchHit, error) {
+	include, exclude := parseMemorySearchQuery(q.Query, q.Mode)
+	if len(include) == 0 {
+		return []models.SearchHit{}, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	hits := []models.SearchHit{}
+	for _, s := range m.songs {
+		if s.deleted() {
+			continue
+		}
+		for i, v := range s.verses {
+			// Повтор находится один раз, как и в PostgreSQL, где его текст не хранится
+			if v.RepeatOf != 0 {
+				continue
+			}
+			verse := v.Text
+			rank, ok := rankVerse(strings.ToLower(verse), include, exclude)
+			if !ok {
+				continue
+			}
+			hits = append(hits, models.SearchHit{
+				SongID:    s.info.ID,
+				Group:     s.info.Group,
+				Song:      s.info.Song,
+				Verse:     i + 1,
+				Rank:      rank,
+				Highlight: highlightTerms(verse, include),
+			})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		if hits[i].SongID != hits[j].SongID {
+			return hits[i].SongID < hits[j].SongID
+		}
+		return hits[i].Verse < hits[j].Verse
+	})
+
+	if hits = paginate(hits, limit, offset); hits == nil {
+		hits = []models.SearchHit{}
+	}
+
+	return hits, nil
+}
+
+var searchTermRegex = regexp.MustCompile(`-?"[^"]*"|\S+`)
+
+// parseMemorySearchQuery разбирает запрос на обязательные и исключаемые термины.
+// В режиме websearch поддерживаются "фразы в кавычках" и -исключения.
+func parseMemorySearchQuery(query, mode string) (include, exclude []string) {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	switch mode {
+	case models.SearchModePhrase:
+		if query != "" {
+			include = append(include, strings.Join(strings.Fields(query), " "))
+		}
+		return include, nil
+	case models.SearchModeWebsearch:
+		for _, term := range searchTermRegex.FindAllString(query, -1) {
+			negate := strings.HasPrefix(term, "-")
+			term = strings.Join(strings.Fields(strings.Trim(strings.TrimPrefix(term, "-"), `"`)), " ")
+			if term == "" || term == "or" {
+				continue
+			}
+			if negate {
+				exclude = append(exclude, term)
+			} else {
+				include = append(include, term)
+			}
+		}
+		return include, exclude
+	default:
+		return strings.Fields(query), nil
+	}
+}
+
+func rankVerse(verse string, include, exclude []string) (float64, bool) {
+	for _, term := range exclude {
+		if strings.Contains(verse, term) {
+			return 0, false
+		}
+	}
+
+	matches := 0
+	for _, term := range include {
+		n := strings.Count(verse, term)
+		if n == 0 {
+			return 0, false
+		}
+		matches += n
+	}
+
+	return float64(matches) / float64(len(strings.Fields(verse))+1), true
+}
+
+func highlightTerms(verse string, terms []string) string {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+
+	verse = strings.NewReplacer(highlightStart, "", highlightStop, "").Replace(verse)
+	re := regexp.MustCompile(`(?i)(` + strings.Join(quoted, "|") + `)`)
+	return highlightMarkup(re.ReplaceAllString(verse, highlightStart+"$1"+highlightStop))
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// memoryTimeFormat совпадает с форматом created_at/updated_at, который отдает PostgreSQL
+const memoryTimeFormat = "2006-01-02T15:04:05.000000"
+
+func memoryNow() string {
+	return time.Now().UTC().Format(memoryTimeFormat)
+}
+
+// compareSortValues сравнивает значения поля сортировки: id - как числа, остальное - как строки
+func compareSortValues(field, a, b string) int {
+	if field == models.SortID {
+		x, _ := strconv.ParseUint(a, 10, 64)
+		y, _ := strconv.ParseUint(b, 10, 64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// compareSongKey сравнивает песню с набором значений ключей сортировки с учетом
+// направлений: отрицательный результат - песня идет раньше, положительный - позже
+func compareSongKey(song models.Song, keys []models.SortKey, values []string) int {
+	for i, key := range keys {
+		c := compareSortValues(key.Field, song.SortValue(key.Field), values[i])
+		if key.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func songKeyValues(song models.Song, keys []models.SortKey) []string {
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = song.SortValue(key.Field)
+	}
+	return values
+}
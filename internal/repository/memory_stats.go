@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+)
+
+func (m *MemoryStore) GetLibraryStats(_ context.Context) (*models.LibraryStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := &models.LibraryStats{Groups: len(m.groups), Albums: len(m.albums)}
+	for _, s := range m.songs {
+		if s.deleted() {
+			stats.DeletedSongs++
+		} else {
+			stats.Songs++
+		}
+	}
+	for _, j := range m.jobs {
+		if j.job.Status == models.JobQueued || j.job.Status == models.JobRunning {
+			stats.PendingJobs++
+		}
+	}
+
+	return stats, nil
+}
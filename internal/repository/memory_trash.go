@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"database/sql"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"sort"
+	"time"
+)
+
+func (m *MemoryStore) GetDeletedSongs(limit, offset int) ([]models.Song, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var deleted []*memorySong
+	for _, s := range m.songs {
+		if s.deleted() {
+			deleted = append(deleted, s)
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool {
+		if !deleted[i].deletedAt.Equal(deleted[j].deletedAt) {
+			return deleted[i].deletedAt.After(deleted[j].deletedAt)
+		}
+		return deleted[i].info.ID > deleted[j].info.ID
+	})
+
+	songs := []models.Song{}
+	for _, s := range paginate(deleted, limit, offset) {
+		song := s.info
+		song.DeletedAt = s.deletedAt.Format(memoryTimeFormat)
+		songs = append(songs, song)
+	}
+
+	return songs, nil
+}
+
+func (m *MemoryStore) RestoreSong(id uint, meta models.ChangeMeta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.songs[id]
+	if !ok || !s.deleted() {
+		return sql.ErrNoRows
+	}
+
+	if m.findLocked(s.info.Group, s.info.Song) != nil {
+		return ErrDuplicate
+	}
+
+	s.deletedAt = time.Time{}
+	m.auditLocked(s, models.AuditRestore, meta, nil)
+	return nil
+}
+
+func (m *MemoryStore) PurgeSong(id uint, meta models.ChangeMeta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.songs[id]
+	if !ok || !s.deleted() {
+		return sql.ErrNoRows
+	}
+
+	m.purgeLocked(s, meta)
+	return nil
+}
+
+func (m *MemoryStore) PurgeDeletedSongs(retention time.Duration, meta models.ChangeMeta) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := time.Now().UTC().Add(-retention)
+	purged := 0
+	for _, s := range m.songs {
+		if s.deleted() && s.deletedAt.Before(cutoff) {
+			m.purgeLocked(s, meta)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// purgeLocked окончательно удаляет песню вместе с треками альбомов и задачами
+func (m *MemoryStore) purgeLocked(s *memorySong, meta models.ChangeMeta) {
+	id := s.info.ID
+	delete(m.songs, id)
+	m.removeTrackLocked(id)
+	m.removeJobsLocked(id)
+	m.auditLocked(s, models.AuditPurge, meta, nil)
+}
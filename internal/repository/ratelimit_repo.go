@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RateLimitStore хранит корзины токенов ограничителя запросов в PostgreSQL,
+// чтобы бюджет клиента был общим для всех реплик сервиса
+type RateLimitStore struct {
+	db *sql.DB
+}
+
+func (r *Repository) RateLimitStore() *RateLimitStore {
+	return &RateLimitStore{db: r.db}
+}
+
+// refilledTokens - SQL-выражение для Take: токены корзины b, пополненные по времени базы
+// со скоростью $3, но не больше емкости $2
+const refilledTokens = `LEAST($2::DOUBLE PRECISION, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3)`
+
+// Take пополняет корзину key по времени базы и забирает из нее n токенов, если в ней есть целый токен.
+// Новая корзина создается полной. Пополнение и списание делает один upsert под блокировкой
+// строки, поэтому одновременные запросы одного клиента не теряют списания.
+func (s *RateLimitStore) Take(key string, burst int, rate float64, n int) (bool, float64, error) {
+	op := "repository.RateLimitStore.Take"
+
+	query := `
+	INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+	VALUES ($1, $2::DOUBLE PRECISION - $4, TRUE, NOW())
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = ` + refilledTokens + ` - CASE WHEN ` + refilledTokens + ` >= 1 THEN $4 ELSE 0 END,
+		allowed = ` + refilledTokens + ` >= 1,
+		updated_at = NOW()
+	RETURNING allowed, tokens`
+
+	var allowed bool
+	var tokens float64
+	if err := s.db.QueryRow(query, key, burst, rate, n).Scan(&allowed, &tokens); err != nil {
+		return false, 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return allowed, tokens, nil
+}
+
+// Sweep удаляет корзины, которые не менялись дольше idle
+func (s *RateLimitStore) Sweep(idle time.Duration) (int, error) {
+	op := "repository.RateLimitStore.Sweep"
+
+	query := `DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - $1 * INTERVAL '1 millisecond'`
+	result, err := s.db.Exec(query, idle.Milliseconds())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	swept, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return int(swept), nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimitStore(t *testing.T) {
+	store := newTestRepository(t).RateLimitStore()
+
+	// Пополнение за время теста не дает целого токена
+	const rate = 0.001
+
+	for i := 0; i < 2; i++ {
+		allowed, tokens, err := store.Take("auth:ip:1", 2, rate, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !allowed || tokens < float64(1-i) || tokens >= float64(1-i)+0.1 {
+			t.Fatalf("take %d: %v, %v", i+1, allowed, tokens)
+		}
+	}
+	allowed, tokens, err := store.Take("auth:ip:1", 2, rate, 1)
+	if err != nil || allowed || tokens >= 1 {
+		t.Fatalf("take from an empty bucket: %v, %v, %v", allowed, tokens, err)
+	}
+	// Проверка без списания у пустой корзины тоже отказывает
+	if allowed, _, err := store.Take("auth:ip:1", 2, rate, 0); err != nil || allowed {
+		t.Fatalf("check of an empty bucket: %v, %v", allowed, err)
+	}
+
+	// Имя клиента из JWT может быть длиннее прежних 512 символов
+	long := "read:client:" + strings.Repeat("x", 2000)
+	if allowed, tokens, err := store.Take(long, 2, rate, 1); err != nil || !allowed || tokens < 1 {
+		t.Fatalf("take with a long key: %v, %v, %v", allowed, tokens, err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if swept, err := store.Sweep(time.Hour); err != nil || swept != 0 {
+		t.Fatalf("Sweep(1h) = %d, %v", swept, err)
+	}
+	if swept, err := store.Sweep(10 * time.Millisecond); err != nil || swept != 2 {
+		t.Fatalf("Sweep(10ms) = %d, %v", swept, err)
+	}
+	// После очистки корзина снова полная
+	if allowed, tokens, err := store.Take("auth:ip:1", 2, rate, 1); err != nil || !allowed || tokens < 1 {
+		t.Fatalf("take after sweep: %v, %v, %v", allowed, tokens, err)
+	}
+}
+
+func TestRateLimitStoreConcurrentTakes(t *testing.T) {
+	store := newTestRepository(t).RateLimitStore()
+
+	// Одновременные запросы одного клиента не получают больше токенов, чем есть в корзине
+	const burst, requests = 5, 20
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	allowed := 0
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, _, err := store.Take("write:ip:1", burst, 0.001, 1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != burst {
+		t.Fatalf("%d of %d requests allowed, want %d", allowed, requests, burst)
+	}
+}
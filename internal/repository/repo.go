@@ -14,6 +14,11 @@ type Repository struct {
 	db *sql.DB
 }
 
+// queryer - общий интерфейс *sql.DB и *sql.Tx для вспомогательных запросов
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 func NewRepository(dbHost, dbPort, dbUser, dbPassword, dbName string) (*Repository, error) {
 	if err := EnsureDatabaseExists(dbHost, dbPort, dbUser, dbPassword, dbName); err != nil {
 		return nil, err
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+)
+
+// revisionColumns - колонки song_revisions в порядке, который ожидает scanRevision
+const revisionColumns = `song_id, rev, author, to_char(created_at, 'YYYY-MM-DD"T"HH24:MI:SS.US'),
+	COALESCE(group_id, 0), group_name, song, COALESCE(to_char(release_date, 'YYYY-MM-DD'), ''), link`
+
+// insertRevision записывает текущее состояние песни следующей ревизией.
+// Вызывается в той же транзакции, что и изменение песни.
+func insertRevision(db execer, songID uint, author string) error {
+	query := `
+	INSERT INTO song_revisions (song_id, rev, author, group_id, group_name, song, release_date, link, verses)
+	SELECT si.id, COALESCE((SELECT MAX(rev) FROM song_revisions WHERE song_id = si.id), 0) + 1,
+		$2, si.group_id, si.group_name, si.song, si.release_date, si.link,
+		COALESCE((
+			SELECT JSONB_AGG(JSONB_BUILD_OBJECT(
+				'position', sv.position, 'type', COALESCE(sv.verse_type, ''), 'repeat_of', COALESCE(sv.repeat_of, 0),
+				'text', sv.verse, 'line_times', sv.line_times) ORDER BY sv.position)
+			FROM song_verses sv WHERE sv.song_id = si.id
+		), '[]')
+	FROM song_info si WHERE si.id = $1`
+	_, err := db.Exec(query, songID, author)
+	return err
+}
+
+func scanRevision(row rowScanner, dest ...interface{}) (*models.SongRevision, error) {
+	var rev models.SongRevision
+	err := row.Scan(append([]interface{}{&rev.SongID, &rev.Rev, &rev.Author, &rev.CreatedAt,
+		&rev.GroupID, &rev.Group, &rev.Song, &rev.ReleaseDate, &rev.Link}, dest...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// GetSongRevisions возвращает ревизии песни без текста, от новых к старым
+func (r *Repository) GetSongRevisions(songID uint, limit, offset int) ([]models.SongRevision, error) {
+	op := "repository.GetSongRevisions"
+
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM song_info WHERE id = $1 AND deleted_at IS NULL)`, songID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	query := `SELECT ` + revisionColumns + ` FROM song_revisions WHERE song_id = $1 ORDER BY rev DESC LIMIT $2 OFFSET $3`
+	rows, err := r.db.Query(query, songID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+	defer rows.Close()
+
+	revisions := []models.SongRevision{}
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", op, err)
+		}
+		revisions = append(revisions, *rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return revisions, nil
+}
+
+// GetSongRevision возвращает ревизию песни вместе с куплетами
+func (r *Repository) GetSongRevision(songID uint, rev int) (*models.SongRevision, error) {
+	op := "repository.GetSongRevision"
+
+	var verses []byte
+	query := `
+	SELECT ` + revisionColumns + `, verses FROM song_revisions
+	WHERE song_id = $1 AND rev = $2
+		AND EXISTS(SELECT 1 FROM song_info WHERE id = $1 AND deleted_at IS NULL)`
+	revision, err := scanRevision(r.db.QueryRow(query, songID, rev), &verses)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	revision.Verses = []models.Verse{}
+	if err := json.Unmarshal(verses, &revision.Verses); err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return revision, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"testing"
+)
+
+func TestStoreRevisions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		id := createSong(t, store, "Muse", "Madness", "2012-08-20", "I, I can't get these memories out of my mind")
+
+		link := "https://example.com/madness-live"
+		meta := models.ChangeMeta{Actor: "ed", RequestID: "req-2", Source: "test"}
+		verses := []models.Verse{{Position: 1, Text: "I, I can't get these memories out of my mind"}, {Position: 2, RepeatOf: 1}}
+		if err := store.UpdateSongByID(id, &models.SongPatch{Link: &link}, verses, meta); err != nil {
+			t.Fatal(err)
+		}
+
+		revisions, err := store.GetSongRevisions(id, 100, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(revisions) != 2 || revisions[0].Rev != 2 || revisions[1].Rev != 1 {
+			t.Fatalf("revisions: %+v", revisions)
+		}
+		if revisions[0].Author != "ed" || revisions[1].Author != testMeta.Actor || revisions[0].Link != link || revisions[0].Verses != nil {
+			t.Fatalf("newest revision: %+v", revisions[0])
+		}
+		if page, err := store.GetSongRevisions(id, 1, 1); err != nil || len(page) != 1 || page[0].Rev != 1 {
+			t.Fatalf("revisions page: %+v, %v", page, err)
+		}
+
+		// В ревизии повтор разворачивается в текст исходного куплета
+		revision, err := store.GetSongRevision(id, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if revision.Group != "Muse" || revision.Song != "Madness" || revision.ReleaseDate != "2012-08-20" ||
+			len(revision.Verses) != 2 || revision.Verses[1].Text != "I, I can't get these memories out of my mind" {
+			t.Fatalf("revision 2: %+v", revision)
+		}
+		first, err := store.GetSongRevision(id, 1)
+		if err != nil || first.Link != "https://example.com/Madness" || len(first.Verses) != 1 {
+			t.Fatalf("revision 1: %+v, %v", first, err)
+		}
+
+		for _, rev := range []int{0, 3} {
+			if _, err := store.GetSongRevision(id, rev); !errors.Is(err, sql.ErrNoRows) {
+				t.Fatalf("GetSongRevision(%d): %v", rev, err)
+			}
+		}
+		if _, err := store.GetSongRevisions(id+100, 100, 0); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("revisions of a missing song: %v", err)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"fmt"
+	"html"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"strings"
+)
+
+// Колонки tsvector и конфигурации поиска. Значения подставляются в запрос
+// напрямую, поэтому берутся только из этих таблиц.
+var (
+	searchColumns = map[string]string{
+		models.SearchLangSimple:  "tsv_simple",
+		models.SearchLangEnglish: "tsv_english",
+		models.SearchLangRussian: "tsv_russian",
+	}
+	searchQueryFuncs = map[string]string{
+		models.SearchModePlain:     "plainto_tsquery",
+		models.SearchModePhrase:    "phraseto_tsquery",
+		models.SearchModeWebsearch: "websearch_to_tsquery",
+	}
+)
+
+// Совпадения отмечаются управляющими символами, которых нет в тексте: после экранирования
+// HTML они заменяются на <b> и </b>, поэтому разметка из текста песни не попадает в ответ
+const (
+	highlightStart = "\x01"
+	highlightStop  = "\x02"
+
+	searchHeadlineOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop +
+		", MaxWords=25, MinWords=8, MaxFragments=3, FragmentDelimiter=\" ... \""
+)
+
+// highlightMarkup экранирует фрагмент как HTML и заменяет метки совпадений на <b>...</b>
+func highlightMarkup(fragment string) string {
+	fragment = html.EscapeString(fragment)
+	fragment = strings.ReplaceAll(fragment, highlightStart, "<b>")
+	return strings.ReplaceAll(fragment, highlightStop, "</b>")
+}
+
+func (r *Repository) SearchLyrics(q models.SearchQuery, limit, offset int) ([]models.SearchHit, error) {
+	op := "repository.SearchLyrics"
+
+	column, ok := searchColumns[q.Language]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported search language %q", op, q.Language)
+	}
+	queryFunc, ok := searchQueryFuncs[q.Mode]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported search mode %q", op, q.Mode)
+	}
+
+	query := fmt.Sprintf(`
+	WITH q AS (SELECT %[2]s('%[3]s'::regconfig, $1) AS query)
+	SELECT si.id, si.group_name, si.song,
+		st.position AS verse_index,
+		ts_rank(st.%[1]s, q.query) AS score,
+		ts_headline('%[3]s'::regconfig, translate(st.verse, E'\x01\x02', ''), q.query, $2) AS highlight
+	FROM song_text st
+	JOIN song_info si ON si.id = st.song_id
+	CROSS JOIN q
+	WHERE st.%[1]s @@ q.query AND si.deleted_at IS NULL
+	ORDER BY score DESC, si.id, verse_index
+	LIMIT $3 OFFSET $4`, column, queryFunc, q.Language)
+
+	rows, err := r.db.Query(query, q.Query, searchHeadlineOptions, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+	defer rows.Close()
+
+	hits := []models.SearchHit{}
+	for rows.Next() {
+		var hit models.SearchHit
+		if err := rows.Scan(&hit.SongID, &hit.Group, &hit.Song, &hit.Verse, &hit.Rank, &hit.Highlight); err != nil {
+			return nil, fmt.Errorf("%s: %v", op, err)
+		}
+		hit.Highlight = highlightMarkup(hit.Highlight)
+		hits = append(hits, hit)
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStoreSearchLyrics(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		uprising := createSong(t, store, "Muse", "Uprising", "2009-09-07",
+			"Paranoia is in bloom\nThe PR transmissions will resume",
+			"They will not force us\nThey will stop degrading us")
+		hysteria := createSong(t, store, "Muse", "Hysteria", "2003-12-01", "It's bugging me\nGrating me", "<script>alert(1)</script> force of habit")
+		trashed := createSong(t, store, "Muse", "Madness", "2012-08-20", "They will force")
+		if err := store.DeleteSongByID(trashed, testMeta); err != nil {
+			t.Fatal(err)
+		}
+
+		search := func(query, mode string) []models.SearchHit {
+			t.Helper()
+			hits, err := store.SearchLyrics(models.SearchQuery{Query: query, Language: models.SearchLangSimple, Mode: mode}, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return hits
+		}
+		songsOf := func(hits []models.SearchHit) []uint {
+			var ids []uint
+			for _, h := range hits {
+				ids = append(ids, h.SongID)
+			}
+			slices.Sort(ids)
+			return ids
+		}
+
+		hits := search("force", models.SearchModeWebsearch)
+		if !slices.Equal(songsOf(hits), []uint{uprising, hysteria}) {
+			t.Fatalf("hits for force: %+v", hits)
+		}
+		for _, h := range hits {
+			if !strings.Contains(h.Highlight, "<b>force</b>") {
+				t.Errorf("highlight %q does not mark the match", h.Highlight)
+			}
+			if strings.Contains(h.Highlight, "<script>") {
+				t.Errorf("highlight %q contains markup from the lyrics", h.Highlight)
+			}
+		}
+
+		if hits = search("force -habit", models.SearchModeWebsearch); len(hits) != 1 || hits[0].SongID != uprising || hits[0].Verse != 2 {
+			t.Fatalf("hits for force -habit: %+v", hits)
+		}
+		if hits = search("will resume", models.SearchModePhrase); len(hits) != 1 || hits[0].Verse != 1 {
+			t.Fatalf("phrase hits: %+v", hits)
+		}
+		if hits = search("resume will", models.SearchModePhrase); len(hits) != 0 {
+			t.Fatalf("phrase hits in another order: %+v", hits)
+		}
+		if hits = search("bloom degrading", models.SearchModePlain); len(hits) != 0 {
+			t.Fatalf("plain query matched terms from different verses: %+v", hits)
+		}
+	})
+}
+
+func TestHighlightTermsEscapesLyrics(t *testing.T) {
+	for _, tc := range []struct {
+		verse string
+		terms []string
+		want  string
+	}{
+		{"They will not force us", []string{"force"}, "They will not <b>force</b> us"},
+		{"Force of FORCE", []string{"force"}, "<b>Force</b> of <b>FORCE</b>"},
+		{`<img src=x onerror="alert(1)"> force`, []string{"force"}, "&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <b>force</b>"},
+		// Метки совпадений из самого текста не превращаются в теги
+		{"a\x01b\x02 force", []string{"force"}, "ab <b>force</b>"},
+		{"a+b (c)", []string{"a+b", "(c)"}, "<b>a+b</b> <b>(c)</b>"},
+	} {
+		if got := highlightTerms(tc.verse, tc.terms); got != tc.want {
+			t.Errorf("highlightTerms(%q, %q) = %q, want %q", tc.verse, tc.terms, got, tc.want)
+		}
+	}
+}
+
+func TestParseMemorySearchQuery(t *testing.T) {
+	for _, tc := range []struct {
+		query, mode      string
+		include, exclude []string
+	}{
+		{"Force  US", models.SearchModePlain, []string{"force", "us"}, nil},
+		{" will  resume ", models.SearchModePhrase, []string{"will resume"}, nil},
+		{`"will not" force -habit or us`, models.SearchModeWebsearch, []string{"will not", "force", "us"}, []string{"habit"}},
+		{`-"force of"`, models.SearchModeWebsearch, nil, []string{"force of"}},
+	} {
+		include, exclude := parseMemorySearchQuery(tc.query, tc.mode)
+		if !slices.Equal(include, tc.include) || !slices.Equal(exclude, tc.exclude) {
+			t.Errorf("parseMemorySearchQuery(%q, %s) = %q, %q, want %q, %q", tc.query, tc.mode, include, exclude, tc.include, tc.exclude)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"strings"
+)
+
+// songFilter - условия выборки песен, построенные по фильтру из запроса
+type songFilter struct {
+	where     string
+	args      []interface{}
+	score     string // выражение сходства для fuzzy-фильтров, пусто если их нет
+	threshold string // порог pg_trgm.similarity_threshold для fuzzy-фильтров
+}
+
+func buildSongFilter(filter map[string]string) songFilter {
+	var f songFilter
+	argIndex := 1
+
+	add := func(cond string, arg interface{}) {
+		f.where += fmt.Sprintf(" AND "+cond, argIndex)
+		f.args = append(f.args, arg)
+		argIndex++
+	}
+
+	if groupID, ok := filter["group_id"]; ok && groupID != "" {
+		add("group_id = $%d", groupID)
+	}
+	if albumID, ok := filter["album_id"]; ok && albumID != "" {
+		add("id IN (SELECT song_id FROM album_tracks WHERE album_id = $%d)", albumID)
+	}
+	for _, field := range []struct{ column, key, matchKey string }{
+		{"group_name", "group_name", "groupMatch"},
+		{"song", "song", "songMatch"},
+	} {
+		value, ok := filter[field.key]
+		if !ok || value == "" {
+			continue
+		}
+
+		switch filter[field.matchKey] {
+		case models.MatchPrefix:
+			add(field.column+" ILIKE $%d", escapeLike(value)+"%")
+		case models.MatchContains:
+			add(field.column+" ILIKE $%d", "%"+escapeLike(value)+"%")
+		case models.MatchFuzzy:
+			add(field.column+" %% $%d", value)
+			score := fmt.Sprintf("similarity(%s, $%d)", field.column, argIndex-1)
+			if f.score != "" {
+				score = f.score + " + " + score
+			}
+			f.score = score
+			f.threshold = filter["similarity"]
+			if f.threshold == "" {
+				f.threshold = models.DefaultSimilarity
+			}
+		default:
+			add(field.column+" = $%d", value)
+		}
+	}
+	if link, ok := filter["link"]; ok && link != "" {
+		add("link = $%d", link)
+	}
+	if releaseDate, ok := filter["releaseDate"]; ok && releaseDate != "" {
+		add("release_date = $%d", releaseDate)
+	}
+	if startDate, ok := filter["startDate"]; ok && startDate != "" {
+		add("release_date >= $%d", startDate)
+	}
+	if endDate, ok := filter["endDate"]; ok && endDate != "" {
+		add("release_date <= $%d", endDate)
+	}
+
+	return f
+}
+
+// query выполняет запрос с условиями фильтра. Для fuzzy-фильтров порог сходства
+// задается на время транзакции, чтобы оператор % мог использовать trigram-индексы.
+func (f songFilter) query(db *sql.DB, query string, args []interface{}, scan func(*sql.Rows) error) error {
+	if f.threshold == "" {
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		return scan(rows)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, f.threshold); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if err := scan(rows); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
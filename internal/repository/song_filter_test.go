@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestStoreSongMatchModes(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		createSong(t, store, "Muse", "Uprising", "2009-09-07")
+		createSong(t, store, "Muse", "Uprise", "2009-09-06")
+		createSong(t, store, "Muse", "100% Uprising_Live", "2009-09-05")
+		createSong(t, store, "Muse", "Hysteria", "2003-12-01")
+		createSong(t, store, "Queen", "Bohemian Rhapsody", "1975-10-31")
+
+		for _, tc := range []struct {
+			name   string
+			filter map[string]string
+			want   []string
+		}{
+			{"exact is case-sensitive", map[string]string{"song": "uprising"}, nil},
+			{"prefix", map[string]string{"song": "upris", "songMatch": models.MatchPrefix}, []string{"Uprising", "Uprise"}},
+			{"contains", map[string]string{"song": "RISING", "songMatch": models.MatchContains}, []string{"Uprising", "100% Uprising_Live"}},
+			{"like wildcards are literal", map[string]string{"song": "0% u", "songMatch": models.MatchContains}, []string{"100% Uprising_Live"}},
+			{"underscore is literal", map[string]string{"song": "g_l", "songMatch": models.MatchContains}, []string{"100% Uprising_Live"}},
+			{"group prefix", map[string]string{"group_name": "QU", "groupMatch": models.MatchPrefix}, []string{"Bohemian Rhapsody"}},
+			// Без явной сортировки fuzzy-результаты идут по убыванию сходства
+			// similarity: Uprising 6/11, Uprise 5/10, 100% Uprising_Live 6/20
+			{"fuzzy", map[string]string{"song": "Uprisng", "songMatch": models.MatchFuzzy, "similarity": "0.4"}, []string{"Uprising", "Uprise"}},
+			{"fuzzy threshold", map[string]string{"song": "Uprisng", "songMatch": models.MatchFuzzy, "similarity": "0.52"}, []string{"Uprising"}},
+			{"fuzzy group", map[string]string{"group_name": "Queeen", "groupMatch": models.MatchFuzzy}, []string{"Bohemian Rhapsody"}},
+		} {
+			songs, err := store.GetSongs(tc.filter, nil, 10, 0)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			var got []string
+			for _, s := range songs {
+				got = append(got, s.Song)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("%s: GetSongs(%v) = %q, want %q", tc.name, tc.filter, got, tc.want)
+			}
+
+			total, err := store.CountSongs(tc.filter)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			if total != len(tc.want) {
+				t.Errorf("%s: CountSongs(%v) = %d, want %d", tc.name, tc.filter, total, len(tc.want))
+			}
+		}
+	})
+}
@@ -5,100 +5,204 @@ import (
 	"errors"
 	"fmt"
 	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
 )
 
 func (r *Repository) SaveSongInfo(group, song, releaseDate, link string) (int, error) {
 	op := "repository.SaveSongInfo"
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", op, err)
+	}
+	defer tx.Rollback()
+
+	groupID, groupName, err := ensureGroup(tx, group)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", op, err)
+	}
+
 	var id int
-	query := `INSERT INTO song_info (group_name, song, release_date, link) VALUES ($1, $2, $3, $4) RETURNING id`
-	err := r.db.QueryRow(query, group, song, releaseDate, link).Scan(&id)
+	query := `INSERT INTO song_info (group_id, group_name, song, release_date, link) VALUES ($1, $2, $3, NULLIF($4, '')::DATE, $5) RETURNING id`
+	err = tx.QueryRow(query, groupID, groupName, song, releaseDate, link).Scan(&id)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, ErrDuplicate
+		}
+		return 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
 		return 0, fmt.Errorf("%s: %v", op, err)
 	}
 	return id, nil
 }
 
-func (r *Repository) SaveSongText(songID uint, verse string) error {
+// SaveSongText сохраняет текст новой песни и записывает ее первую ревизию и создание в журнал аудита
+func (r *Repository) SaveSongText(songID uint, verses []models.Verse, meta models.ChangeMeta) error {
 	op := "repository.SaveSongText"
 
-	query := `INSERT INTO song_text (song_id, verse) VALUES ($1, $2)`
-	_, err := r.db.Exec(query, songID, verse)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return fmt.Errorf("%s: %v", op, err)
 	}
+	defer tx.Rollback()
+
+	if err := insertVerses(tx, songID, verses); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if err := recordChange(tx, songID, models.AuditCreate, meta, songState{}); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
 	return nil
 }
 
-func (r *Repository) GetSongs(filter map[string]string, limit, offset int) ([]models.Song, error) {
+// SongExists проверяет, есть ли песня с таким названием у группы (название группы без учета регистра)
+func (r *Repository) SongExists(group, song string) (bool, error) {
+	op := "repository.SongExists"
+
+	var exists bool
+	query := `
+	SELECT EXISTS(
+		SELECT 1 FROM song_info si
+		JOIN groups g ON g.id = si.group_id
+		WHERE LOWER(g.name) = LOWER($1) AND si.song = $2 AND si.deleted_at IS NULL
+	)`
+	if err := r.db.QueryRow(query, group, song).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return exists, nil
+}
+
+// GetSongs возвращает песни по фильтру в порядке sort (по умолчанию - сначала новые).
+// Для fuzzy-фильтров без явной сортировки сначала идут самые похожие.
+func (r *Repository) GetSongs(filter map[string]string, sort []models.SortKey, limit, offset int) ([]models.Song, error) {
 	op := "repository.GetSongs"
 
-	query := `SELECT id, group_name, song, release_date, link FROM song_info WHERE 1=1`
-	args := []interface{}{}
-	argIndex := 1
+	f := buildSongFilter(filter)
+	query := `SELECT ` + songColumns + ` FROM song_info WHERE deleted_at IS NULL` + f.where
+	args := f.args
+	argIndex := len(args) + 1
 
-	if group, ok := filter["group_name"]; ok && group != "" {
-		query += fmt.Sprintf(" AND group_name = $%d", argIndex)
-		args = append(args, group)
-		argIndex++
-	}
-	if song, ok := filter["song"]; ok && song != "" {
-		query += fmt.Sprintf(" AND song = $%d", argIndex)
-		args = append(args, song)
-		argIndex++
+	order, err := songOrderBy(sort, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
 	}
-	if link, ok := filter["link"]; ok && link != "" {
-		query += fmt.Sprintf(" AND link = $%d", argIndex)
-		args = append(args, link)
-		argIndex++
+	if f.score != "" && len(sort) == 0 {
+		order = "(" + f.score + ") DESC, " + order
 	}
-	if releaseDate, ok := filter["releaseDate"]; ok && releaseDate != "" {
-		query += fmt.Sprintf(" AND release_date = $%d", argIndex)
-		args = append(args, releaseDate)
-		argIndex++
+
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", order, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var songs []models.Song
+	err = f.query(r.db, query, args, func(rows *sql.Rows) error {
+		return scanSongs(rows, &songs)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
 	}
-	if startDate, ok := filter["startDate"]; ok && startDate != "" {
-		query += fmt.Sprintf(" AND release_date >= $%d", argIndex)
-		args = append(args, startDate)
-		argIndex++
+
+	return songs, nil
+}
+
+// GetSongsAfter возвращает до limit песен, следующих за курсором в порядке sort,
+// или предшествующих ему, если cursor.Backward. Без курсора возвращается первая
+// страница. Последним ключом sort должен быть id, чтобы порядок был однозначным.
+// hasMore сообщает, есть ли еще песни дальше в направлении выборки.
+func (r *Repository) GetSongsAfter(filter map[string]string, sort []models.SortKey, cursor *models.SongCursor, limit int) ([]models.Song, bool, error) {
+	op := "repository.GetSongsAfter"
+
+	f := buildSongFilter(filter)
+	query := `SELECT ` + songColumns + ` FROM song_info WHERE deleted_at IS NULL` + f.where
+	args := f.args
+	argIndex := len(args) + 1
+
+	backward := cursor != nil && cursor.Backward
+	if cursor != nil {
+		cond, condArgs, err := songKeysetCondition(sort, cursor.Values, backward, argIndex)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %v", op, err)
+		}
+		query += " AND " + cond
+		args = append(args, condArgs...)
+		argIndex += len(condArgs)
 	}
-	if endDate, ok := filter["endDate"]; ok && endDate != "" {
-		query += fmt.Sprintf(" AND release_date <= $%d", argIndex)
-		args = append(args, endDate)
-		argIndex++
+
+	order, err := songOrderBy(sort, backward)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %v", op, err)
 	}
 
-	query += fmt.Sprintf(" ORDER BY release_date DESC LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
-	args = append(args, limit, offset)
+	// Берем на одну запись больше, чтобы узнать, есть ли следующая страница
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", order, argIndex)
+	args = append(args, limit+1)
 
-	rows, err := r.db.Query(query, args...)
+	songs := []models.Song{}
+	err = f.query(r.db, query, args, func(rows *sql.Rows) error {
+		return scanSongs(rows, &songs)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%s: %v", op, err)
+		return nil, false, fmt.Errorf("%s: %v", op, err)
 	}
-	defer rows.Close()
 
-	var songs []models.Song
-	for rows.Next() {
-		var song models.Song
-		if err := rows.Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Link); err != nil {
-			return nil, fmt.Errorf("%s: %v", op, err)
+	hasMore := len(songs) > limit
+	if hasMore {
+		songs = songs[:limit]
+	}
+
+	if backward {
+		for i, j := 0, len(songs)-1; i < j; i, j = i+1, j-1 {
+			songs[i], songs[j] = songs[j], songs[i]
 		}
-		songs = append(songs, song)
 	}
 
-	return songs, nil
+	return songs, hasMore, nil
 }
 
-func (r *Repository) GetSongTextByGroup(groupName, songName string, limit, offset int) ([]string, error) {
+func (r *Repository) CountSongs(filter map[string]string) (int, error) {
+	op := "repository.CountSongs"
+
+	f := buildSongFilter(filter)
+	query := `SELECT COUNT(*) FROM song_info WHERE deleted_at IS NULL` + f.where
+
+	var total int
+	err := f.query(r.db, query, f.args, func(rows *sql.Rows) error {
+		if rows.Next() {
+			if err := rows.Scan(&total); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return total, nil
+}
+
+// GetSongTextByGroup возвращает куплеты (by = verse) или строки (by = line) песни по порядку
+func (r *Repository) GetSongTextByGroup(groupName, songName, by string, limit, offset int) ([]string, error) {
 	op := "repository.GetSongTextByGroup"
 
-	query := `
-	SELECT sl.verse 
-	FROM song_text sl
-	JOIN song_info si ON si.id = sl.song_id
-	WHERE si.group_name = $1 AND si.song = $2
-	ORDER BY sl.id
-	LIMIT $3 OFFSET $4`
+	table, column, order := songTextSource(by)
+	query := fmt.Sprintf(`
+	SELECT t.%s
+	FROM %s t
+	JOIN song_info si ON si.id = t.song_id
+	JOIN groups g ON g.id = si.group_id
+	WHERE LOWER(g.name) = LOWER($1) AND si.song = $2 AND si.deleted_at IS NULL
+	ORDER BY %s
+	LIMIT $3 OFFSET $4`, column, table, order)
 
 	rows, err := r.db.Query(query, groupName, songName, limit, offset)
 	if err != nil {
@@ -118,31 +222,28 @@ func (r *Repository) GetSongTextByGroup(groupName, songName string, limit, offse
 	return verses, nil
 }
 
-func (r *Repository) DeleteSong(groupName, songName string) error {
+// DeleteSong перемещает песню в корзину; окончательно она удаляется PurgeSong или PurgeDeletedSongs
+func (r *Repository) DeleteSong(groupName, songName string, meta models.ChangeMeta) error {
 	op := "repository.DeleteSong"
 
-	query := `DELETE FROM song_info WHERE group_name = $1 AND song = $2`
-
-	result, err := r.db.Exec(query, groupName, songName)
-	if err != nil {
-		return fmt.Errorf("%s: %v", op, err)
-	}
+	query := `
+	UPDATE song_info SET deleted_at = NOW()
+	WHERE group_id = (SELECT id FROM groups WHERE LOWER(name) = LOWER($1)) AND song = $2 AND deleted_at IS NULL`
 
-	rowsAffected, err := result.RowsAffected()
+	rowsAffected, err := execAudited(r.db, query, models.AuditDelete, meta, groupName, songName)
 	if err != nil {
 		return fmt.Errorf("%s: %v", op, err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("%s: %v", op, sql.ErrNoRows)
+		return sql.ErrNoRows
 	}
 
 	return nil
 }
 
-func (r *Repository) UpdateSongInfo(groupName, songName, newReleaseDate, newLink string) error {
-	op := "repository.UpdateSongInfo"
-
+// updateSongInfo обновляет дату выхода и ссылку песни; пустые значения не меняются
+func updateSongInfo(tx *sql.Tx, songID int, newReleaseDate, newLink string) error {
 	query := `UPDATE song_info SET updated_at = CURRENT_TIMESTAMP`
 	var args []interface{}
 	argCount := 1
@@ -159,92 +260,344 @@ func (r *Repository) UpdateSongInfo(groupName, songName, newReleaseDate, newLink
 		argCount++
 	}
 
-	query += ` WHERE group_name = $` + fmt.Sprintf("%d", argCount)
-	args = append(args, groupName)
-	argCount++
+	query += ` WHERE id = $` + fmt.Sprintf("%d", argCount)
+	args = append(args, songID)
 
-	query += ` AND song = $` + fmt.Sprintf("%d", argCount)
-	args = append(args, songName)
+	_, err := tx.Exec(query, args...)
+	return err
+}
+
+// UpdateSong обновляет песню по группе и названию и записывает ревизию и изменения в журнал аудита
+func (r *Repository) UpdateSong(groupName, songName, newReleaseDate, newLink string, newVerses []models.Verse, meta models.ChangeMeta) error {
+	op := "repository.UpdateSong"
 
-	result, err := r.db.Exec(query, args...)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return fmt.Errorf("%s: %v", op, err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	var songID int
+	query := `
+	SELECT si.id FROM song_info si
+	JOIN groups g ON g.id = si.group_id
+	WHERE LOWER(g.name) = LOWER($1) AND si.song = $2 AND si.deleted_at IS NULL
+	FOR UPDATE OF si`
+	err = tx.QueryRow(query, groupName, songName).Scan(&songID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
 		return fmt.Errorf("%s: %v", op, err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("%s: %v", op, sql.ErrNoRows)
+	before, err := loadSongState(tx, uint(songID))
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if err := updateSongInfo(tx, songID, newReleaseDate, newLink); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if len(newVerses) > 0 {
+		if _, err := tx.Exec(`DELETE FROM song_text WHERE song_id = $1`, songID); err != nil {
+			return fmt.Errorf("%s: %v", op, err)
+		}
+		if err := insertVerses(tx, uint(songID), newVerses); err != nil {
+			return fmt.Errorf("%s: %v", op, err)
+		}
+	}
+
+	if err := recordChange(tx, uint(songID), models.AuditUpdate, meta, before); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
 	}
 
 	return nil
 }
 
-func (r *Repository) UpdateSongText(groupName, songName string, newVerses []string) error {
-	op := "repository.UpdateSongText"
+func (r *Repository) GetSongByID(id uint) (*models.Song, error) {
+	op := "repository.GetSongByID"
 
-	var songID int
-	query := `SELECT id FROM song_info WHERE group_name = $1 AND song = $2`
-	err := r.db.QueryRow(query, groupName, songName).Scan(&songID)
+	query := `SELECT ` + songColumns + ` FROM song_info WHERE id = $1 AND deleted_at IS NULL`
+	song, err := scanSong(r.db.QueryRow(query, id))
 	if err != nil {
-		return fmt.Errorf("%s: %v", op, err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("%s: %v", op, err)
 	}
 
-	_, err = r.db.Exec(`DELETE FROM song_text WHERE song_id = $1`, songID)
+	verses, err := r.getVerses(id, -1, 0)
 	if err != nil {
-		return fmt.Errorf("%s: %v", op, err)
+		return nil, fmt.Errorf("%s: %v", op, err)
 	}
+	song.Text = strings.Join(renderVerses(verses), "\n\n")
 
-	for _, verse := range newVerses {
-		_, err := r.db.Exec(`INSERT INTO song_text (song_id, verse) VALUES ($1, $2)`, songID, verse)
-		if err != nil {
-			return fmt.Errorf("%s: %v", op, err)
+	return &song, nil
+}
+
+// GetSongTextByID возвращает куплеты (by = verse) или строки (by = line) песни по порядку
+func (r *Repository) GetSongTextByID(id uint, by string, limit, offset int) (*models.SongTextResp, error) {
+	op := "repository.GetSongTextByID"
+
+	resp := models.SongTextResp{ID: id}
+	query := `SELECT group_name, song FROM song_info WHERE id = $1 AND deleted_at IS NULL`
+	err := r.db.QueryRow(query, id).Scan(&resp.Group, &resp.Song)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
 		}
+		return nil, fmt.Errorf("%s: %v", op, err)
 	}
 
-	return nil
+	resp.Text, err = r.getSongText(id, by, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return &resp, nil
 }
 
-func (r *Repository) UpdateSong(groupName, songName, newReleaseDate, newLink string, newVerses []string) error {
-	op := "repository.UpdateSong"
+func (r *Repository) GetSongVerses(id uint, limit, offset int) (*models.SongVersesResp, error) {
+	op := "repository.GetSongVerses"
+
+	resp := models.SongVersesResp{ID: id}
+	query := `SELECT group_name, song FROM song_info WHERE id = $1 AND deleted_at IS NULL`
+	err := r.db.QueryRow(query, id).Scan(&resp.Group, &resp.Song)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	resp.Verses, err = r.getVerses(id, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return &resp, nil
+}
+
+func (r *Repository) GetVerse(id uint, n int) (*models.Verse, error) {
+	op := "repository.GetVerse"
+
+	query := `
+	SELECT ` + verseColumns + ` FROM song_verses
+	WHERE song_id = $1 AND position = $2
+		AND EXISTS(SELECT 1 FROM song_info WHERE id = $1 AND deleted_at IS NULL)`
+	verse, err := scanVerse(r.db.QueryRow(query, id, n))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return verse, nil
+}
+
+// UpdateSongByID обновляет переданные в patch поля песни и записывает ревизию и изменения
+// в журнал аудита. Если newVerses не nil, текст песни полностью заменяется.
+func (r *Repository) UpdateSongByID(id uint, patch *models.SongPatch, newVerses []models.Verse, meta models.ChangeMeta) error {
+	op := "repository.UpdateSongByID"
 
 	tx, err := r.db.Begin()
 	if err != nil {
 		return fmt.Errorf("%s: %v", op, err)
 	}
+	defer tx.Rollback()
 
-	var songID int
-	query := `SELECT id FROM song_info WHERE group_name = $1 AND song = $2`
-	err = tx.QueryRow(query, groupName, songName).Scan(&songID)
+	before, err := loadSongState(tx, id)
 	if err != nil {
-		tx.Rollback()
 		if errors.Is(err, sql.ErrNoRows) {
 			return sql.ErrNoRows
 		}
 		return fmt.Errorf("%s: %v", op, err)
 	}
 
-	err = r.UpdateSongInfo(groupName, songName, newReleaseDate, newLink)
+	query := `UPDATE song_info SET updated_at = CURRENT_TIMESTAMP`
+	var args []interface{}
+	argCount := 1
+
+	var groupID *uint
+	var groupName *string
+	switch {
+	case patch.GroupID != nil:
+		var name string
+		err := tx.QueryRow(`SELECT name FROM groups WHERE id = $1`, *patch.GroupID).Scan(&name)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrGroupNotFound
+			}
+			return fmt.Errorf("%s: %v", op, err)
+		}
+		groupID, groupName = patch.GroupID, &name
+	case patch.Group != nil:
+		id, name, err := ensureGroup(tx, *patch.Group)
+		if err != nil {
+			return fmt.Errorf("%s: %v", op, err)
+		}
+		groupID, groupName = &id, &name
+	}
+
+	if groupID != nil {
+		query += fmt.Sprintf(", group_id = $%d", argCount)
+		args = append(args, *groupID)
+		argCount++
+	}
+
+	for _, field := range []struct {
+		column string
+		value  *string
+	}{
+		{"group_name", groupName},
+		{"song", patch.Song},
+		{"release_date", patch.ReleaseDate},
+		{"link", patch.Link},
+	} {
+		if field.value == nil {
+			continue
+		}
+		query += fmt.Sprintf(", %s = $%d", field.column, argCount)
+		args = append(args, *field.value)
+		argCount++
+	}
+
+	query += fmt.Sprintf(" WHERE id = $%d AND deleted_at IS NULL", argCount)
+	args = append(args, id)
+
+	result, err := tx.Exec(query, args...)
 	if err != nil {
-		tx.Rollback()
-		return err
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("%s: %v", op, err)
 	}
 
-	if len(newVerses) > 0 {
-		err = r.UpdateSongText(groupName, songName, newVerses)
-		if err != nil {
-			tx.Rollback()
-			return err
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if newVerses != nil {
+		if _, err := tx.Exec(`DELETE FROM song_text WHERE song_id = $1`, id); err != nil {
+			return fmt.Errorf("%s: %v", op, err)
+		}
+
+		if err := insertVerses(tx, id, newVerses); err != nil {
+			return fmt.Errorf("%s: %v", op, err)
 		}
 	}
 
-	err = tx.Commit()
+	if err := recordChange(tx, id, models.AuditUpdate, meta, before); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	return nil
+}
+
+// DeleteSongByID перемещает песню в корзину
+func (r *Repository) DeleteSongByID(id uint, meta models.ChangeMeta) error {
+	op := "repository.DeleteSongByID"
+
+	query := `UPDATE song_info SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	rowsAffected, err := execAudited(r.db, query, models.AuditDelete, meta, id)
 	if err != nil {
 		return fmt.Errorf("%s: %v", op, err)
 	}
 
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
 	return nil
 }
+
+// verseColumns - колонки song_verses в порядке, который ожидает scanVerse
+const verseColumns = `position, COALESCE(verse_type, ''), COALESCE(repeat_of, 0), verse, line_times`
+
+func scanVerse(row rowScanner) (*models.Verse, error) {
+	var v models.Verse
+	if err := row.Scan(&v.Position, &v.Type, &v.RepeatOf, &v.Text, pq.Array(&v.LineTimes)); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// getVerses возвращает куплеты песни по порядку; limit < 0 означает без ограничения
+func (r *Repository) getVerses(songID uint, limit, offset int) ([]models.Verse, error) {
+	query := `SELECT ` + verseColumns + ` FROM song_verses WHERE song_id = $1 ORDER BY position OFFSET $2`
+	args := []interface{}{songID, offset}
+	if limit >= 0 {
+		query += ` LIMIT $3`
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	verses := []models.Verse{}
+	for rows.Next() {
+		verse, err := scanVerse(rows)
+		if err != nil {
+			return nil, err
+		}
+		verses = append(verses, *verse)
+	}
+
+	return verses, rows.Err()
+}
+
+// getSongText возвращает тексты куплетов или строки песни по порядку
+func (r *Repository) getSongText(songID uint, by string, limit, offset int) ([]string, error) {
+	table, column, order := songTextSource(by)
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE song_id = $1 ORDER BY %s LIMIT $2 OFFSET $3`, column, table, order)
+
+	rows, err := r.db.Query(query, songID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	texts := []string{}
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return nil, err
+		}
+		texts = append(texts, text)
+	}
+
+	return texts, rows.Err()
+}
+
+// songTextSource возвращает представление, колонку текста и порядок для пагинации
+// по куплетам (song_verses) или по строкам (song_lines)
+func songTextSource(by string) (table, column, order string) {
+	if by == models.TextByLine {
+		return "song_lines", "text", "position, line"
+	}
+	return "song_verses", "verse", "position"
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
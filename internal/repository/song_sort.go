@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"strings"
+)
+
+// songColumns - колонки песни в порядке, который ожидает scanSong
+const songColumns = `id, group_id, group_name, song, COALESCE(to_char(release_date, 'YYYY-MM-DD'), ''), link,
+	to_char(created_at, 'YYYY-MM-DD"T"HH24:MI:SS.US'), to_char(updated_at, 'YYYY-MM-DD"T"HH24:MI:SS.US'), enrichment_status`
+
+func scanSong(row rowScanner) (models.Song, error) {
+	var song models.Song
+	err := row.Scan(&song.ID, &song.GroupID, &song.Group, &song.Song, &song.ReleaseDate, &song.Link, &song.CreatedAt, &song.UpdatedAt, &song.EnrichmentStatus)
+	return song, err
+}
+
+// songSortColumns - белый список полей сортировки. В запрос подставляются
+// только значения из этой таблицы, поэтому построение ORDER BY безопасно.
+// empty - значение, которым заменяется NULL: у песен, ожидающих обогащения,
+// нет даты выхода, а в курсоре она передается пустой строкой.
+var songSortColumns = map[string]struct{ column, cast, empty string }{
+	models.SortGroup:       {"group_name", "TEXT", ""},
+	models.SortSong:        {"song", "TEXT", ""},
+	models.SortReleaseDate: {"COALESCE(release_date, '-infinity')", "DATE", "'-infinity'"},
+	models.SortCreatedAt:   {"created_at", "TIMESTAMP", ""},
+	models.SortUpdatedAt:   {"updated_at", "TIMESTAMP", ""},
+	models.SortID:          {"id", "INT", ""},
+}
+
+// DefaultSongSort - сортировка списка песен по умолчанию: сначала новые
+var DefaultSongSort = []models.SortKey{
+	{Field: models.SortReleaseDate, Desc: true},
+	{Field: models.SortID, Desc: true},
+}
+
+// songOrderBy строит выражение ORDER BY; reverse меняет все направления на противоположные
+func songOrderBy(keys []models.SortKey, reverse bool) (string, error) {
+	if len(keys) == 0 {
+		keys = DefaultSongSort
+	}
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		col, ok := songSortColumns[key.Field]
+		if !ok {
+			return "", fmt.Errorf("unsupported sort field %q", key.Field)
+		}
+
+		dir := "ASC"
+		if key.Desc != reverse {
+			dir = "DESC"
+		}
+		parts = append(parts, col.column+" "+dir)
+	}
+
+	return strings.Join(parts, ", "), nil
+}
+
+// songKeysetCondition строит условие "строка идет после курсора" для ключей сортировки:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., где > заменяется на < для DESC
+// и на противоположный оператор при движении назад.
+func songKeysetCondition(keys []models.SortKey, values []string, backward bool, argIndex int) (string, []interface{}, error) {
+	if len(keys) == 0 {
+		keys = DefaultSongSort
+	}
+	if len(values) != len(keys) {
+		return "", nil, fmt.Errorf("cursor has %d values for %d sort keys", len(values), len(keys))
+	}
+
+	var alternatives []string
+	var args []interface{}
+	for i := range keys {
+		var conds []string
+		for j := 0; j <= i; j++ {
+			col, ok := songSortColumns[keys[j].Field]
+			if !ok {
+				return "", nil, fmt.Errorf("unsupported sort field %q", keys[j].Field)
+			}
+
+			op := "="
+			if j == i {
+				op = ">"
+				if keys[j].Desc != backward {
+					op = "<"
+				}
+			}
+			value := fmt.Sprintf("$%d::%s", argIndex, col.cast)
+			if col.empty != "" {
+				value = fmt.Sprintf("COALESCE(NULLIF($%d, '')::%s, %s)", argIndex, col.cast, col.empty)
+			}
+			conds = append(conds, fmt.Sprintf("%s %s %s", col.column, op, value))
+			args = append(args, values[j])
+			argIndex++
+		}
+		alternatives = append(alternatives, "("+strings.Join(conds, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args, nil
+}
+
+func scanSongs(rows *sql.Rows, songs *[]models.Song) error {
+	for rows.Next() {
+		song, err := scanSong(rows)
+		if err != nil {
+			return err
+		}
+		*songs = append(*songs, song)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"slices"
+	"testing"
+)
+
+// Постраничный обход в обе стороны должен давать тот же порядок, что и GetSongs
+func TestStoreGetSongsAfter(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		createSong(t, store, "Muse", "Hysteria", "2003-12-01")
+		createSong(t, store, "Muse", "Starlight", "2006-09-04")
+		createSong(t, store, "Muse", "Knights of Cydonia", "2006-09-04")
+		createSong(t, store, "Queen", "Bohemian Rhapsody", "1975-10-31")
+		// Песня, ожидающая обогащения, еще без даты выхода
+		if _, _, err := store.CreatePendingSong("Muse", "Uprising", nil, 3, testMeta); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, sort := range [][]models.SortKey{
+			DefaultSongSort,
+			{{Field: models.SortReleaseDate}, {Field: models.SortID}},
+			{{Field: models.SortGroup}, {Field: models.SortReleaseDate, Desc: true}, {Field: models.SortID}},
+			{{Field: models.SortSong, Desc: true}, {Field: models.SortID, Desc: true}},
+			{{Field: models.SortCreatedAt}, {Field: models.SortID}},
+		} {
+			all, err := store.GetSongs(map[string]string{}, sort, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := songIDs(all)
+
+			var forward []uint
+			var pages [][]models.Song
+			var cursor *models.SongCursor
+			for {
+				page, hasMore, err := store.GetSongsAfter(map[string]string{}, sort, cursor, 2)
+				if err != nil {
+					t.Fatal(err)
+				}
+				forward = append(forward, songIDs(page)...)
+				pages = append(pages, page)
+				if !hasMore {
+					break
+				}
+				cursor = &models.SongCursor{Values: songKeyValues(page[len(page)-1], sort)}
+			}
+			if !slices.Equal(forward, want) {
+				t.Errorf("sort %v: pages %v, want %v", sort, forward, want)
+				continue
+			}
+
+			// Назад от первой песни последней страницы
+			last := pages[len(pages)-1]
+			cursor = &models.SongCursor{Values: songKeyValues(last[0], sort), Backward: true}
+			back, hasMore, err := store.GetSongsAfter(map[string]string{}, sort, cursor, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(songIDs(back), songIDs(pages[len(pages)-2])) || !hasMore {
+				t.Errorf("sort %v: previous page %v (more %v), want %v", sort, songIDs(back), hasMore, songIDs(pages[len(pages)-2]))
+			}
+		}
+	})
+}
+
+func songIDs(songs []models.Song) []uint {
+	var ids []uint
+	for _, s := range songs {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestStoreSongByID(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		id := createSong(t, store, "Muse", "Uprising", "2009-09-07", "Paranoia is in bloom", "They will not force us\nThey will stop degrading us")
+		createSong(t, store, "Muse", "Hysteria", "2003-12-01")
+
+		song, err := store.GetSongByID(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if song.Group != "Muse" || song.Song != "Uprising" || song.Text != "Paranoia is in bloom\n\nThey will not force us\nThey will stop degrading us" {
+			t.Fatalf("song: %+v", song)
+		}
+
+		text, err := store.GetSongTextByID(id, models.TextByLine, 100, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(text.Text, []string{"They will not force us", "They will stop degrading us"}) {
+			t.Fatalf("lines: %q", text.Text)
+		}
+
+		link := "https://example.com/new"
+		if err := store.UpdateSongByID(id, &models.SongPatch{Link: &link}, nil, testMeta); err != nil {
+			t.Fatal(err)
+		}
+		if song, _ = store.GetSongByID(id); song.Link != link || song.ReleaseDate != "2009-09-07" || song.Text == "" {
+			t.Fatalf("patched song: %+v", song)
+		}
+
+		name := "Hysteria"
+		if err := store.UpdateSongByID(id, &models.SongPatch{Song: &name}, nil, testMeta); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("rename to an existing song: %v, want ErrDuplicate", err)
+		}
+
+		group := "Queen"
+		if err := store.UpdateSongByID(id, &models.SongPatch{Group: &group}, []models.Verse{{Text: "Rise up"}}, testMeta); err != nil {
+			t.Fatal(err)
+		}
+		if song, _ = store.GetSongByID(id); song.Group != "Queen" || song.Text != "Rise up" {
+			t.Fatalf("moved song: %+v", song)
+		}
+
+		missing := uint(9999)
+		if err := store.UpdateSongByID(id, &models.SongPatch{GroupID: &missing}, nil, testMeta); !errors.Is(err, ErrGroupNotFound) {
+			t.Fatalf("move to a missing group: %v, want ErrGroupNotFound", err)
+		}
+
+		if err := store.DeleteSongByID(id, testMeta); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetSongByID(id); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("GetSongByID after delete: %v", err)
+		}
+		if err := store.UpdateSongByID(id, &models.SongPatch{Link: &link}, nil, testMeta); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("UpdateSongByID after delete: %v", err)
+		}
+		if err := store.DeleteSongByID(id, testMeta); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("second DeleteSongByID: %v", err)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+)
+
+// DB возвращает пул соединений, например для метрик
+func (r *Repository) DB() *sql.DB {
+	return r.db
+}
+
+// GetLibraryStats считает песни, песни в корзине, группы, альбомы и незавершенные задачи обогащения;
+// запрос прерывается по отмене ctx
+func (r *Repository) GetLibraryStats(ctx context.Context) (*models.LibraryStats, error) {
+	op := "repository.GetLibraryStats"
+
+	query := `
+	SELECT
+		(SELECT COUNT(*) FROM song_info WHERE deleted_at IS NULL),
+		(SELECT COUNT(*) FROM song_info WHERE deleted_at IS NOT NULL),
+		(SELECT COUNT(*) FROM groups),
+		(SELECT COUNT(*) FROM albums),
+		(SELECT COUNT(*) FROM enrichment_jobs WHERE status IN ($1, $2))`
+
+	var stats models.LibraryStats
+	err := r.db.QueryRowContext(ctx, query, models.JobQueued, models.JobRunning).
+		Scan(&stats.Songs, &stats.DeletedSongs, &stats.Groups, &stats.Albums, &stats.PendingJobs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return &stats, nil
+}
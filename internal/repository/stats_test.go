@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"testing"
+	"time"
+)
+
+func TestStoreLibraryStats(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		song, err := store.GetSongByID(createSong(t, store, "Muse", "Hysteria", ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		deleted := createSong(t, store, "Muse", "Uprising", "")
+		if err := store.DeleteSongByID(deleted, testMeta); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := store.CreatePendingSong("Radiohead", "Creep", nil, 3, testMeta); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.CreateAlbum(&models.Album{GroupID: song.GroupID, Title: "Absolution"}); err != nil {
+			t.Fatal(err)
+		}
+
+		stats, err := store.GetLibraryStats(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := models.LibraryStats{Songs: 2, DeletedSongs: 1, Groups: 2, Albums: 1, PendingJobs: 1}
+		if *stats != want {
+			t.Fatalf("stats: %+v, want %+v", *stats, want)
+		}
+	})
+}
+
+func TestInstrument(t *testing.T) {
+	type observation struct {
+		method string
+		err    error
+	}
+	var observed []observation
+	store := Instrument(NewMemoryStore(), func(method string, duration time.Duration, err error) {
+		if duration < 0 {
+			t.Errorf("%s: negative duration", method)
+		}
+		observed = append(observed, observation{method, err})
+	})
+
+	id := createSong(t, store, "Muse", "Hysteria", "")
+	if _, err := store.GetSongByID(id + 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("missing song: %v", err)
+	}
+	// Методы вне song_repo.go не замеряются
+	if _, err := store.GetLibraryStats(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []observation{{"SaveSongInfo", nil}, {"SaveSongText", nil}, {"GetSongByID", sql.ErrNoRows}}
+	if len(observed) != len(want) {
+		t.Fatalf("observed: %+v", observed)
+	}
+	for i := range want {
+		if observed[i].method != want[i].method || !errors.Is(observed[i].err, want[i].err) {
+			t.Fatalf("observed: %+v, want %+v", observed, want)
+		}
+	}
+}
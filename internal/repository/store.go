@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"time"
+)
+
+var (
+	// ErrDuplicate возвращается, если запись с такими уникальными полями уже существует
+	ErrDuplicate = errors.New("record already exists")
+	// ErrGroupNotFound возвращается, если указанная группа не существует
+	ErrGroupNotFound = errors.New("group not found")
+	// ErrSongNotFound возвращается, если указанная песня не существует
+	ErrSongNotFound = errors.New("song not found")
+	// ErrInUse возвращается при удалении записи, на которую ссылаются другие записи
+	ErrInUse = errors.New("record is referenced by other records")
+	// ErrLeaseLost возвращается, если аренда задачи обогащения истекла и задачу
+	// уже взял другой обработчик или она завершена
+	ErrLeaseLost = errors.New("enrichment job lease lost")
+)
+
+// SongStore описывает хранилище песен, с которым работает сервисный слой.
+// Реализации: Repository (PostgreSQL) и MemoryStore (в памяти, для демо и тестов).
+type SongStore interface {
+	SaveSongInfo(group, song, releaseDate, link string) (int, error)
+	SaveSongText(songID uint, verses []models.Verse, meta models.ChangeMeta) error
+	SongExists(group, song string) (bool, error)
+	GetSongs(filter map[string]string, sort []models.SortKey, limit, offset int) ([]models.Song, error)
+	GetSongsAfter(filter map[string]string, sort []models.SortKey, cursor *models.SongCursor, limit int) ([]models.Song, bool, error)
+	CountSongs(filter map[string]string) (int, error)
+	ExportSongs(ctx context.Context, filter map[string]string, sort []models.SortKey, withText bool, fn func(song models.Song, verses []string) error) error
+	GetSongTextByGroup(groupName, songName, by string, limit, offset int) ([]string, error)
+	UpdateSong(groupName, songName, newReleaseDate, newLink string, newVerses []models.Verse, meta models.ChangeMeta) error
+	DeleteSong(groupName, songName string, meta models.ChangeMeta) error
+
+	GetSongByID(id uint) (*models.Song, error)
+	GetSongTextByID(id uint, by string, limit, offset int) (*models.SongTextResp, error)
+	GetSongVerses(id uint, limit, offset int) (*models.SongVersesResp, error)
+	GetVerse(id uint, n int) (*models.Verse, error)
+	UpdateSongByID(id uint, patch *models.SongPatch, newVerses []models.Verse, meta models.ChangeMeta) error
+	GetSongRevisions(songID uint, limit, offset int) ([]models.SongRevision, error)
+	GetSongRevision(songID uint, rev int) (*models.SongRevision, error)
+	DeleteSongByID(id uint, meta models.ChangeMeta) error
+
+	GetDeletedSongs(limit, offset int) ([]models.Song, error)
+	RestoreSong(id uint, meta models.ChangeMeta) error
+	PurgeSong(id uint, meta models.ChangeMeta) error
+	PurgeDeletedSongs(retention time.Duration, meta models.ChangeMeta) (int, error)
+
+	GetAuditLog(filter models.AuditFilter, limit, offset int) ([]models.AuditEntry, error)
+
+	CreateGroup(group *models.Group) (uint, error)
+	GetGroups(filter map[string]string, limit, offset int) ([]models.Group, error)
+	GetGroupByID(id uint) (*models.Group, error)
+	UpdateGroup(id uint, patch *models.GroupPatch, meta models.ChangeMeta) error
+	DeleteGroup(id uint) error
+
+	CreateAlbum(album *models.Album) (uint, error)
+	GetAlbums(filter map[string]string, limit, offset int) ([]models.Album, error)
+	GetAlbumByID(id uint) (*models.Album, error)
+	UpdateAlbum(id uint, patch *models.AlbumPatch) error
+	DeleteAlbum(id uint) error
+	GetAlbumTracks(id uint) ([]models.AlbumTrack, error)
+	SetAlbumTracks(id uint, songIDs []uint) error
+
+	CreatePendingSong(group, song string, verses []models.Verse, maxAttempts int, meta models.ChangeMeta) (uint, uint, error)
+	GetEnrichmentJob(id uint) (*models.EnrichmentJob, error)
+	ClaimEnrichmentJob(lease time.Duration) (*models.EnrichmentJob, error)
+	// attempt - номер попытки, полученный из ClaimEnrichmentJob: задачу меняет только ее владелец
+	CompleteEnrichmentJob(jobID, songID uint, attempt int, details *models.SongDetails, verses []models.Verse, meta models.ChangeMeta) error
+	RetryEnrichmentJob(jobID uint, attempt int, delay time.Duration, lastError string) error
+	PostponeEnrichmentJob(jobID uint, attempt int, lastError string) error
+	FailEnrichmentJob(jobID, songID uint, attempt int, lastError string) error
+
+	SearchLyrics(q models.SearchQuery, limit, offset int) ([]models.SearchHit, error)
+
+	GetLibraryStats(ctx context.Context) (*models.LibraryStats, error)
+}
+
+var (
+	_ SongStore = (*Repository)(nil)
+	_ SongStore = (*MemoryStore)(nil)
+	_ SongStore = (*instrumentedStore)(nil)
+)
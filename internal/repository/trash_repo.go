@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"time"
+)
+
+// GetDeletedSongs возвращает песни из корзины без текста, сначала удаленные последними
+func (r *Repository) GetDeletedSongs(limit, offset int) ([]models.Song, error) {
+	op := "repository.GetDeletedSongs"
+
+	query := `
+	SELECT ` + songColumns + `, to_char(deleted_at, 'YYYY-MM-DD"T"HH24:MI:SS.US')
+	FROM song_info
+	WHERE deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC, id DESC
+	LIMIT $1 OFFSET $2`
+	rows, err := r.db.Query(query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+	defer rows.Close()
+
+	songs := []models.Song{}
+	for rows.Next() {
+		var song models.Song
+		err := rows.Scan(&song.ID, &song.GroupID, &song.Group, &song.Song, &song.ReleaseDate, &song.Link,
+			&song.CreatedAt, &song.UpdatedAt, &song.EnrichmentStatus, &song.DeletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", op, err)
+		}
+		songs = append(songs, song)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return songs, nil
+}
+
+// RestoreSong возвращает песню из корзины. Если за это время появилась песня
+// с тем же названием у той же группы, возвращается ErrDuplicate.
+func (r *Repository) RestoreSong(id uint, meta models.ChangeMeta) error {
+	op := "repository.RestoreSong"
+
+	query := `UPDATE song_info SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+	rowsAffected, err := execAudited(r.db, query, models.AuditRestore, meta, id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// PurgeSong окончательно удаляет песню из корзины вместе с текстом, ревизиями и задачами
+func (r *Repository) PurgeSong(id uint, meta models.ChangeMeta) error {
+	op := "repository.PurgeSong"
+
+	query := `DELETE FROM song_info WHERE id = $1 AND deleted_at IS NOT NULL`
+	rowsAffected, err := execAudited(r.db, query, models.AuditPurge, meta, id)
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// PurgeDeletedSongs окончательно удаляет песни, пролежавшие в корзине дольше retention.
+// Время считается на стороне базы, как и deleted_at. Возвращает число удаленных песен.
+func (r *Repository) PurgeDeletedSongs(retention time.Duration, meta models.ChangeMeta) (int, error) {
+	op := "repository.PurgeDeletedSongs"
+
+	query := `DELETE FROM song_info WHERE deleted_at < NOW() - $1 * INTERVAL '1 millisecond'`
+	rowsAffected, err := execAudited(r.db, query, models.AuditPurge, meta, retention.Milliseconds())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", op, err)
+	}
+
+	return int(rowsAffected), nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStoreTrash(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		hysteria := createSong(t, store, "Muse", "Hysteria", "2003-12-01", "It's bugging me")
+		time.Sleep(10 * time.Millisecond)
+		uprising := createSong(t, store, "Muse", "Uprising", "2009-09-07")
+
+		for _, id := range []uint{hysteria, uprising} {
+			if err := store.DeleteSongByID(id, testMeta); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if _, err := store.GetSongByID(hysteria); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("deleted song is still visible: %v", err)
+		}
+		if err := store.DeleteSongByID(hysteria, testMeta); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("second delete: %v", err)
+		}
+
+		deleted, err := store.GetDeletedSongs(100, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deleted) != 2 || deleted[0].ID != uprising || deleted[1].ID != hysteria || deleted[0].DeletedAt == "" {
+			t.Fatalf("trash: %+v", deleted)
+		}
+
+		// Восстановление возвращает песню вместе с текстом
+		if err := store.RestoreSong(hysteria, testMeta); err != nil {
+			t.Fatal(err)
+		}
+		song, err := store.GetSongByID(hysteria)
+		if err != nil || song.Text != "It's bugging me" || song.DeletedAt != "" {
+			t.Fatalf("restored song: %+v, %v", song, err)
+		}
+		if err := store.RestoreSong(hysteria, testMeta); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("restore of a live song: %v", err)
+		}
+		if err := store.PurgeSong(hysteria, testMeta); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("purge of a live song: %v", err)
+		}
+
+		// Песню нельзя восстановить, если ее название уже занято
+		if _, err := store.SaveSongInfo("muse", "Uprising", "", ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.RestoreSong(uprising, testMeta); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("restore over a live duplicate: %v, want ErrDuplicate", err)
+		}
+
+		if err := store.PurgeSong(uprising, testMeta); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.RestoreSong(uprising, testMeta); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("restore of a purged song: %v", err)
+		}
+		if deleted, err = store.GetDeletedSongs(100, 0); err != nil || len(deleted) != 0 {
+			t.Fatalf("trash after purge: %+v, %v", deleted, err)
+		}
+	})
+}
+
+func TestStorePurgeDeletedSongs(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		ids := []uint{
+			createSong(t, store, "Muse", "Hysteria", ""),
+			createSong(t, store, "Muse", "Uprising", ""),
+		}
+		live := createSong(t, store, "Muse", "Madness", "")
+		for _, id := range ids {
+			if err := store.DeleteSongByID(id, testMeta); err != nil {
+				t.Fatal(err)
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		// Срок хранения еще не истек
+		if purged, err := store.PurgeDeletedSongs(time.Hour, testMeta); err != nil || purged != 0 {
+			t.Fatalf("PurgeDeletedSongs(1h) = %d, %v", purged, err)
+		}
+
+		purged, err := store.PurgeDeletedSongs(time.Millisecond, testMeta)
+		if err != nil || purged != 2 {
+			t.Fatalf("PurgeDeletedSongs(1ms) = %d, %v", purged, err)
+		}
+		if deleted, err := store.GetDeletedSongs(100, 0); err != nil || len(deleted) != 0 {
+			t.Fatalf("trash after purge: %+v, %v", deleted, err)
+		}
+		if _, err := store.GetSongByID(live); err != nil {
+			t.Fatalf("live song was purged: %v", err)
+		}
+	})
+}
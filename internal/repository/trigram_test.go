@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTrigramSimilarity(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want float64
+	}{
+		{"word", "word", 1},
+		{"Muse", "MUSE", 1},
+		// Значения совпадают с SELECT similarity(a, b) из pg_trgm
+		{"hello", "hallo", 3.0 / 9},
+		{"Muse", "Mues", 2.0 / 8},
+		{"AC/DC", "ac dc", 1},
+		{"Uprising", "", 0},
+		{"!!!", "???", 0},
+		{"ab", "abc", 2.0 / 5},
+	} {
+		got := trigramSimilarity(tc.a, tc.b)
+		if math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("trigramSimilarity(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+		if back := trigramSimilarity(tc.b, tc.a); back != got {
+			t.Errorf("trigramSimilarity is not symmetric for %q and %q: %v and %v", tc.a, tc.b, got, back)
+		}
+	}
+}
+
+func TestTrigrams(t *testing.T) {
+	got := trigrams("Ёж")
+	for _, want := range []string{"  ё", " ёж", "ёж "} {
+		if !got[want] {
+			t.Errorf("trigrams of Ёж do not contain %q: %v", want, got)
+		}
+	}
+	if len(got) != 3 {
+		t.Errorf("trigrams of Ёж: %v", got)
+	}
+}
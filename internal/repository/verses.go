@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// execer - общий интерфейс *sql.DB и *sql.Tx для запросов без результата
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// renderedVerse - SQL-выражение над song_verses, совпадающее с models.Verse.Rendered
+const renderedVerse = `CASE WHEN verse_type IS NULL THEN verse ELSE '[' || INITCAP(verse_type) || ']' || E'\n' || verse END`
+
+// insertVerses записывает куплеты песни по порядку. Повтор хранится без текста и типа,
+// они берутся из исходного куплета через представление song_verses.
+func insertVerses(db execer, songID uint, verses []models.Verse) error {
+	query := `
+	INSERT INTO song_text (song_id, position, verse_type, repeat_of, verse, line_times)
+	VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, 0), $5, $6)`
+	for i, v := range verses {
+		verseType, text := v.Type, v.Text
+		if v.RepeatOf != 0 {
+			verseType, text = "", ""
+		}
+		var lineTimes interface{}
+		if len(v.LineTimes) > 0 {
+			lineTimes = pq.Array(v.LineTimes)
+		}
+		if _, err := db.Exec(query, songID, i+1, verseType, v.RepeatOf, text, lineTimes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveVerses нумерует куплеты по порядку и подставляет в повторы текст и тип
+// исходного куплета, как это делает представление song_verses
+func resolveVerses(verses []models.Verse) []models.Verse {
+	resolved := make([]models.Verse, len(verses))
+	for i, v := range verses {
+		v.Position = i + 1
+		v.LineTimes = slices.Clone(v.LineTimes)
+		if v.RepeatOf > 0 && v.RepeatOf <= i {
+			orig := resolved[v.RepeatOf-1]
+			v.Type, v.Text = orig.Type, orig.Text
+		}
+		resolved[i] = v
+	}
+	return resolved
+}
+
+func renderVerses(verses []models.Verse) []string {
+	rendered := make([]string, len(verses))
+	for i, v := range verses {
+		rendered[i] = v.Rendered()
+	}
+	return rendered
+}
+
+// verseTexts возвращает тексты куплетов (by = verse) или их строки (by = line) по порядку
+func verseTexts(verses []models.Verse, by string) []string {
+	texts := []string{}
+	for _, v := range verses {
+		switch {
+		case by != models.TextByLine:
+			texts = append(texts, v.Text)
+		case v.Text != "":
+			texts = append(texts, strings.Split(v.Text, "\n")...)
+		}
+	}
+	return texts
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestStoreVerses(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store SongStore) {
+		id := createSong(t, store, "Muse", "Starlight", "2006-09-04")
+
+		verses := []models.Verse{
+			{Position: 1, Type: models.VerseTypeVerse, Text: "Far away\nThe ship is taking me far away", LineTimes: []int64{1000, 4500}},
+			{Position: 2, Type: models.VerseTypeChorus, Text: "My life\nYou electrify my life"},
+			// Повтор хранится ссылкой, текст и тип берутся из исходного куплета
+			{Position: 3, RepeatOf: 2, LineTimes: []int64{30000, 32000}},
+			{Position: 4, Text: "Our hopes and expectations"},
+		}
+		if err := store.UpdateSongByID(id, &models.SongPatch{}, verses, testMeta); err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := store.GetSongVerses(id, 100, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := slices.Clone(verses)
+		want[2].Type, want[2].Text = models.VerseTypeChorus, "My life\nYou electrify my life"
+		if resp.Song != "Starlight" || !reflect.DeepEqual(resp.Verses, want) {
+			t.Fatalf("verses:\n%+v\nwant:\n%+v", resp.Verses, want)
+		}
+
+		page, err := store.GetSongVerses(id, 2, 3)
+		if err != nil || len(page.Verses) != 1 || page.Verses[0].Position != 4 {
+			t.Fatalf("verses page: %+v, %v", page, err)
+		}
+
+		verse, err := store.GetVerse(id, 3)
+		if err != nil || !reflect.DeepEqual(*verse, want[2]) {
+			t.Fatalf("GetVerse(3) = %+v, %v", verse, err)
+		}
+		for _, n := range []int{0, 5} {
+			if _, err := store.GetVerse(id, n); !errors.Is(err, sql.ErrNoRows) {
+				t.Fatalf("GetVerse(%d): %v", n, err)
+			}
+		}
+
+		lines, err := store.GetSongTextByID(id, models.TextByLine, 3, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(lines.Text, []string{"My life", "You electrify my life", "My life"}) {
+			t.Fatalf("lines: %q", lines.Text)
+		}
+
+		// Пустой список куплетов очищает текст
+		if err := store.UpdateSongByID(id, &models.SongPatch{}, []models.Verse{}, testMeta); err != nil {
+			t.Fatal(err)
+		}
+		resp, err = store.GetSongVerses(id, 100, 0)
+		if err != nil || resp.Verses == nil || len(resp.Verses) != 0 {
+			t.Fatalf("verses after clearing: %+v, %v", resp, err)
+		}
+
+		if _, err := store.GetSongVerses(id+100, 100, 0); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("verses of a missing song: %v", err)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package router_test
+
+import (
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestAlbumRoutes(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	uprising := api.createSong("Muse", "Uprising", "")
+	starlight := api.createSong("Muse", "Starlight", "")
+
+	api.expect(api.do(http.MethodPost, "/albums", editorKey, models.Album{GroupID: 999, Title: "The Resistance"}), http.StatusBadRequest, nil)
+	api.expect(api.do(http.MethodPost, "/albums", editorKey, models.Album{GroupID: uprising.GroupID, Title: "The Resistance", Type: "CD"}), http.StatusBadRequest, nil)
+
+	var album models.Album
+	api.expect(api.do(http.MethodPost, "/albums", editorKey, models.Album{GroupID: uprising.GroupID, Title: "The Resistance", ReleaseDate: "2009-09-14", Type: "LP"}), http.StatusCreated, &album)
+	api.expect(api.do(http.MethodPost, "/albums", editorKey, models.Album{GroupID: uprising.GroupID, Title: "The Resistance"}), http.StatusConflict, nil)
+	path := fmt.Sprintf("/albums/%d", album.ID)
+
+	var tracks []models.AlbumTrack
+	api.expect(api.do(http.MethodPut, path+"/tracks", editorKey, models.AlbumTracksReq{SongIDs: []uint{starlight.ID, uprising.ID}}), http.StatusOK, &tracks)
+	if len(tracks) != 2 || tracks[0].ID != starlight.ID || tracks[1].Position != 2 {
+		t.Fatalf("tracks: %+v", tracks)
+	}
+	api.expect(api.do(http.MethodPut, path+"/tracks", editorKey, models.AlbumTracksReq{SongIDs: []uint{uprising.ID, 999}}), http.StatusBadRequest, nil)
+	api.expect(api.do(http.MethodPut, path+"/tracks", editorKey, models.AlbumTracksReq{SongIDs: []uint{uprising.ID, uprising.ID}}), http.StatusBadRequest, nil)
+
+	var songs []models.Song
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/songs?album_id=%d", album.ID), readerKey, nil), http.StatusOK, &songs)
+	if len(songs) != 2 {
+		t.Fatalf("songs by album_id: %+v", songs)
+	}
+
+	albumType := "EP"
+	var patched models.Album
+	api.expect(api.do(http.MethodPatch, path, editorKey, models.AlbumPatch{Type: &albumType}), http.StatusOK, &patched)
+	if patched.Type != albumType || patched.Title != album.Title {
+		t.Fatalf("patched album: %+v", patched)
+	}
+
+	api.expect(api.do(http.MethodDelete, fmt.Sprintf("/groups/%d", uprising.GroupID), adminKey, nil), http.StatusConflict, nil)
+	api.expect(api.do(http.MethodDelete, path, adminKey, nil), http.StatusOK, nil)
+	api.expect(api.do(http.MethodGet, path, readerKey, nil), http.StatusNotFound, nil)
+	api.expect(api.do(http.MethodGet, path+"/tracks", readerKey, nil), http.StatusNotFound, nil)
+}
@@ -0,0 +1,67 @@
+package router_test
+
+import (
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	song := api.createSong("Muse", "Hysteria", "")
+	api.expect(api.do(http.MethodDelete, fmt.Sprintf("/songs/%d", song.ID), adminKey, nil, "X-Request-ID", "req-delete"), http.StatusOK, nil)
+
+	var audit []models.AuditEntry
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/audit?song_id=%d&actor=root", song.ID), adminKey, nil), http.StatusOK, &audit)
+	if len(audit) != 1 || audit[0].Action != models.AuditDelete || audit[0].RequestID != "req-delete" || audit[0].Source != models.AuditSourceAPI {
+		t.Fatalf("delete is not in the audit log: %+v", audit)
+	}
+
+	api.expect(api.do(http.MethodGet, "/audit?from=2000-01-01&to=2000-01-01", adminKey, nil), http.StatusOK, &audit)
+	if len(audit) != 0 {
+		t.Fatalf("audit outside the period: %+v", audit)
+	}
+
+	api.expect(api.do(http.MethodGet, "/audit", editorKey, nil), http.StatusForbidden, nil)
+	for _, query := range []string{"song_id=0", "song_id=x", "from=yesterday", "to=01.01.2024"} {
+		api.expect(api.do(http.MethodGet, "/audit?"+query, adminKey, nil), http.StatusBadRequest, nil)
+	}
+}
+
+func TestGroupRenameIsRecordedAndRevertKeepsGroup(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	song := api.createSong("Muse", "Hysteria", "")
+
+	name := "MUSE"
+	api.expect(api.do(http.MethodPatch, fmt.Sprintf("/groups/%d", song.GroupID), editorKey, models.GroupPatch{Name: &name}), http.StatusOK, nil)
+
+	var songs []models.Song
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/groups/%d/songs", song.GroupID), readerKey, nil), http.StatusOK, &songs)
+	if len(songs) != 1 || songs[0].Group != name {
+		t.Fatalf("group songs after rename: %+v", songs)
+	}
+
+	var audit []models.AuditEntry
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/audit?song_id=%d", song.ID), adminKey, nil), http.StatusOK, &audit)
+	if len(audit) == 0 || audit[0].Actor != "ed" || len(audit[0].Changes) != 1 || audit[0].Changes[0].Field != "group" {
+		t.Fatalf("rename is not in the audit log: %+v", audit)
+	}
+
+	// Ревизия 1 записана до переименования, но откат не должен отделять песню от группы
+	var reverted models.Song
+	api.expect(api.do(http.MethodPost, fmt.Sprintf("/songs/%d/revisions/1/revert", song.ID), editorKey, nil), http.StatusOK, &reverted)
+	if reverted.GroupID != song.GroupID || reverted.Group != name {
+		t.Fatalf("revert moved the song to another group: %+v", reverted)
+	}
+
+	var groups []models.Group
+	api.expect(api.do(http.MethodGet, "/groups", readerKey, nil), http.StatusOK, &groups)
+	if len(groups) != 1 {
+		t.Fatalf("revert created a new group: %+v", groups)
+	}
+
+	api.expect(api.do(http.MethodDelete, fmt.Sprintf("/groups/%d", song.GroupID), adminKey, nil), http.StatusConflict, nil)
+}
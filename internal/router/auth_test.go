@@ -0,0 +1,58 @@
+package router_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func hs256Token(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingInput := header + "." + enc.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, []byte(jwtSecret))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthentication(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	rec := api.do(http.MethodGet, "/groups", "", nil)
+	api.expect(rec, http.StatusUnauthorized, nil)
+	if rec.Header().Get("WWW-Authenticate") == "" {
+		t.Fatal("401 without WWW-Authenticate")
+	}
+
+	api.expect(api.do(http.MethodGet, "/groups", "wrong-key", nil), http.StatusUnauthorized, nil)
+	api.expect(api.do(http.MethodGet, "/groups", readerKey, nil), http.StatusOK, nil)
+	api.expect(api.do(http.MethodPost, "/groups", readerKey, models.Group{Name: "Muse"}), http.StatusForbidden, nil)
+	api.expect(api.do(http.MethodDelete, "/songs/1", editorKey, nil), http.StatusForbidden, nil)
+
+	exp := time.Now().Add(time.Hour).Unix()
+	token := hs256Token(t, map[string]interface{}{"sub": "jwt-editor", "role": "editor", "exp": exp})
+	var group models.Group
+	api.expect(api.do(http.MethodPost, "/groups", "", models.Group{Name: "Muse"}, "Authorization", "Bearer "+token), http.StatusCreated, &group)
+
+	expired := hs256Token(t, map[string]interface{}{"sub": "jwt-editor", "role": "editor", "exp": time.Now().Add(-time.Hour).Unix()})
+	api.expect(api.do(http.MethodGet, "/groups", "", nil, "Authorization", "Bearer "+expired), http.StatusUnauthorized, nil)
+	// Бессрочные токены не принимаются
+	unbounded := hs256Token(t, map[string]interface{}{"sub": "jwt-editor", "role": "editor"})
+	api.expect(api.do(http.MethodGet, "/groups", "", nil, "Authorization", "Bearer "+unbounded), http.StatusUnauthorized, nil)
+	api.expect(api.do(http.MethodGet, "/groups", "", nil, "Authorization", "Bearer "+token[:len(token)-2]+"xx"), http.StatusUnauthorized, nil)
+
+	// Без аутентификации доступны только проверки состояния
+	api.expect(api.do(http.MethodGet, "/healthz", "", nil), http.StatusOK, nil)
+}
@@ -0,0 +1,61 @@
+package router_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExportRoute(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	// Пустая библиотека выгружается пустым массивом, а не 404
+	var songs []models.ExportSong
+	api.expect(api.do(http.MethodGet, "/export", readerKey, nil), http.StatusOK, &songs)
+	if len(songs) != 0 {
+		t.Fatalf("empty export: %+v", songs)
+	}
+
+	api.createSong("Muse", "Uprising", "")
+	api.createSong("Muse", "Hysteria", "")
+
+	rec := api.do(http.MethodGet, "/export?lyrics=true&sort=song", readerKey, nil)
+	api.expect(rec, http.StatusOK, &songs)
+	if len(songs) != 2 || songs[0].Song != "Hysteria" || len(songs[0].Verses) != 1 || songs[0].Verses[0] != "It's bugging me" {
+		t.Fatalf("json export: %+v", songs)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="songs.json"` {
+		t.Fatalf("Content-Disposition: %q", cd)
+	}
+
+	rec = api.do(http.MethodGet, "/export?format=csv&song=Uprising", readerKey, nil)
+	api.expect(rec, http.StatusOK, nil)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("Content-Type: %q", ct)
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || len(records[0]) != 8 || records[1][3] != "Uprising" || records[1][4] != "2009-09-07" {
+		t.Fatalf("csv export: %q", records)
+	}
+
+	rec = api.do(http.MethodGet, "/export?format=ndjson", readerKey, nil)
+	api.expect(rec, http.StatusOK, nil)
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("ndjson export: %q", rec.Body.String())
+	}
+	var first models.ExportSong
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first.Song != "Uprising" {
+		t.Fatalf("ndjson line: %q, %v", lines[0], err)
+	}
+
+	for _, query := range []string{"format=xml", "lyrics=maybe", "sort=title", "group_id=x"} {
+		api.expect(api.do(http.MethodGet, "/export?"+query, readerKey, nil), http.StatusBadRequest, nil)
+	}
+}
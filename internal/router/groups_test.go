@@ -0,0 +1,73 @@
+package router_test
+
+import (
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestGroupRoutes(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	var group models.Group
+	api.expect(api.do(http.MethodPost, "/groups", editorKey, models.Group{Name: "Muse", Country: "UK"}), http.StatusCreated, &group)
+	api.expect(api.do(http.MethodPost, "/groups", editorKey, models.Group{Name: "muse"}), http.StatusConflict, nil)
+	api.expect(api.do(http.MethodPost, "/groups", editorKey, models.Group{}), http.StatusBadRequest, nil)
+	path := fmt.Sprintf("/groups/%d", group.ID)
+
+	song := api.createSong("muse", "Uprising", "")
+	if song.GroupID != group.ID || song.Group != "Muse" {
+		t.Fatalf("song was not added to the existing group: %+v", song)
+	}
+
+	var songs []models.Song
+	api.expect(api.do(http.MethodGet, "/songs?group_id="+fmt.Sprint(group.ID), readerKey, nil), http.StatusOK, &songs)
+	if len(songs) != 1 || songs[0].ID != song.ID {
+		t.Fatalf("songs by group_id: %+v", songs)
+	}
+
+	api.expect(api.do(http.MethodPost, "/groups", editorKey, models.Group{Name: "Queen"}), http.StatusCreated, nil)
+	taken := "QUEEN"
+	api.expect(api.do(http.MethodPatch, path, editorKey, models.GroupPatch{Name: &taken}), http.StatusConflict, nil)
+
+	name := "MUSE"
+	var renamed models.Group
+	api.expect(api.do(http.MethodPatch, path, editorKey, models.GroupPatch{Name: &name}), http.StatusOK, &renamed)
+	if renamed.Name != name || renamed.Country != "UK" {
+		t.Fatalf("renamed group: %+v", renamed)
+	}
+	api.expect(api.do(http.MethodGet, path+"/songs", readerKey, nil), http.StatusOK, &songs)
+	if len(songs) != 1 || songs[0].Group != name {
+		t.Fatalf("group songs after rename: %+v", songs)
+	}
+
+	api.expect(api.do(http.MethodDelete, path, adminKey, nil), http.StatusConflict, nil)
+	api.expect(api.do(http.MethodGet, "/groups/999", readerKey, nil), http.StatusNotFound, nil)
+	api.expect(api.do(http.MethodGet, "/groups/999/songs", readerKey, nil), http.StatusNotFound, nil)
+}
+
+func TestLegacySongRoutesMatchGroupCaseInsensitively(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	api.expect(api.do(http.MethodPost, "/groups", editorKey, models.Group{Name: "Muse"}), http.StatusCreated, nil)
+	song := api.createSong("muse", "Uprising", "")
+	api.expect(api.do(http.MethodPost, "/create-song", editorKey, models.CreateSongReq{Group: "MUSE", Song: "Uprising"}), http.StatusConflict, nil)
+
+	query := "group=muse&song=Uprising"
+	var text []string
+	api.expect(api.do(http.MethodGet, "/song?"+query, readerKey, nil), http.StatusOK, &text)
+	if len(text) != 1 {
+		t.Fatalf("text for group=muse: %q", text)
+	}
+
+	api.expect(api.do(http.MethodPut, "/song", editorKey, models.Song{Group: "MUSE", Song: "Uprising", Link: "https://example.com/new"}), http.StatusOK, nil)
+	var updated models.Song
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/songs/%d", song.ID), readerKey, nil), http.StatusOK, &updated)
+	if updated.Link != "https://example.com/new" {
+		t.Fatalf("PUT /song did not update the link: %+v", updated)
+	}
+
+	api.expect(api.do(http.MethodDelete, "/song?"+query, adminKey, nil), http.StatusOK, nil)
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/songs/%d", song.ID), readerKey, nil), http.StatusNotFound, nil)
+}
@@ -0,0 +1,34 @@
+package router_test
+
+import (
+	"context"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/health"
+	"net/http"
+	"testing"
+)
+
+func TestReadiness(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	var report health.Report
+	api.expect(api.do(http.MethodGet, "/readyz", "", nil), http.StatusOK, &report)
+	if report.Status != health.StatusOK {
+		t.Fatalf("report: %+v", report)
+	}
+
+	api.checker.Add("database", func(context.Context) error { return errors.New("connection refused") })
+	api.expect(api.do(http.MethodGet, "/readyz", "", nil), http.StatusServiceUnavailable, &report)
+	if report.Status != health.StatusFail || report.Checks["database"].Error != "connection refused" {
+		t.Fatalf("report with a failed check: %+v", report)
+	}
+
+	api.checker.StartShutdown()
+	report = health.Report{}
+	api.expect(api.do(http.MethodGet, "/readyz", "", nil), http.StatusServiceUnavailable, &report)
+	if report.Status != health.StatusFail || report.Checks["shutdown"].Status != health.StatusFail {
+		t.Fatalf("report during shutdown: %+v", report)
+	}
+	// Живость не зависит от готовности и не требует аутентификации
+	api.expect(api.do(http.MethodGet, "/healthz", "", nil), http.StatusOK, nil)
+}
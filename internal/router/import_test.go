@@ -0,0 +1,51 @@
+package router_test
+
+import (
+	"bytes"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mime/multipart"
+	"net/http"
+	"testing"
+)
+
+func TestImportRoute(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	csv := "group,song,release_date,text,link\n" +
+		"Muse,Uprising,,,\n" +
+		"Muse,Resistance,2009-09-14,Love is our resistance,https://example.com/resistance\n" +
+		"Muse,Uprising,,,\n"
+
+	var report models.ImportReport
+	api.expect(api.do(http.MethodPost, "/import", editorKey, csv, "Content-Type", "text/csv"), http.StatusOK, &report)
+	if report.Created != 2 || report.Duplicates != 1 || report.Failed != 0 {
+		t.Fatalf("csv report: %+v", report)
+	}
+	if report.Results[0].Sources[models.DetailReleaseDate] != "file" {
+		t.Fatalf("missing fields were not enriched: %+v", report.Results[0])
+	}
+
+	ndjson := `{"group": "Muse", "song": "Hysteria"}` + "\n" + `{"group": "Muse", "song": "Resistance"}`
+	api.expect(api.do(http.MethodPost, "/import?format=ndjson", editorKey, ndjson), http.StatusOK, &report)
+	if report.Created != 1 || report.Duplicates != 1 {
+		t.Fatalf("ndjson report: %+v", report)
+	}
+
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	fw, err := mw.CreateFormFile("file", "songs.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte("group,song\nMuse,Starlight\n"))
+	mw.Close()
+	api.expect(api.do(http.MethodPost, "/import", editorKey, form.String(), "Content-Type", mw.FormDataContentType()), http.StatusOK, &report)
+	if report.Created != 1 || report.Results[0].Song != "Starlight" {
+		t.Fatalf("multipart report: %+v", report)
+	}
+
+	api.expect(api.do(http.MethodPost, "/import", editorKey, csv, "Content-Type", "text/plain"), http.StatusBadRequest, nil)
+	api.expect(api.do(http.MethodPost, "/import?format=xml", editorKey, csv), http.StatusBadRequest, nil)
+	api.expect(api.do(http.MethodPost, "/import", editorKey, "song\nUprising\n", "Content-Type", "text/csv"), http.StatusBadRequest, nil)
+	api.expect(api.do(http.MethodPost, "/import", readerKey, csv, "Content-Type", "text/csv"), http.StatusForbidden, nil)
+}
@@ -0,0 +1,57 @@
+package router_test
+
+import (
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLRC(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	lrc := "[00:01.00]Paranoia is in bloom\n[00:04.50]The PR transmissions will resume\n"
+	song := api.createSong("Muse", "Uprising", lrc)
+	path := fmt.Sprintf("/songs/%d/lyrics", song.ID)
+
+	// Текст из LRC не перезаписывается данными источника
+	if song.Text != "Paranoia is in bloom\nThe PR transmissions will resume" {
+		t.Fatalf("text of a song created from LRC: %q", song.Text)
+	}
+
+	rec := api.do(http.MethodGet, path+".lrc", readerKey, nil)
+	api.expect(rec, http.StatusOK, nil)
+	for _, line := range []string{"[ti:Uprising]", "[00:01.00]Paranoia is in bloom", "[00:04.50]The PR transmissions will resume"} {
+		if !strings.Contains(rec.Body.String(), line) {
+			t.Fatalf("LRC %q does not contain %q", rec.Body.String(), line)
+		}
+	}
+
+	rec = api.do(http.MethodGet, path+".srt", readerKey, nil)
+	api.expect(rec, http.StatusOK, nil)
+	if !strings.HasPrefix(rec.Body.String(), "1\n00:00:01,000 --> 00:00:04,500\n") {
+		t.Fatalf("SRT:\n%s", rec.Body.String())
+	}
+
+	rec = api.do(http.MethodGet, path+".vtt", readerKey, nil)
+	api.expect(rec, http.StatusOK, nil)
+	if !strings.HasPrefix(rec.Body.String(), "WEBVTT") || !strings.Contains(rec.Body.String(), "00:00:01.000 --> 00:00:04.500") {
+		t.Fatalf("VTT:\n%s", rec.Body.String())
+	}
+
+	var verses models.SongVersesResp
+	upload := "[Chorus]\n[00:10.00]They will not force us\n"
+	api.expect(api.do(http.MethodPut, path+".lrc", editorKey, upload, "Content-Type", "text/plain"), http.StatusOK, &verses)
+	if len(verses.Verses) != 1 || verses.Verses[0].Type != models.VerseTypeChorus || verses.Verses[0].LineTimes[0] != 10000 {
+		t.Fatalf("uploaded verses: %+v", verses.Verses)
+	}
+
+	api.expect(api.do(http.MethodPut, path+".lrc", editorKey, "no timestamps here", "Content-Type", "text/plain"), http.StatusBadRequest, nil)
+	api.expect(api.do(http.MethodPut, path+".lrc", readerKey, upload, "Content-Type", "text/plain"), http.StatusForbidden, nil)
+	api.expect(api.do(http.MethodGet, "/songs/999/lyrics.srt", readerKey, nil), http.StatusNotFound, nil)
+
+	// Песня без временных меток
+	plain := api.createSong("Muse", "Hysteria", "")
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/songs/%d/lyrics.vtt", plain.ID), readerKey, nil), http.StatusNotFound, nil)
+}
@@ -0,0 +1,32 @@
+package router_test
+
+import (
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/metrics"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	api := newTestAPI(t, apiOptions{metrics: metrics.New()})
+
+	song := api.createSong("Muse", "Hysteria", "")
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/songs/%d", song.ID), readerKey, nil), http.StatusOK, nil)
+	api.expect(api.do(http.MethodGet, "/songs/999", readerKey, nil), http.StatusNotFound, nil)
+
+	// /metrics открыт без аутентификации, как и проверки состояния
+	rec := api.do(http.MethodGet, "/metrics", "", nil)
+	api.expect(rec, http.StatusOK, nil)
+	body := rec.Body.String()
+	// createSong уже один раз читает песню
+	for _, series := range []string{
+		`music_library_http_requests_total{method="GET",route="/songs/:id",status="200"} 2`,
+		`music_library_http_requests_total{method="GET",route="/songs/:id",status="404"} 1`,
+		`music_library_http_requests_total{method="POST",route="/create-song",status="202"} 1`,
+	} {
+		if !strings.Contains(body, series) {
+			t.Errorf("/metrics has no %s", series)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package router
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"mikromolekula2002/music_library_ver1.0/internal/auth"
+	"mikromolekula2002/music_library_ver1.0/internal/ratelimit"
+	"mikromolekula2002/music_library_ver1.0/internal/service"
+	"mikromolekula2002/music_library_ver1.0/pkg/logger"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxActorLength совпадает с размером колонки song_revisions.author
+const maxActorLength = 255
+
+// maxRequestIDLength совпадает с размером колонки audit_log.request_id
+const maxRequestIDLength = 255
+
+// actorMiddleware кладет в контекст запроса автора изменений из заголовка X-Actor.
+// Если включена аутентификация, автором становится клиент и заголовок не учитывается.
+func actorMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if actor := strings.TrimSpace(ctx.GetHeader("X-Actor")); actor != "" {
+			if len(actor) > maxActorLength {
+				actor = strings.ToValidUTF8(actor[:maxActorLength], "")
+			}
+			ctx.Request = ctx.Request.WithContext(service.WithActor(ctx.Request.Context(), actor))
+		}
+		ctx.Next()
+	}
+}
+
+// requestIDMiddleware берет идентификатор запроса из заголовка X-Request-ID или создает новый
+// и возвращает его клиенту в том же заголовке. В контекст запроса кладутся идентификатор и запись
+// лога с ним, через которую пишут контроллеры и сервис.
+func requestIDMiddleware(base *logrus.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := strings.TrimSpace(ctx.GetHeader("X-Request-ID"))
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		ctx.Header("X-Request-ID", requestID)
+		reqCtx := service.WithRequestID(ctx.Request.Context(), requestID)
+		reqCtx = logger.WithEntry(reqCtx, base.WithField("request_id", requestID))
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		ctx.Next()
+	}
+}
+
+// validRequestID допускает идентификаторы из видимых ASCII-символов, чтобы чужой заголовок
+// не мог подделать строки лога
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// accessLogMiddleware пишет по строке лога на каждый запрос: статус, время обработки и размер ответа.
+// Ответы 5xx пишутся с уровнем error, 4xx - warning.
+func accessLogMiddleware(base *logrus.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		fields := logrus.Fields{
+			"method":     ctx.Request.Method,
+			"path":       ctx.Request.URL.Path,
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"bytes":      max(ctx.Writer.Size(), 0),
+			"client_ip":  ctx.ClientIP(),
+			"user_agent": ctx.Request.UserAgent(),
+		}
+		if query := ctx.Request.URL.RawQuery; query != "" {
+			fields["query"] = query
+		}
+		if principal, ok := auth.PrincipalFromContext(ctx.Request.Context()); ok {
+			fields["client"] = principal.Subject
+		}
+
+		entry := logger.FromContext(ctx.Request.Context(), base).WithFields(fields)
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error("Request completed")
+		case status >= http.StatusBadRequest:
+			entry.Warn("Request completed")
+		default:
+			entry.Info("Request completed")
+		}
+	}
+}
+
+// requireRole пропускает запрос, только если клиент аутентифицирован и его роли хватает для role.
+// Клиент кладется в контекст запроса и становится автором изменений. Без authenticator
+// аутентификация выключена и запросы проходят без проверки.
+// Неверные учетные данные расходуют бюджет auth IP-адреса клиента; пока он исчерпан, запросы
+// с этого адреса отклоняются с 429 до проверки учетных данных, чтобы их нельзя было подбирать.
+// Хранилище корзин при этом читается только после неудачной попытки.
+func requireRole(authenticator *auth.Authenticator, limiter *ratelimit.Limiter, base *logrus.Logger, role auth.Role) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if authenticator == nil {
+			return
+		}
+
+		client := ipClient(ctx)
+		if limiter != nil {
+			if res := limiter.Check(ratelimit.Auth, client); res != nil {
+				logger.FromContext(ctx.Request.Context(), base).WithFields(logrus.Fields{"client": client}).Warn("Too many failed authentication attempts")
+				abortTooManyRequests(ctx, res)
+				return
+			}
+		}
+
+		principal, err := authenticator.Authenticate(ctx.Request)
+		if err != nil {
+			if !errors.Is(err, auth.ErrNoCredentials) {
+				logger.FromContext(ctx.Request.Context(), base).Warn("Authentication failed: ", err)
+				if limiter != nil {
+					if _, err := limiter.Allow(ratelimit.Auth, client); err != nil {
+						logger.FromContext(ctx.Request.Context(), base).Error("Rate limit check failed: ", err)
+					}
+				}
+			}
+			ctx.Header("WWW-Authenticate", `Bearer realm="music_library"`)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if !principal.Allows(role) {
+			logger.FromContext(ctx.Request.Context(), base).WithFields(logrus.Fields{"subject": principal.Subject, "role": principal.Role.String(), "required": role.String()}).Warn("Access denied")
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: " + role.String() + " role required"})
+			return
+		}
+
+		reqCtx := auth.WithPrincipal(ctx.Request.Context(), principal)
+		ctx.Request = ctx.Request.WithContext(service.WithActor(reqCtx, principal.Subject))
+	}
+}
+
+// rateLimit расходует токен из бюджета class клиента: аутентифицированного - по имени,
+// остальных - по IP. Отвечает 429, если бюджет исчерпан, и добавляет заголовки RateLimit-*.
+// Если хранилище корзин недоступно, запрос пропускается.
+func rateLimit(limiter *ratelimit.Limiter, base *logrus.Logger, class ratelimit.Class) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if limiter == nil {
+			return
+		}
+
+		client := ipClient(ctx)
+		if principal, ok := auth.PrincipalFromContext(ctx.Request.Context()); ok {
+			client = "client:" + principal.Subject
+		}
+
+		res, err := limiter.Allow(class, client)
+		if err != nil {
+			logger.FromContext(ctx.Request.Context(), base).Error("Rate limit check failed: ", err)
+			return
+		}
+		if res == nil {
+			return
+		}
+
+		if !res.Allowed {
+			logger.FromContext(ctx.Request.Context(), base).WithFields(logrus.Fields{"client": client, "class": string(class)}).Warn("Rate limit exceeded")
+			abortTooManyRequests(ctx, res)
+			return
+		}
+		setRateLimitHeaders(ctx, res)
+	}
+}
+
+// ipClient - ключ клиента по IP-адресу. Адрес из X-Forwarded-For учитывается, только если
+// запрос пришел от доверенного прокси (TRUSTED_PROXIES).
+func ipClient(ctx *gin.Context) string {
+	return "ip:" + ctx.ClientIP()
+}
+
+func setRateLimitHeaders(ctx *gin.Context, res *ratelimit.Result) {
+	ctx.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", res.Limit.Requests, int(math.Ceil(res.Limit.Period.Seconds()))))
+	ctx.Header("RateLimit-Limit", strconv.Itoa(res.Limit.Requests))
+	ctx.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	ctx.Header("RateLimit-Reset", strconv.Itoa(int(math.Ceil(res.Reset.Seconds()))))
+}
+
+func abortTooManyRequests(ctx *gin.Context, res *ratelimit.Result) {
+	setRateLimitHeaders(ctx, res)
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+	ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too Many Requests"})
+}
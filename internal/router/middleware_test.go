@@ -0,0 +1,132 @@
+package router
+
+import (
+	"io"
+	"mikromolekula2002/music_library_ver1.0/internal/service"
+	"mikromolekula2002/music_library_ver1.0/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// newLoggedEngine собирает gin с идентификатором запроса и журналом доступа; handler
+// обрабатывает все запросы
+func newLoggedEngine(t *testing.T, handler gin.HandlerFunc) (*gin.Engine, *test.Hook) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	base, hook := test.NewNullLogger()
+	base.SetOutput(io.Discard)
+
+	r := gin.New()
+	r.Use(requestIDMiddleware(base), accessLogMiddleware(base), actorMiddleware())
+	r.Any("/*path", handler)
+	return r, hook
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen, logged string
+	r, _ := newLoggedEngine(t, func(ctx *gin.Context) {
+		seen = service.RequestIDFromContext(ctx.Request.Context())
+		logged, _ = logger.FromContext(ctx.Request.Context(), nil).Data["request_id"].(string)
+	})
+
+	for _, tc := range []struct {
+		header string
+		keep   bool
+	}{
+		{"req-42", true},
+		{"  req-42  ", true},
+		{"", false},
+		{"two words", false},
+		{"line\nbreak", false},
+		{"привет", false},
+		{strings.Repeat("x", maxRequestIDLength), true},
+		{strings.Repeat("x", maxRequestIDLength+1), false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-ID", tc.header)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		got := rec.Header().Get("X-Request-ID")
+		if tc.keep && got != strings.TrimSpace(tc.header) {
+			t.Errorf("X-Request-ID %q replaced with %q", tc.header, got)
+		}
+		if !tc.keep && (got == tc.header || len(got) != 32) {
+			t.Errorf("X-Request-ID %q: got %q, want a new ID", tc.header, got)
+		}
+		if seen != got || logged != got {
+			t.Errorf("request ID in context %q and log %q, header %q", seen, logged, got)
+		}
+	}
+
+	if newRequestID() == newRequestID() {
+		t.Error("request IDs repeat")
+	}
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	status := http.StatusOK
+	r, hook := newLoggedEngine(t, func(ctx *gin.Context) {
+		ctx.String(status, "hello")
+	})
+
+	for _, tc := range []struct {
+		status int
+		level  logrus.Level
+	}{
+		{http.StatusOK, logrus.InfoLevel},
+		{http.StatusNotFound, logrus.WarnLevel},
+		{http.StatusBadGateway, logrus.ErrorLevel},
+	} {
+		hook.Reset()
+		status = tc.status
+
+		req := httptest.NewRequest(http.MethodPost, "/songs?limit=5", nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		req.Header.Set("User-Agent", "tests")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		entry := hook.LastEntry()
+		if len(hook.AllEntries()) != 1 || entry.Level != tc.level || entry.Message != "Request completed" {
+			t.Fatalf("status %d: entries %+v", tc.status, hook.AllEntries())
+		}
+		for field, want := range map[string]interface{}{
+			"method": http.MethodPost, "path": "/songs", "query": "limit=5", "status": tc.status,
+			"bytes": 5, "user_agent": "tests", "request_id": "req-1",
+		} {
+			if entry.Data[field] != want {
+				t.Errorf("status %d: field %s = %v, want %v", tc.status, field, entry.Data[field], want)
+			}
+		}
+		if _, ok := entry.Data["latency_ms"].(float64); !ok {
+			t.Errorf("status %d: no latency_ms", tc.status)
+		}
+	}
+}
+
+func TestActorMiddleware(t *testing.T) {
+	var actor string
+	r, _ := newLoggedEngine(t, func(ctx *gin.Context) {
+		actor = service.ActorFromContext(ctx.Request.Context())
+	})
+
+	for header, want := range map[string]string{
+		"":                       service.ActorAnonymous,
+		" ed ":                   "ed",
+		strings.Repeat("я", 200): strings.Repeat("я", maxActorLength/2),
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Actor", header)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		if actor != want {
+			t.Errorf("X-Actor %.10q: actor %.10q, want %.10q", header, actor, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package router_test
+
+import (
+	"encoding/base64"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCursorPagination(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	for _, name := range []string{"Uprising", "Hysteria", "Starlight", "Madness", "Supermassive Black Hole"} {
+		api.createSong("Muse", name, "")
+	}
+
+	var got []string
+	var pages []models.SongPage
+	path := "/songs?pagination=cursor&limit=2&sort=song&total=body"
+	for {
+		var page models.SongPage
+		api.expect(api.do(http.MethodGet, path, readerKey, nil), http.StatusOK, &page)
+		if page.Total == nil || *page.Total != 5 {
+			t.Fatalf("total %v, want 5", page.Total)
+		}
+		pages = append(pages, page)
+		for _, s := range page.Items {
+			got = append(got, s.Song)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		path = "/songs?limit=2&sort=song&total=body&cursor=" + url.QueryEscape(page.NextCursor)
+	}
+
+	want := []string{"Hysteria", "Madness", "Starlight", "Supermassive Black Hole", "Uprising"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("pages %v, want %v", got, want)
+	}
+
+	var prev models.SongPage
+	api.expect(api.do(http.MethodGet, "/songs?limit=2&sort=song&cursor="+url.QueryEscape(pages[1].PrevCursor), readerKey, nil), http.StatusOK, &prev)
+	if len(prev.Items) != 2 || prev.Items[0].Song != "Hysteria" {
+		t.Fatalf("previous page: %+v", prev.Items)
+	}
+
+	// Курсор другой сортировки и курсор с подмененными значениями отклоняются
+	api.expect(api.do(http.MethodGet, "/songs?limit=2&sort=-song&cursor="+url.QueryEscape(pages[0].NextCursor), readerKey, nil), http.StatusBadRequest, nil)
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"-release_date,-id","v":["x","y"]}`))
+	api.expect(api.do(http.MethodGet, "/songs?limit=2&cursor="+tampered, readerKey, nil), http.StatusBadRequest, nil)
+}
+
+func TestSongsPaginationParams(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	api.createSong("Muse", "Uprising", "")
+
+	rec := api.do(http.MethodGet, "/songs?total=header", readerKey, nil)
+	api.expect(rec, http.StatusOK, nil)
+	if rec.Header().Get("X-Total-Count") != "1" {
+		t.Fatalf("X-Total-Count %q", rec.Header().Get("X-Total-Count"))
+	}
+
+	for _, query := range []string{"total=yes", "pagination=page", "pagination=cursor&songMatch=fuzzy&song=x", "limit=-1"} {
+		api.expect(api.do(http.MethodGet, "/songs?"+query, readerKey, nil), http.StatusBadRequest, nil)
+	}
+}
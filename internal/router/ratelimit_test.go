@@ -0,0 +1,53 @@
+package router_test
+
+import (
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/ratelimit"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	api := newTestAPI(t, apiOptions{limits: map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.Read:  {Requests: 2, Period: time.Minute},
+		ratelimit.Write: {Requests: 5, Period: time.Minute},
+		ratelimit.Auth:  {Requests: 2, Period: time.Minute},
+	}})
+
+	for i := 0; i < 2; i++ {
+		rec := api.do(http.MethodGet, "/groups", readerKey, nil)
+		api.expect(rec, http.StatusOK, nil)
+		if rec.Header().Get("RateLimit-Remaining") != fmt.Sprint(1-i) {
+			t.Fatalf("RateLimit-Remaining %q after %d requests", rec.Header().Get("RateLimit-Remaining"), i+1)
+		}
+		if rec.Header().Get("RateLimit-Policy") != "2;w=60" || rec.Header().Get("RateLimit-Limit") != "2" {
+			t.Fatalf("RateLimit-Policy %q, RateLimit-Limit %q", rec.Header().Get("RateLimit-Policy"), rec.Header().Get("RateLimit-Limit"))
+		}
+	}
+	rec := api.do(http.MethodGet, "/groups", readerKey, nil)
+	api.expect(rec, http.StatusTooManyRequests, nil)
+	if rec.Header().Get("Retry-After") != "30" {
+		t.Fatalf("429 with Retry-After %q", rec.Header().Get("Retry-After"))
+	}
+
+	// Бюджеты классов и клиентов независимы
+	api.expect(api.do(http.MethodPost, "/groups", editorKey, models.Group{Name: "Muse"}), http.StatusCreated, nil)
+	api.expect(api.do(http.MethodGet, "/groups", adminKey, nil), http.StatusOK, nil)
+
+	// Длинное имя клиента из JWT ограничивается так же, как короткое
+	token := hs256Token(t, map[string]interface{}{"sub": fmt.Sprintf("%0600d", 1), "role": "reader", "exp": time.Now().Add(time.Hour).Unix()})
+	for i := 0; i < 2; i++ {
+		api.expect(api.do(http.MethodGet, "/groups", "", nil, "Authorization", "Bearer "+token), http.StatusOK, nil)
+	}
+	api.expect(api.do(http.MethodGet, "/groups", "", nil, "Authorization", "Bearer "+token), http.StatusTooManyRequests, nil)
+
+	// Неверные ключи расходуют бюджет IP, подмена X-Forwarded-For не дает новый бюджет,
+	// а после его исчерпания не проверяется даже верный ключ
+	for i := 0; i < 2; i++ {
+		api.expect(api.do(http.MethodGet, "/groups", fmt.Sprintf("guess-%d", i), nil, "X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i)), http.StatusUnauthorized, nil)
+	}
+	api.expect(api.do(http.MethodGet, "/groups", "guess-2", nil, "X-Forwarded-For", "203.0.113.2"), http.StatusTooManyRequests, nil)
+	api.expect(api.do(http.MethodGet, "/groups", adminKey, nil), http.StatusTooManyRequests, nil)
+}
@@ -0,0 +1,55 @@
+package router_test
+
+import (
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRevisions(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	song := api.createSong("Muse", "Madness", "")
+
+	text := "I, I can't get these memories out of my mind\nAnd some kind of madness"
+	api.expect(api.do(http.MethodPatch, fmt.Sprintf("/songs/%d", song.ID), editorKey, models.SongPatch{Text: &text}), http.StatusOK, nil)
+
+	var revisions []models.SongRevision
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/songs/%d/revisions", song.ID), readerKey, nil), http.StatusOK, &revisions)
+	// создание, обогащение и изменение текста
+	if len(revisions) != 3 || revisions[0].Rev != 3 || revisions[0].Author != "ed" {
+		t.Fatalf("revisions: %+v", revisions)
+	}
+
+	var diff models.RevisionDiff
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/songs/%d/revisions/3/diff?from=2", song.ID), readerKey, nil), http.StatusOK, &diff)
+	if !strings.Contains(fmt.Sprint(diff), "And some kind of madness") {
+		t.Fatalf("diff does not show the added line: %+v", diff)
+	}
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/songs/%d/revisions/3/diff?from=x", song.ID), readerKey, nil), http.StatusBadRequest, nil)
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/songs/%d/revisions/9/diff", song.ID), readerKey, nil), http.StatusNotFound, nil)
+
+	api.expect(api.do(http.MethodPost, fmt.Sprintf("/songs/%d/revisions/2/revert", song.ID), editorKey, nil), http.StatusOK, nil)
+	var verses models.SongVersesResp
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/songs/%d/verses", song.ID), readerKey, nil), http.StatusOK, &verses)
+	if len(verses.Verses) != 1 || verses.Verses[0].Text != "I, I can't get these memories out of my mind" {
+		t.Fatalf("verses after revert: %+v", verses.Verses)
+	}
+}
+
+func TestRevisionDiffTooLarge(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	song := api.createSong("Muse", "Madness", "")
+
+	lines := make([]string, 1001)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	text := strings.Join(lines, "\n")
+	api.expect(api.do(http.MethodPatch, fmt.Sprintf("/songs/%d", song.ID), editorKey, models.SongPatch{Text: &text}), http.StatusOK, nil)
+
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/songs/%d/revisions/3/diff", song.ID), readerKey, nil), http.StatusUnprocessableEntity, nil)
+}
@@ -1,7 +1,11 @@
 package router
 
 import (
+	"mikromolekula2002/music_library_ver1.0/internal/auth"
 	"mikromolekula2002/music_library_ver1.0/internal/controller"
+	"mikromolekula2002/music_library_ver1.0/internal/health"
+	"mikromolekula2002/music_library_ver1.0/internal/metrics"
+	"mikromolekula2002/music_library_ver1.0/internal/ratelimit"
 	"mikromolekula2002/music_library_ver1.0/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -12,23 +16,122 @@ import (
 type Router struct {
 	Gin            *gin.Engine
 	MusicCotroller *controller.MusicLibController
+	service        *service.MusicLibService
+	auth           *auth.Authenticator
+	limiter        *ratelimit.Limiter
+	metrics        *metrics.Metrics
+	health         *health.Checker
 }
 
-func NewRouter(service *service.MusicLibService) *Router {
-	r := gin.Default()
+// NewRouter создает роутер; если authenticator, limiter или metrics равны nil,
+// аутентификация, ограничение частоты запросов или метрики выключены
+func NewRouter(service *service.MusicLibService, authenticator *auth.Authenticator, limiter *ratelimit.Limiter, metrics *metrics.Metrics, checker *health.Checker) *Router {
+	// Вместо стандартного логгера gin запросы пишет accessLogMiddleware
+	r := gin.New()
+	// Пока не заданы доверенные прокси, X-Forwarded-For не учитывается и клиент - адрес соединения
+	r.SetTrustedProxies(nil)
+	r.Use(requestIDMiddleware(service.Logger), accessLogMiddleware(service.Logger))
+	if metrics != nil {
+		r.Use(metrics.Middleware())
+	}
+	r.Use(gin.Recovery(), actorMiddleware())
 
 	return &Router{
 		Gin:            r,
 		MusicCotroller: controller.NewMusicLibController(service),
+		service:        service,
+		auth:           authenticator,
+		limiter:        limiter,
+		metrics:        metrics,
+		health:         checker,
+	}
+}
+
+// SetTrustedProxies задает адреса и подсети прокси, которым доверяется X-Forwarded-For
+// при определении IP-адреса клиента
+func (r *Router) SetTrustedProxies(proxies []string) error {
+	return r.Gin.SetTrustedProxies(proxies)
+}
+
+// guard проверяет роль клиента и затем расходует токен из его бюджета class
+func (r *Router) guard(role auth.Role, class ratelimit.Class) gin.HandlerFunc {
+	authorize := requireRole(r.auth, r.limiter, r.service.Logger, role)
+	limit := rateLimit(r.limiter, r.service.Logger, class)
+	return func(ctx *gin.Context) {
+		if authorize(ctx); ctx.IsAborted() {
+			return
+		}
+		limit(ctx)
 	}
 }
 
 func (r *Router) SetRoutes(envType string) {
-	r.Gin.POST("/create-song", r.MusicCotroller.SaveSong)
-	r.Gin.GET("/song", r.MusicCotroller.GetSongTextByGroup)
-	r.Gin.PUT("/song", r.MusicCotroller.UpdateSong)
-	r.Gin.DELETE("/song", r.MusicCotroller.DeleteSong)
-	r.Gin.GET("/songs", r.MusicCotroller.GetAllSongs)
+	// GET доступен читателям, создание и изменение - редакторам, удаление, корзина и аудит - администраторам.
+	// Создание песен и импорт обращаются к источникам данных и расходуют отдельный бюджет upstream.
+	reader := r.guard(auth.RoleReader, ratelimit.Read)
+	editor := r.guard(auth.RoleEditor, ratelimit.Write)
+	upstream := r.guard(auth.RoleEditor, ratelimit.Upstream)
+	admin := r.guard(auth.RoleAdmin, ratelimit.Write)
+	adminRead := r.guard(auth.RoleAdmin, ratelimit.Read)
+
+	r.Gin.POST("/create-song", upstream, r.MusicCotroller.SaveSong)
+	r.Gin.GET("/song", reader, r.MusicCotroller.GetSongTextByGroup)
+	r.Gin.PUT("/song", editor, r.MusicCotroller.UpdateSong)
+	r.Gin.DELETE("/song", admin, r.MusicCotroller.DeleteSong)
+	r.Gin.GET("/songs", reader, r.MusicCotroller.GetAllSongs)
+
+	r.Gin.GET("/songs/:id", reader, r.MusicCotroller.GetSongByID)
+	r.Gin.PUT("/songs/:id", editor, r.MusicCotroller.ReplaceSong)
+	r.Gin.PATCH("/songs/:id", editor, r.MusicCotroller.PatchSong)
+	r.Gin.DELETE("/songs/:id", admin, r.MusicCotroller.DeleteSongByID)
+	r.Gin.GET("/songs/:id/text", reader, r.MusicCotroller.GetSongTextByID)
+	r.Gin.GET("/songs/:id/verses", reader, r.MusicCotroller.GetSongVerses)
+	r.Gin.PUT("/songs/:id/verses", editor, r.MusicCotroller.SetSongVerses)
+	r.Gin.GET("/songs/:id/verses/:n", reader, r.MusicCotroller.GetVerse)
+	r.Gin.GET("/songs/:id/lyrics.lrc", reader, r.MusicCotroller.GetLyricsLRC)
+	r.Gin.PUT("/songs/:id/lyrics.lrc", editor, r.MusicCotroller.UploadLRC)
+	r.Gin.GET("/songs/:id/lyrics.srt", reader, r.MusicCotroller.GetLyricsSRT)
+	r.Gin.GET("/songs/:id/lyrics.vtt", reader, r.MusicCotroller.GetLyricsVTT)
+	r.Gin.GET("/songs/:id/revisions", reader, r.MusicCotroller.GetSongRevisions)
+	r.Gin.GET("/songs/:id/revisions/:rev", reader, r.MusicCotroller.GetSongRevision)
+	r.Gin.GET("/songs/:id/revisions/:rev/diff", reader, r.MusicCotroller.DiffSongRevisions)
+	r.Gin.POST("/songs/:id/revisions/:rev/revert", editor, r.MusicCotroller.RevertSong)
+
+	r.Gin.GET("/trash", adminRead, r.MusicCotroller.GetDeletedSongs)
+	r.Gin.POST("/trash/:id/restore", admin, r.MusicCotroller.RestoreSong)
+	r.Gin.DELETE("/trash/:id", admin, r.MusicCotroller.PurgeSong)
+
+	r.Gin.POST("/groups", editor, r.MusicCotroller.CreateGroup)
+	r.Gin.GET("/groups", reader, r.MusicCotroller.GetGroups)
+	r.Gin.GET("/groups/:id", reader, r.MusicCotroller.GetGroupByID)
+	r.Gin.PATCH("/groups/:id", editor, r.MusicCotroller.UpdateGroup)
+	r.Gin.DELETE("/groups/:id", admin, r.MusicCotroller.DeleteGroup)
+	r.Gin.GET("/groups/:id/songs", reader, r.MusicCotroller.GetGroupSongs)
+
+	r.Gin.POST("/albums", editor, r.MusicCotroller.CreateAlbum)
+	r.Gin.GET("/albums", reader, r.MusicCotroller.GetAlbums)
+	r.Gin.GET("/albums/:id", reader, r.MusicCotroller.GetAlbumByID)
+	r.Gin.PATCH("/albums/:id", editor, r.MusicCotroller.UpdateAlbum)
+	r.Gin.DELETE("/albums/:id", admin, r.MusicCotroller.DeleteAlbum)
+	r.Gin.GET("/albums/:id/tracks", reader, r.MusicCotroller.GetAlbumTracks)
+	r.Gin.PUT("/albums/:id/tracks", editor, r.MusicCotroller.SetAlbumTracks)
+
+	r.Gin.GET("/search", reader, r.MusicCotroller.SearchLyrics)
+
+	r.Gin.POST("/import", upstream, r.MusicCotroller.ImportSongs)
+	r.Gin.GET("/export", reader, r.MusicCotroller.ExportSongs)
+
+	r.Gin.GET("/jobs/:id", reader, r.MusicCotroller.GetEnrichmentJob)
+
+	r.Gin.GET("/audit", adminRead, r.MusicCotroller.GetAuditLog)
+
+	// Проверки живости и готовности выполняет оркестратор, а метрики собирает Prometheus,
+	// поэтому они доступны без аутентификации и ограничения частоты
+	r.Gin.GET("/healthz", r.health.LiveHandler)
+	r.Gin.GET("/readyz", r.health.ReadyHandler)
+	if r.metrics != nil {
+		r.Gin.GET("/metrics", gin.WrapH(r.metrics.Handler()))
+	}
 
 	if envType == "debug" {
 		r.Gin.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -0,0 +1,229 @@
+package router_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mikromolekula2002/music_library_ver1.0/internal/auth"
+	"mikromolekula2002/music_library_ver1.0/internal/health"
+	"mikromolekula2002/music_library_ver1.0/internal/metrics"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/ratelimit"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
+	"mikromolekula2002/music_library_ver1.0/internal/router"
+	"mikromolekula2002/music_library_ver1.0/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	readerKey = "reader-key"
+	editorKey = "editor-key"
+	adminKey  = "admin-key"
+
+	jwtSecret = "test-secret"
+)
+
+// detailsFixtures - данные песен для источника file, которым в тестах заменяется music-info
+const detailsFixtures = `[
+	{"group": "Muse", "song": "Supermassive Black Hole", "releaseDate": "16.07.2006",
+	 "text": "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\n\n[Chorus]\nOoh\nYou set my soul alight",
+	 "link": "https://www.youtube.com/watch?v=Xsp3_a-PMTw"},
+	{"group": "Muse", "song": "Uprising", "releaseDate": "2009-09-07", "text": "Paranoia is in bloom", "link": "https://example.com/uprising"},
+	{"group": "Muse", "song": "Hysteria", "releaseDate": "2003-12-01", "text": "It's bugging me", "link": "https://example.com/hysteria"},
+	{"group": "Muse", "song": "Starlight", "releaseDate": "2006-09-04", "text": "Far away", "link": "https://example.com/starlight"},
+	{"group": "Muse", "song": "Madness", "releaseDate": "2012-08-20", "text": "I, I can't get these memories out of my mind", "link": "https://example.com/madness"}
+]`
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+type apiOptions struct {
+	limits  map[ratelimit.Class]ratelimit.Limit
+	metrics *metrics.Metrics
+}
+
+// testAPI - весь HTTP-стек сервиса поверх хранилища в памяти
+type testAPI struct {
+	t       *testing.T
+	handler http.Handler
+	checker *health.Checker
+}
+
+func newTestAPI(t *testing.T, opts apiOptions) *testAPI {
+	t.Helper()
+
+	detailsFile := filepath.Join(t.TempDir(), "details.json")
+	if err := os.WriteFile(detailsFile, []byte(detailsFixtures), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	svc, err := service.NewSongService(repository.NewMemoryStore(), logger, service.Options{
+		DetailsProviders: []string{service.ProviderFile},
+		DetailsFile:      detailsFile,
+		Enrichment: service.EnrichmentOptions{
+			PollInterval: 10 * time.Millisecond,
+			RetryBase:    10 * time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := auth.ParseAPIKeys("rita:reader:" + readerKey + ",ed:editor:" + editorKey + ",root:admin:" + adminKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator, err := auth.NewAuthenticator(auth.Options{APIKeys: keys, HMACSecret: []byte(jwtSecret)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var limiter *ratelimit.Limiter
+	if opts.limits != nil {
+		limiter = ratelimit.NewLimiter(ratelimit.NewMemoryBackend(), opts.limits)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := svc.RunEnrichmentWorkers(ctx)
+	t.Cleanup(func() {
+		cancel()
+		wait()
+	})
+
+	checker := health.NewChecker(0)
+	r := router.NewRouter(svc, authenticator, limiter, opts.metrics, checker)
+	r.SetRoutes("release")
+
+	return &testAPI{t: t, handler: r.Gin, checker: checker}
+}
+
+// do выполняет запрос с ключом key; body сериализуется в JSON, если это не строка
+func (a *testAPI) do(method, path, key string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	a.t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			a.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.RemoteAddr = "192.0.2.1:1234"
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	a.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect проверяет статус ответа и разбирает его тело в out, если out не nil
+func (a *testAPI) expect(rec *httptest.ResponseRecorder, status int, out interface{}) {
+	a.t.Helper()
+
+	if rec.Code != status {
+		a.t.Fatalf("status %d, want %d: %s", rec.Code, status, rec.Body.String())
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			a.t.Fatalf("decode %s: %v", rec.Body.String(), err)
+		}
+	}
+}
+
+// createSong создает песню и дожидается завершения задачи обогащения
+func (a *testAPI) createSong(group, song, lrc string) models.Song {
+	a.t.Helper()
+
+	var created models.CreateSongResp
+	a.expect(a.do(http.MethodPost, "/create-song", editorKey, models.CreateSongReq{Group: group, Song: song, LRC: lrc}), http.StatusAccepted, &created)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var job models.EnrichmentJob
+		a.expect(a.do(http.MethodGet, fmt.Sprintf("/jobs/%d", created.JobID), readerKey, nil), http.StatusOK, &job)
+		if job.Status == models.JobDone || job.Status == models.JobFailed {
+			break
+		}
+		if time.Now().After(deadline) {
+			a.t.Fatalf("enrichment job %d is still %s", job.ID, job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var stored models.Song
+	a.expect(a.do(http.MethodGet, fmt.Sprintf("/songs/%d", created.ID), readerKey, nil), http.StatusOK, &stored)
+	return stored
+}
+
+func TestLegacySongRoutes(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	api.expect(api.do(http.MethodGet, "/songs", readerKey, nil), http.StatusNotFound, nil)
+
+	song := api.createSong("Muse", "Supermassive Black Hole", "")
+	if song.EnrichmentStatus != models.EnrichmentDone || song.ReleaseDate != "2006-07-16" {
+		t.Fatalf("song was not enriched: %+v", song)
+	}
+	api.expect(api.do(http.MethodPost, "/create-song", editorKey, models.CreateSongReq{Group: "Muse", Song: song.Song}), http.StatusConflict, nil)
+	api.expect(api.do(http.MethodPost, "/create-song", editorKey, "{"), http.StatusBadRequest, nil)
+
+	query := "group=Muse&song=" + url.QueryEscape(song.Song)
+	var text []string
+	api.expect(api.do(http.MethodGet, "/song?"+query, readerKey, nil), http.StatusOK, &text)
+	if len(text) != 2 || text[1] != "Ooh\nYou set my soul alight" {
+		t.Fatalf("text: %q", text)
+	}
+	api.expect(api.do(http.MethodGet, "/song?group=Muse", readerKey, nil), http.StatusBadRequest, nil)
+
+	var songs []models.Song
+	api.expect(api.do(http.MethodGet, "/songs?group=Muse", readerKey, nil), http.StatusOK, &songs)
+	if len(songs) != 1 || songs[0].ID != song.ID {
+		t.Fatalf("songs: %+v", songs)
+	}
+
+	update := models.Song{Group: "Muse", Song: song.Song, Link: "https://example.com/smbh"}
+	api.expect(api.do(http.MethodPut, "/song", editorKey, update), http.StatusOK, nil)
+	var updated models.Song
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/songs/%d", song.ID), readerKey, nil), http.StatusOK, &updated)
+	if updated.Link != update.Link || updated.ReleaseDate != song.ReleaseDate {
+		t.Fatalf("PUT /song changed the wrong fields: %+v", updated)
+	}
+	update.Song = "Resistance"
+	api.expect(api.do(http.MethodPut, "/song", editorKey, update), http.StatusNotFound, nil)
+
+	api.expect(api.do(http.MethodDelete, "/song?"+query, adminKey, nil), http.StatusOK, nil)
+	api.expect(api.do(http.MethodDelete, "/song?"+query, adminKey, nil), http.StatusNotFound, nil)
+	api.expect(api.do(http.MethodGet, "/songs", readerKey, nil), http.StatusNotFound, nil)
+}
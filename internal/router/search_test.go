@@ -0,0 +1,35 @@
+package router_test
+
+import (
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestSearchRoute(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	song := api.createSong("Muse", "Uprising", "")
+	text := "<script>alert(1)</script> they will not force us"
+	api.expect(api.do(http.MethodPatch, fmt.Sprintf("/songs/%d", song.ID), editorKey, models.SongPatch{Text: &text}), http.StatusOK, nil)
+
+	var hits []models.SearchHit
+	api.expect(api.do(http.MethodGet, "/search?q=force&lang=en&mode=plain", readerKey, nil), http.StatusOK, &hits)
+	if len(hits) != 1 || hits[0].SongID != song.ID || hits[0].Verse != 1 {
+		t.Fatalf("hits: %+v", hits)
+	}
+	want := "&lt;script&gt;alert(1)&lt;/script&gt; they will not <b>force</b> us"
+	if hits[0].Highlight != want {
+		t.Fatalf("highlight %q, want %q", hits[0].Highlight, want)
+	}
+
+	api.expect(api.do(http.MethodGet, "/search?q=paranoia", readerKey, nil), http.StatusOK, &hits)
+	if len(hits) != 0 {
+		t.Fatalf("hits for replaced text: %+v", hits)
+	}
+
+	api.expect(api.do(http.MethodGet, "/search", readerKey, nil), http.StatusBadRequest, nil)
+	api.expect(api.do(http.MethodGet, "/search?q=force&lang=de", readerKey, nil), http.StatusBadRequest, nil)
+	api.expect(api.do(http.MethodGet, "/search?q=force&mode=regex", readerKey, nil), http.StatusBadRequest, nil)
+}
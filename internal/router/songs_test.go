@@ -0,0 +1,79 @@
+package router_test
+
+import (
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestSongByIDRoutes(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	song := api.createSong("Muse", "Supermassive Black Hole", "")
+	other := api.createSong("Muse", "Uprising", "")
+	path := fmt.Sprintf("/songs/%d", song.ID)
+
+	var text models.SongTextResp
+	api.expect(api.do(http.MethodGet, path+"/text?by=line&limit=2&offset=1", readerKey, nil), http.StatusOK, &text)
+	if len(text.Text) != 2 || text.Text[0] != "Ooh baby, can you hear me moan?" {
+		t.Fatalf("lines: %q", text.Text)
+	}
+
+	var patched models.Song
+	link := "https://example.com/smbh"
+	api.expect(api.do(http.MethodPatch, path, editorKey, models.SongPatch{Link: &link}), http.StatusOK, &patched)
+	if patched.Link != link || patched.ReleaseDate != song.ReleaseDate || patched.Text != song.Text {
+		t.Fatalf("PATCH changed the wrong fields: %+v", patched)
+	}
+
+	name := other.Song
+	api.expect(api.do(http.MethodPatch, path, editorKey, models.SongPatch{Song: &name}), http.StatusConflict, nil)
+	empty := ""
+	api.expect(api.do(http.MethodPatch, path, editorKey, models.SongPatch{Song: &empty}), http.StatusBadRequest, nil)
+	date := "16.07.2006"
+	api.expect(api.do(http.MethodPatch, path, editorKey, models.SongPatch{ReleaseDate: &date}), http.StatusBadRequest, nil)
+
+	group := "Queen"
+	api.expect(api.do(http.MethodPatch, path, editorKey, models.SongPatch{Group: &group}), http.StatusOK, &patched)
+	if patched.Group != group || patched.GroupID == song.GroupID {
+		t.Fatalf("song was not moved to a new group: %+v", patched)
+	}
+
+	var replaced models.Song
+	replacement := models.Song{Group: "Muse", Song: "Starlight", ReleaseDate: "2006-09-04", Text: "Far away\n\nMy life"}
+	api.expect(api.do(http.MethodPut, path, editorKey, replacement), http.StatusOK, &replaced)
+	if replaced.Song != "Starlight" || replaced.GroupID != song.GroupID || replaced.Link != "" || replaced.Text != "Far away\n\nMy life" {
+		t.Fatalf("PUT did not replace the song: %+v", replaced)
+	}
+
+	api.expect(api.do(http.MethodGet, "/songs/abc", readerKey, nil), http.StatusBadRequest, nil)
+	api.expect(api.do(http.MethodGet, "/songs/999", readerKey, nil), http.StatusNotFound, nil)
+	api.expect(api.do(http.MethodPatch, "/songs/999", editorKey, models.SongPatch{Link: &link}), http.StatusNotFound, nil)
+
+	api.expect(api.do(http.MethodDelete, path, adminKey, nil), http.StatusOK, nil)
+	api.expect(api.do(http.MethodGet, path, readerKey, nil), http.StatusNotFound, nil)
+	api.expect(api.do(http.MethodDelete, path, adminKey, nil), http.StatusNotFound, nil)
+}
+
+func TestSongFilterMatchModes(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	api.createSong("Muse", "Uprising", "")
+	api.createSong("Muse", "Hysteria", "")
+
+	var songs []models.Song
+	api.expect(api.do(http.MethodGet, "/songs?song=RISING&songMatch=contains", readerKey, nil), http.StatusOK, &songs)
+	if len(songs) != 1 || songs[0].Song != "Uprising" {
+		t.Fatalf("contains: %+v", songs)
+	}
+	api.expect(api.do(http.MethodGet, "/songs?group=mu&groupMatch=prefix&song=Histeria&songMatch=fuzzy", readerKey, nil), http.StatusOK, &songs)
+	if len(songs) != 1 || songs[0].Song != "Hysteria" {
+		t.Fatalf("fuzzy: %+v", songs)
+	}
+	api.expect(api.do(http.MethodGet, "/songs?song=rising", readerKey, nil), http.StatusNotFound, nil)
+
+	for _, query := range []string{"songMatch=regex", "groupMatch=FUZZY", "similarity=0", "similarity=1.5", "similarity=abc", "group_id=-1", "album_id=x"} {
+		api.expect(api.do(http.MethodGet, "/songs?"+query, readerKey, nil), http.StatusBadRequest, nil)
+	}
+}
@@ -0,0 +1,38 @@
+package router_test
+
+import (
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestSongsSortParam(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+	api.createSong("Muse", "Uprising", "")
+	api.createSong("Muse", "Hysteria", "")
+	api.createSong("Muse", "Starlight", "")
+
+	for _, tc := range []struct {
+		sort string
+		want []string
+	}{
+		{"song", []string{"Hysteria", "Starlight", "Uprising"}},
+		{"-release_date", []string{"Uprising", "Starlight", "Hysteria"}},
+		{"release_date", []string{"Hysteria", "Starlight", "Uprising"}},
+	} {
+		var songs []models.Song
+		api.expect(api.do(http.MethodGet, "/songs?sort="+tc.sort, readerKey, nil), http.StatusOK, &songs)
+		var got []string
+		for _, s := range songs {
+			got = append(got, s.Song)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("sort=%s: %v, want %v", tc.sort, got, tc.want)
+		}
+	}
+
+	for _, sort := range []string{"title", "song,-song"} {
+		api.expect(api.do(http.MethodGet, "/songs?sort="+sort, readerKey, nil), http.StatusBadRequest, nil)
+	}
+}
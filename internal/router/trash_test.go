@@ -0,0 +1,35 @@
+package router_test
+
+import (
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestTrash(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	song := api.createSong("Muse", "Hysteria", "")
+	path := fmt.Sprintf("/songs/%d", song.ID)
+
+	api.expect(api.do(http.MethodDelete, path, adminKey, nil), http.StatusOK, nil)
+	api.expect(api.do(http.MethodGet, path, readerKey, nil), http.StatusNotFound, nil)
+
+	var trash []models.Song
+	api.expect(api.do(http.MethodGet, "/trash", adminKey, nil), http.StatusOK, &trash)
+	if len(trash) != 1 || trash[0].ID != song.ID {
+		t.Fatalf("trash: %+v", trash)
+	}
+	api.expect(api.do(http.MethodGet, "/trash", readerKey, nil), http.StatusForbidden, nil)
+	api.expect(api.do(http.MethodPost, fmt.Sprintf("/trash/%d/restore", song.ID), editorKey, nil), http.StatusForbidden, nil)
+
+	api.expect(api.do(http.MethodPost, fmt.Sprintf("/trash/%d/restore", song.ID), adminKey, nil), http.StatusOK, nil)
+	api.expect(api.do(http.MethodGet, path, readerKey, nil), http.StatusOK, nil)
+	api.expect(api.do(http.MethodDelete, fmt.Sprintf("/trash/%d", song.ID), adminKey, nil), http.StatusNotFound, nil)
+
+	api.expect(api.do(http.MethodDelete, path, adminKey, nil), http.StatusOK, nil)
+	api.expect(api.do(http.MethodDelete, fmt.Sprintf("/trash/%d", song.ID), adminKey, nil), http.StatusOK, nil)
+	api.expect(api.do(http.MethodPost, fmt.Sprintf("/trash/%d/restore", song.ID), adminKey, nil), http.StatusNotFound, nil)
+	api.expect(api.do(http.MethodPost, "/trash/x/restore", adminKey, nil), http.StatusBadRequest, nil)
+}
@@ -0,0 +1,52 @@
+package router_test
+
+import (
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestVerses(t *testing.T) {
+	api := newTestAPI(t, apiOptions{})
+
+	song := api.createSong("Muse", "Starlight", "")
+	path := fmt.Sprintf("/songs/%d/verses", song.ID)
+
+	req := models.SongVersesReq{Verses: []models.Verse{
+		{Type: models.VerseTypeVerse, Text: "Far away\nThe ship is taking me far away"},
+		{Type: models.VerseTypeChorus, Text: "My life\nYou electrify my life"},
+		{RepeatOf: 2},
+	}}
+	api.expect(api.do(http.MethodPut, path, readerKey, req), http.StatusForbidden, nil)
+	api.expect(api.do(http.MethodPut, path, editorKey, req), http.StatusOK, nil)
+
+	var verses models.SongVersesResp
+	api.expect(api.do(http.MethodGet, path, readerKey, nil), http.StatusOK, &verses)
+	if len(verses.Verses) != 3 || verses.Verses[2].RepeatOf != 2 {
+		t.Fatalf("verses: %+v", verses.Verses)
+	}
+
+	var verse models.VerseResp
+	api.expect(api.do(http.MethodGet, path+"/3", readerKey, nil), http.StatusOK, &verse)
+	if verse.Text != "My life\nYou electrify my life" || verse.Type != models.VerseTypeChorus {
+		t.Fatalf("repeated verse: %+v", verse)
+	}
+	api.expect(api.do(http.MethodGet, path+"/4", readerKey, nil), http.StatusNotFound, nil)
+
+	// Тексты по куплетам берутся из тех же куплетов
+	var text models.SongTextResp
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/songs/%d/text?limit=1&offset=2", song.ID), readerKey, nil), http.StatusOK, &text)
+	if len(text.Text) != 1 || text.Text[0] != "My life\nYou electrify my life" {
+		t.Fatalf("text: %q", text.Text)
+	}
+
+	for _, bad := range []models.SongVersesReq{
+		{Verses: []models.Verse{{Type: "solo", Text: "x"}}},
+		{Verses: []models.Verse{{RepeatOf: 1}}},
+		{Verses: []models.Verse{{Text: "a\nb", LineTimes: []int64{1000}}}},
+	} {
+		api.expect(api.do(http.MethodPut, path, editorKey, bad), http.StatusBadRequest, nil)
+	}
+	api.expect(api.do(http.MethodPut, "/songs/999/verses", editorKey, req), http.StatusNotFound, nil)
+}
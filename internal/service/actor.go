@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+)
+
+// Авторы изменений, которые вносит сам сервис
+const (
+	ActorAnonymous  = "anonymous"
+	ActorEnrichment = "enrichment"
+	ActorImport     = "import"
+	ActorRetention  = "retention"
+)
+
+type (
+	actorKey     struct{}
+	requestIDKey struct{}
+	sourceKey    struct{}
+)
+
+// WithActor возвращает контекст с автором изменений, которые будут сделаны в его рамках
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext возвращает автора изменений из контекста или ActorAnonymous
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return ActorAnonymous
+}
+
+// WithRequestID возвращает контекст с идентификатором запроса, в рамках которого идут изменения
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext возвращает идентификатор запроса из контекста или пустую строку
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// WithSource возвращает контекст с источником изменений (models.AuditSource*)
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// changeMeta собирает из контекста автора, запрос и источник изменения; по умолчанию источник - API
+func changeMeta(ctx context.Context) models.ChangeMeta {
+	source, ok := ctx.Value(sourceKey{}).(string)
+	if !ok || source == "" {
+		source = models.AuditSourceAPI
+	}
+	return models.ChangeMeta{
+		Actor:     ActorFromContext(ctx),
+		RequestID: RequestIDFromContext(ctx),
+		Source:    source,
+	}
+}
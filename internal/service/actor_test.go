@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"testing"
+)
+
+func TestChangeMeta(t *testing.T) {
+	if meta := changeMeta(context.Background()); meta != (models.ChangeMeta{Actor: ActorAnonymous, Source: models.AuditSourceAPI}) {
+		t.Fatalf("meta of an empty context: %+v", meta)
+	}
+
+	ctx := WithActor(context.Background(), "ed")
+	ctx = WithRequestID(ctx, "req-1")
+	ctx = WithSource(ctx, models.AuditSourceImport)
+	if meta := changeMeta(ctx); meta != (models.ChangeMeta{Actor: "ed", RequestID: "req-1", Source: models.AuditSourceImport}) {
+		t.Fatalf("meta: %+v", meta)
+	}
+
+	// Пустые значения заменяются значениями по умолчанию
+	ctx = WithSource(WithActor(context.Background(), ""), "")
+	if meta := changeMeta(ctx); meta.Actor != ActorAnonymous || meta.Source != models.AuditSourceAPI {
+		t.Fatalf("meta with empty values: %+v", meta)
+	}
+}
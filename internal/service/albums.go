@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultAlbumType = "LP"
+
+func (s *MusicLibService) CreateAlbum(ctx context.Context, album *models.Album) error {
+	s.log(ctx).WithFields(logrus.Fields{"group_id": album.GroupID, "title": album.Title}).Debug("Creating album")
+
+	if album.Type == "" {
+		album.Type = defaultAlbumType
+	}
+
+	id, err := s.repo.CreateAlbum(album)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	album.ID = id
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Album created")
+
+	return nil
+}
+
+func (s *MusicLibService) GetAlbums(ctx context.Context, filter map[string]string, limit, offset int) ([]models.Album, error) {
+	s.log(ctx).WithFields(logrus.Fields{"limit": limit, "offset": offset, "filters": filter}).Debug("Fetching albums")
+
+	albums, err := s.repo.GetAlbums(filter, limit, offset)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	return albums, nil
+}
+
+func (s *MusicLibService) GetAlbumByID(ctx context.Context, id uint) (*models.Album, error) {
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Fetching album")
+
+	album, err := s.repo.GetAlbumByID(id)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	return album, nil
+}
+
+func (s *MusicLibService) UpdateAlbum(ctx context.Context, id uint, patch *models.AlbumPatch) error {
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Updating album")
+
+	if err := s.repo.UpdateAlbum(id, patch); err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Album updated")
+
+	return nil
+}
+
+func (s *MusicLibService) DeleteAlbum(ctx context.Context, id uint) error {
+	if err := s.repo.DeleteAlbum(id); err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Album deleted")
+
+	return nil
+}
+
+func (s *MusicLibService) GetAlbumTracks(ctx context.Context, id uint) ([]models.AlbumTrack, error) {
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Fetching album tracks")
+
+	tracks, err := s.repo.GetAlbumTracks(id)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	return tracks, nil
+}
+
+func (s *MusicLibService) SetAlbumTracks(ctx context.Context, id uint, songIDs []uint) error {
+	s.log(ctx).WithFields(logrus.Fields{"id": id, "tracks": len(songIDs)}).Debug("Setting album tracks")
+
+	if err := s.repo.SetAlbumTracks(id, songIDs); err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	return nil
+}
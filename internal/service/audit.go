@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GetAuditLog возвращает записи журнала аудита по фильтру, сначала новые
+func (s *MusicLibService) GetAuditLog(ctx context.Context, filter models.AuditFilter, limit, offset int) ([]models.AuditEntry, error) {
+	s.log(ctx).WithFields(logrus.Fields{
+		"song_id": filter.SongID, "actor": filter.Actor, "from": filter.From, "to": filter.To, "limit": limit, "offset": offset,
+	}).Debug("Fetching audit log")
+
+	entries, err := s.repo.GetAuditLog(filter, limit, offset)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/pkg/cache"
+	"mikromolekula2002/music_library_ver1.0/pkg/logger"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DetailsCacheOptions - настройки кэша данных песен из внешних источников
+type DetailsCacheOptions struct {
+	// Size - максимальное число песен в кэше; отрицательное значение отключает кэш
+	Size int
+	// TTL - время жизни найденных песен
+	TTL time.Duration
+	// NegativeTTL - время жизни ответа "песня не найдена" (404)
+	NegativeTTL time.Duration
+}
+
+const (
+	defaultDetailsCacheSize        = 1000
+	defaultDetailsCacheTTL         = 10 * time.Minute
+	defaultDetailsCacheNegativeTTL = time.Minute
+)
+
+func (o DetailsCacheOptions) withDefaults() DetailsCacheOptions {
+	if o.Size == 0 {
+		o.Size = defaultDetailsCacheSize
+	}
+	if o.TTL <= 0 {
+		o.TTL = defaultDetailsCacheTTL
+	}
+	if o.NegativeTTL <= 0 {
+		o.NegativeTTL = defaultDetailsCacheNegativeTTL
+	}
+	return o
+}
+
+// detailsCache хранит ответы цепочки источников и объединяет одновременные запросы одной
+// и той же песни. Найденные песни хранятся по нормализованной паре группа+песня, а ответы
+// "не найдена" - по названиям в том виде, в каком их получил источник: источник может
+// различать регистр, и 404 для одного написания ничего не говорит о другом.
+type detailsCache struct {
+	opts    DetailsCacheOptions
+	entries *cache.LRU[detailsCacheKey, detailsEntry]
+	flight  cache.Group[string, detailsEntry]
+	lookup  func(ctx context.Context, group, song string) (*models.SongDetails, error)
+	logger  *logrus.Logger
+}
+
+// detailsCacheKey - ключ записи кэша: нормализованный для найденных песен, точный для 404
+type detailsCacheKey struct {
+	key      string
+	notFound bool
+}
+
+// detailsEntry - данные песни или ErrDetailsNotFound
+type detailsEntry struct {
+	details models.SongDetails
+	err     error
+}
+
+func newDetailsCache(opts DetailsCacheOptions, logger *logrus.Logger, lookup func(ctx context.Context, group, song string) (*models.SongDetails, error)) *detailsCache {
+	opts = opts.withDefaults()
+	if opts.Size < 0 {
+		return nil
+	}
+
+	return &detailsCache{
+		opts:    opts,
+		entries: cache.NewLRU[detailsCacheKey, detailsEntry](opts.Size),
+		lookup:  lookup,
+		logger:  logger,
+	}
+}
+
+// detailsKey нормализует название группы и песни: регистр и пробелы по краям не важны
+func detailsKey(group, song string) string {
+	return strings.ToLower(strings.TrimSpace(group)) + "\x00" + strings.ToLower(strings.TrimSpace(song))
+}
+
+func (c *detailsCache) get(ctx context.Context, group, song string) (*models.SongDetails, error) {
+	group, song = strings.TrimSpace(group), strings.TrimSpace(song)
+	found := detailsCacheKey{key: detailsKey(group, song)}
+	notFound := detailsCacheKey{key: group + "\x00" + song, notFound: true}
+
+	entry, ok := c.entries.Get(found)
+	if !ok {
+		entry, ok = c.entries.Get(notFound)
+	}
+	if ok {
+		logger.FromContext(ctx, c.logger).WithFields(logrus.Fields{"group": group, "song": song}).Debug("Song details cache hit")
+	} else {
+		var err error
+		var shared bool
+		// Объединяются только запросы с одинаковым написанием: источник получает ровно те названия,
+		// под которыми будет сохранен его ответ 404
+		entry, err, shared = c.flight.Do(ctx, notFound.key, func() (detailsEntry, error) {
+			// Запрос обслуживает всех ожидающих, поэтому не отменяется вместе с контекстом первого из них
+			details, err := c.lookup(context.WithoutCancel(ctx), group, song)
+			switch {
+			case err == nil:
+				entry := detailsEntry{details: *details}
+				c.entries.Set(found, entry, c.opts.TTL)
+				return entry, nil
+			case errors.Is(err, ErrDetailsNotFound):
+				entry := detailsEntry{err: err}
+				c.entries.Set(notFound, entry, c.opts.NegativeTTL)
+				return entry, nil
+			default:
+				return detailsEntry{}, err
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		if shared {
+			logger.FromContext(ctx, c.logger).WithFields(logrus.Fields{"group": group, "song": song}).Debug("Song details request coalesced")
+		}
+	}
+
+	if entry.err != nil {
+		return nil, entry.err
+	}
+
+	details := entry.details
+	return &details, nil
+}
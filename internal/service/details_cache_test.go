@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// recordingLookup - источник данных для кэша, который запоминает свои вызовы
+type recordingLookup struct {
+	mu      sync.Mutex
+	calls   []string
+	details func(group, song string) (*models.SongDetails, error)
+}
+
+func (l *recordingLookup) lookup(_ context.Context, group, song string) (*models.SongDetails, error) {
+	l.mu.Lock()
+	l.calls = append(l.calls, group+"/"+song)
+	l.mu.Unlock()
+	return l.details(group, song)
+}
+
+func (l *recordingLookup) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.calls)
+}
+
+func newTestDetailsCache(opts DetailsCacheOptions, l *recordingLookup) *detailsCache {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return newDetailsCache(opts, logger, l.lookup)
+}
+
+// caseSensitiveDetails знает песню только в написании Muse/Uprising
+func caseSensitiveDetails(group, song string) (*models.SongDetails, error) {
+	if group == "Muse" && song == "Uprising" {
+		return &models.SongDetails{ReleaseDate: "2009-09-07", Link: "https://example.com/uprising"}, nil
+	}
+	return nil, ErrDetailsNotFound
+}
+
+func TestDetailsCacheNotFoundIsPerSpelling(t *testing.T) {
+	l := &recordingLookup{details: caseSensitiveDetails}
+	c := newTestDetailsCache(DetailsCacheOptions{}, l)
+	ctx := context.Background()
+
+	if _, err := c.get(ctx, "muse", "uprising"); !errors.Is(err, ErrDetailsNotFound) {
+		t.Fatalf("lowercase lookup: %v", err)
+	}
+	if _, err := c.get(ctx, "muse", " uprising "); !errors.Is(err, ErrDetailsNotFound) {
+		t.Fatalf("cached 404: %v", err)
+	}
+	if l.count() != 1 {
+		t.Fatalf("404 for the same spelling was not cached: %v", l.calls)
+	}
+
+	// 404 для одного написания не мешает найти песню в другом
+	details, err := c.get(ctx, " Muse", "Uprising")
+	if err != nil || details.ReleaseDate != "2009-09-07" {
+		t.Fatalf("canonical lookup: %+v, %v", details, err)
+	}
+	if l.count() != 2 || l.calls[1] != "Muse/Uprising" {
+		t.Fatalf("upstream calls: %q", l.calls)
+	}
+
+	// Найденная песня отдается для любого написания
+	for _, spelling := range [][2]string{{"MUSE", "UPRISING"}, {"muse", "uprising"}} {
+		if details, err := c.get(ctx, spelling[0], spelling[1]); err != nil || details.Link != "https://example.com/uprising" {
+			t.Fatalf("%v: %+v, %v", spelling, details, err)
+		}
+	}
+	if l.count() != 2 {
+		t.Fatalf("cached song was requested again: %q", l.calls)
+	}
+}
+
+func TestDetailsCacheTTL(t *testing.T) {
+	l := &recordingLookup{details: caseSensitiveDetails}
+	c := newTestDetailsCache(DetailsCacheOptions{TTL: 20 * time.Millisecond, NegativeTTL: 20 * time.Millisecond}, l)
+	ctx := context.Background()
+
+	c.get(ctx, "Muse", "Uprising")
+	c.get(ctx, "Muse", "Resistance")
+	c.get(ctx, "Muse", "Uprising")
+	c.get(ctx, "Muse", "Resistance")
+	if l.count() != 2 {
+		t.Fatalf("upstream calls: %q", l.calls)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	c.get(ctx, "Muse", "Uprising")
+	c.get(ctx, "Muse", "Resistance")
+	if l.count() != 4 {
+		t.Fatalf("expired entries were not refreshed: %q", l.calls)
+	}
+}
+
+func TestDetailsCacheSkipsErrors(t *testing.T) {
+	unavailable := errors.New("music info is unavailable")
+	l := &recordingLookup{details: func(string, string) (*models.SongDetails, error) { return nil, unavailable }}
+	c := newTestDetailsCache(DetailsCacheOptions{}, l)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.get(context.Background(), "Muse", "Uprising"); !errors.Is(err, unavailable) {
+			t.Fatalf("get: %v", err)
+		}
+	}
+	if l.count() != 2 {
+		t.Fatalf("temporary error was cached: %q", l.calls)
+	}
+}
+
+func TestDetailsCacheCoalescesRequests(t *testing.T) {
+	release := make(chan struct{})
+	l := &recordingLookup{details: func(group, song string) (*models.SongDetails, error) {
+		<-release
+		return caseSensitiveDetails(group, song)
+	}}
+	c := newTestDetailsCache(DetailsCacheOptions{}, l)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.get(context.Background(), "Muse", "Uprising"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if l.count() != 1 {
+		t.Fatalf("concurrent requests were not coalesced: %q", l.calls)
+	}
+}
+
+func TestDetailsCacheDisabled(t *testing.T) {
+	if c := newTestDetailsCache(DetailsCacheOptions{Size: -1}, &recordingLookup{}); c != nil {
+		t.Fatal("negative size should disable the cache")
+	}
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/openapi"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
+	"mikromolekula2002/music_library_ver1.0/pkg/logger"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EnrichmentOptions - настройки фоновых задач обогащения песен
+type EnrichmentOptions struct {
+	// Workers - число одновременно обрабатываемых задач
+	Workers int
+	// MaxAttempts - число попыток, после которого задача завершается с ошибкой
+	MaxAttempts int
+	// RetryBase - пауза перед первым повтором, каждая следующая вдвое больше, но не больше RetryMax
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// PollInterval - как часто свободный обработчик проверяет очередь
+	PollInterval time.Duration
+	// Lease - время, на которое задача закрепляется за обработчиком
+	Lease time.Duration
+}
+
+const (
+	defaultEnrichmentWorkers      = 2
+	defaultEnrichmentMaxAttempts  = 5
+	defaultEnrichmentRetryBase    = 5 * time.Second
+	defaultEnrichmentRetryMax     = 10 * time.Minute
+	defaultEnrichmentPollInterval = time.Second
+	defaultEnrichmentLease        = 2 * time.Minute
+
+	// enrichmentCallTimeout ограничивает одну попытку, чтобы она укладывалась в аренду задачи
+	enrichmentCallTimeout = 30 * time.Second
+)
+
+func (o EnrichmentOptions) withDefaults() EnrichmentOptions {
+	if o.Workers <= 0 {
+		o.Workers = defaultEnrichmentWorkers
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = defaultEnrichmentMaxAttempts
+	}
+	if o.RetryBase <= 0 {
+		o.RetryBase = defaultEnrichmentRetryBase
+	}
+	if o.RetryMax < o.RetryBase {
+		o.RetryMax = defaultEnrichmentRetryMax
+		if o.RetryMax < o.RetryBase {
+			o.RetryMax = o.RetryBase
+		}
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = defaultEnrichmentPollInterval
+	}
+	if o.Lease <= enrichmentCallTimeout {
+		o.Lease = defaultEnrichmentLease
+	}
+	return o
+}
+
+var (
+	// errPermanent помечает ошибки, при которых повторять задачу бессмысленно
+	errPermanent = errors.New("permanent enrichment error")
+	// errSongTrashed - песня в корзине: задача ждет ее восстановления, история не пишется.
+	// Если песня удалена окончательно, задача удалена вместе с ней.
+	errSongTrashed = errors.New("song is in the trash")
+)
+
+// CreateSong сохраняет песню в статусе pending и ставит в очередь задачу загрузки
+// ее данных из внешнего API. Если передан lrc, текст с временными метками сохраняется
+// сразу и задача его не перезаписывает. Возвращает id песни и id задачи.
+func (s *MusicLibService) CreateSong(ctx context.Context, group, song, lrc string) (uint, uint, error) {
+	s.log(ctx).WithFields(logrus.Fields{"group": group, "song": song, "lrc": lrc != ""}).Debug("Creating song")
+
+	var verses []models.Verse
+	if lrc != "" {
+		var err error
+		if verses, err = parseLRC(lrc); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	songID, jobID, err := s.repo.CreatePendingSong(group, song, verses, s.enrichment.MaxAttempts, changeMeta(ctx))
+	if err != nil {
+		s.log(ctx).Error(err)
+		return 0, 0, err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"songID": songID, "jobID": jobID}).Debug("Enrichment job queued")
+
+	// Будим свободный обработчик, не дожидаясь следующего опроса очереди
+	select {
+	case s.enrichmentWake <- struct{}{}:
+	default:
+	}
+
+	return songID, jobID, nil
+}
+
+func (s *MusicLibService) GetEnrichmentJob(ctx context.Context, id uint) (*models.EnrichmentJob, error) {
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Fetching enrichment job")
+
+	job, err := s.repo.GetEnrichmentJob(id)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.log(ctx).Error(err)
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// RunEnrichmentWorkers запускает обработчики очереди обогащения. Они работают до отмены ctx;
+// возвращаемая функция дожидается завершения текущих задач.
+func (s *MusicLibService) RunEnrichmentWorkers(ctx context.Context) (wait func()) {
+	s.log(ctx).WithFields(logrus.Fields{"workers": s.enrichment.Workers}).Info("Starting enrichment workers")
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.enrichment.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.enrichmentWorker(ctx)
+		}()
+	}
+
+	return wg.Wait
+}
+
+func (s *MusicLibService) enrichmentWorker(ctx context.Context) {
+	ticker := time.NewTicker(s.enrichment.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Разбираем очередь, пока в ней есть готовые задачи
+		for ctx.Err() == nil {
+			job, err := s.repo.ClaimEnrichmentJob(s.enrichment.Lease)
+			if err != nil {
+				if !errors.Is(err, sql.ErrNoRows) {
+					s.log(ctx).Error(err)
+				}
+				break
+			}
+			s.processEnrichmentJob(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.enrichmentWake:
+		}
+	}
+}
+
+func (s *MusicLibService) processEnrichmentJob(ctx context.Context, job *models.EnrichmentJob) {
+	// Логи задачи, в том числе запросов к источникам данных, помечаются ее id
+	ctx = logger.WithEntry(ctx, s.log(ctx).WithFields(logrus.Fields{"jobID": job.ID, "songID": job.SongID}))
+	fields := logrus.Fields{"attempt": job.Attempts}
+	s.log(ctx).WithFields(fields).Debug("Processing enrichment job")
+
+	err := s.enrichSong(ctx, job)
+	if err == nil {
+		s.log(ctx).WithFields(fields).Info("Song enriched")
+		return
+	}
+	fields["error"] = err.Error()
+
+	switch {
+	case errors.Is(err, repository.ErrLeaseLost):
+		s.log(ctx).WithFields(fields).Warn("Enrichment job lease lost, result discarded")
+	// Попытка прервана остановкой сервиса или песня в корзине: задача сразу возвращается
+	// в очередь без учета попытки, а ClaimEnrichmentJob не берет задачи песен из корзины
+	// до их восстановления
+	case ctx.Err() != nil, errors.Is(err, errSongTrashed):
+		s.log(ctx).WithFields(fields).Warn("Enrichment job postponed")
+		s.settleEnrichmentJob(ctx, s.repo.PostponeEnrichmentJob(job.ID, job.Attempts, err.Error()))
+	case errors.Is(err, errPermanent) || job.Attempts >= job.MaxAttempts:
+		s.log(ctx).WithFields(fields).Warn("Enrichment job failed")
+		s.settleEnrichmentJob(ctx, s.repo.FailEnrichmentJob(job.ID, job.SongID, job.Attempts, err.Error()))
+	default:
+		delay := s.retryDelay(job.Attempts)
+		fields["retry in"] = delay.String()
+		s.log(ctx).WithFields(fields).Warn("Enrichment attempt failed")
+		s.settleEnrichmentJob(ctx, s.repo.RetryEnrichmentJob(job.ID, job.Attempts, delay, err.Error()))
+	}
+}
+
+// settleEnrichmentJob логирует ошибку перевода задачи в следующее состояние; потерянная аренда -
+// не ошибка: задачей уже занимается другой обработчик
+func (s *MusicLibService) settleEnrichmentJob(ctx context.Context, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, repository.ErrLeaseLost):
+		s.log(ctx).Warn("Enrichment job lease lost, state change discarded")
+	default:
+		s.log(ctx).Error(err)
+	}
+}
+
+func (s *MusicLibService) enrichSong(ctx context.Context, job *models.EnrichmentJob) error {
+	song, err := s.repo.GetSongByID(job.SongID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errSongTrashed
+		}
+		return err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, enrichmentCallTimeout)
+	defer cancel()
+
+	details, err := s.GetSongDetails(callCtx, song.Group, song.Song)
+	if err != nil {
+		if errors.Is(err, ErrDetailsNotFound) || openapi.IsPermanent(err) {
+			return fmt.Errorf("%w: %v", errPermanent, err)
+		}
+		return err
+	}
+
+	if !s.IsValidDate(details.ReleaseDate) {
+		return fmt.Errorf("%w: invalid release date %q from %s", errPermanent, details.ReleaseDate, details.Sources[models.DetailReleaseDate])
+	}
+
+	err = s.repo.CompleteEnrichmentJob(job.ID, job.SongID, job.Attempts, details, parseVerses(details.Text), models.ChangeMeta{
+		Actor:  ActorEnrichment,
+		Source: models.AuditSourceEnrichment,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return errSongTrashed
+	}
+	return err
+}
+
+// retryDelay - экспоненциальная пауза перед повтором после attempt неудачных попыток
+func (s *MusicLibService) retryDelay(attempt int) time.Duration {
+	delay := s.enrichment.RetryBase
+	for i := 1; i < attempt && delay < s.enrichment.RetryMax; i++ {
+		delay *= 2
+	}
+	if delay > s.enrichment.RetryMax {
+		delay = s.enrichment.RetryMax
+	}
+	return delay
+}
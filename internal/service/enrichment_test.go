@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
+	"testing"
+	"time"
+)
+
+// stubProvider отдает данные песни из функции теста
+type stubProvider func(ctx context.Context, group, song string) (*models.SongDetails, error)
+
+func (p stubProvider) Name() string {
+	return "stub"
+}
+
+func (p stubProvider) SongDetails(ctx context.Context, group, song string) (*models.SongDetails, error) {
+	return p(ctx, group, song)
+}
+
+func newEnrichmentService(t *testing.T, maxAttempts int, provider stubProvider) (*MusicLibService, *repository.MemoryStore) {
+	t.Helper()
+
+	s, store := newTestService(t, Options{
+		DetailsProviders: []string{ProviderFile},
+		DetailsFile:      writeDetailsFile(t, `[]`),
+		Enrichment:       EnrichmentOptions{MaxAttempts: maxAttempts, RetryBase: time.Hour},
+	})
+	s.providers = []DetailsProvider{provider}
+	return s, store
+}
+
+// claimAndProcess создает песню song и выполняет одну попытку ее задачи
+func claimAndProcess(t *testing.T, s *MusicLibService, store *repository.MemoryStore, ctx context.Context, song string, before func()) *models.EnrichmentJob {
+	t.Helper()
+
+	_, jobID, err := s.CreateSong(context.Background(), "Muse", song, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := store.ClaimEnrichmentJob(time.Minute)
+	if err != nil || job.ID != jobID {
+		t.Fatalf("ClaimEnrichmentJob = %+v, %v", job, err)
+	}
+	if before != nil {
+		before()
+	}
+	s.processEnrichmentJob(ctx, job)
+
+	job, err = store.GetEnrichmentJob(jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func TestProcessEnrichmentJob(t *testing.T) {
+	details := func(ctx context.Context, group, song string) (*models.SongDetails, error) {
+		switch song {
+		case "Uprising":
+			return &models.SongDetails{ReleaseDate: "2009-09-07", Text: "Paranoia is in bloom", Link: "https://example.com/uprising"}, nil
+		case "Hysteria":
+			return nil, errors.New("connection reset")
+		case "Madness":
+			return &models.SongDetails{ReleaseDate: "someday", Text: "x", Link: "y"}, nil
+		case "Starlight":
+			<-ctx.Done()
+			return nil, ctx.Err()
+		default:
+			return nil, ErrDetailsNotFound
+		}
+	}
+	s, store := newEnrichmentService(t, 2, details)
+
+	job := claimAndProcess(t, s, store, context.Background(), "Uprising", nil)
+	if job.Status != models.JobDone || job.Attempts != 1 {
+		t.Fatalf("successful job: %+v", job)
+	}
+
+	// Временная ошибка: задача ждет повтора через RetryBase
+	job = claimAndProcess(t, s, store, context.Background(), "Hysteria", nil)
+	if job.Status != models.JobQueued || job.Attempts != 1 || job.LastError != "connection reset" {
+		t.Fatalf("retried job: %+v", job)
+	}
+
+	for _, song := range []string{"Resistance", "Madness"} {
+		job = claimAndProcess(t, s, store, context.Background(), song, nil)
+		if job.Status != models.JobFailed || job.Attempts != 1 {
+			t.Fatalf("job of %s should fail without retries: %+v", song, job)
+		}
+	}
+
+	// Остановка сервиса и корзина не расходуют попытки
+	stopped, cancel := context.WithCancel(context.Background())
+	cancel()
+	job = claimAndProcess(t, s, store, stopped, "Starlight", nil)
+	if job.Status != models.JobQueued || job.Attempts != 0 {
+		t.Fatalf("job interrupted by shutdown: %+v", job)
+	}
+	// Отложенная задача снова готова к запуску; убираем ее песню в корзину, чтобы она не мешала следующей
+	if err := store.DeleteSong("Muse", "Starlight", models.ChangeMeta{}); err != nil {
+		t.Fatal(err)
+	}
+
+	job = claimAndProcess(t, s, store, context.Background(), "Supremacy", func() {
+		if err := store.DeleteSong("Muse", "Supremacy", models.ChangeMeta{}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if job.Status != models.JobQueued || job.Attempts != 0 || job.LastError != errSongTrashed.Error() {
+		t.Fatalf("job of a trashed song: %+v", job)
+	}
+}
+
+func TestEnrichmentLastAttempt(t *testing.T) {
+	s, store := newEnrichmentService(t, 1, func(context.Context, string, string) (*models.SongDetails, error) {
+		return nil, errors.New("connection reset")
+	})
+
+	job := claimAndProcess(t, s, store, context.Background(), "Uprising", nil)
+	if job.Status != models.JobFailed || job.Attempts != 1 || job.LastError != "connection reset" {
+		t.Fatalf("job after the last attempt: %+v", job)
+	}
+	song, err := store.GetSongByID(job.SongID)
+	if err != nil || song.EnrichmentStatus != models.EnrichmentFailed {
+		t.Fatalf("song after the last attempt: %+v, %v", song, err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	s, _ := newTestService(t, Options{Enrichment: EnrichmentOptions{RetryBase: time.Second, RetryMax: 5 * time.Second}})
+
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := s.retryDelay(attempt); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Форматы выгрузки библиотеки
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatJSON   = "json"
+	ExportFormatNDJSON = "ndjson"
+)
+
+var ErrUnsupportedExportFormat = errors.New("unsupported export format")
+
+// SongEncoder последовательно записывает песни выгрузки в выбранном формате
+type SongEncoder interface {
+	// Begin пишет начало документа (заголовок CSV или открывающую скобку JSON)
+	Begin() error
+	Encode(song models.ExportSong) error
+	// Flush сбрасывает буферизованные данные в нижележащий writer
+	Flush() error
+	// End завершает документ
+	End() error
+	ContentType() string
+}
+
+// NewSongEncoder возвращает кодировщик для формата csv, json или ndjson.
+// В CSV куплеты склеиваются в колонку text через пустую строку.
+func NewSongEncoder(w io.Writer, format string, withText bool) (SongEncoder, error) {
+	switch format {
+	case ExportFormatCSV:
+		return &csvSongEncoder{w: csv.NewWriter(w), withText: withText}, nil
+	case ExportFormatJSON:
+		return &jsonSongEncoder{w: w, enc: newJSONEncoder(w), array: true}, nil
+	case ExportFormatNDJSON:
+		return &jsonSongEncoder{w: w, enc: newJSONEncoder(w)}, nil
+	default:
+		return nil, ErrUnsupportedExportFormat
+	}
+}
+
+// ExportSongs передает в fn все песни, подходящие под фильтр, в порядке sort
+func (s *MusicLibService) ExportSongs(ctx context.Context, filter map[string]string, sort []models.SortKey, withText bool, fn func(song models.ExportSong) error) error {
+	s.log(ctx).WithFields(logrus.Fields{"filters": filter, "sort": sort, "with text": withText}).Debug("Exporting songs")
+
+	count := 0
+	err := s.repo.ExportSongs(ctx, filter, sort, withText, func(song models.Song, verses []string) error {
+		count++
+		return fn(models.ExportSong{
+			ID:          song.ID,
+			GroupID:     song.GroupID,
+			Group:       song.Group,
+			Song:        song.Song,
+			ReleaseDate: song.ReleaseDate,
+			Link:        song.Link,
+			CreatedAt:   song.CreatedAt,
+			UpdatedAt:   song.UpdatedAt,
+			Verses:      verses,
+		})
+	})
+	if err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"count": count}).Debug("Songs exported")
+
+	return nil
+}
+
+type csvSongEncoder struct {
+	w        *csv.Writer
+	withText bool
+}
+
+func (e *csvSongEncoder) Begin() error {
+	header := []string{"id", "group_id", "group", "song", "release_date", "link", "created_at", "updated_at"}
+	if e.withText {
+		header = append(header, "text")
+	}
+	return e.w.Write(header)
+}
+
+func (e *csvSongEncoder) Encode(song models.ExportSong) error {
+	record := []string{
+		strconv.FormatUint(uint64(song.ID), 10),
+		strconv.FormatUint(uint64(song.GroupID), 10),
+		song.Group,
+		song.Song,
+		song.ReleaseDate,
+		song.Link,
+		song.CreatedAt,
+		song.UpdatedAt,
+	}
+	if e.withText {
+		record = append(record, strings.Join(song.Verses, "\n\n"))
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvSongEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvSongEncoder) End() error {
+	return e.Flush()
+}
+
+func (e *csvSongEncoder) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+// jsonSongEncoder пишет JSON-массив (array) или по объекту на строку (NDJSON)
+type jsonSongEncoder struct {
+	w     io.Writer
+	enc   *json.Encoder
+	array bool
+	count int
+}
+
+func newJSONEncoder(w io.Writer) *json.Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc
+}
+
+func (e *jsonSongEncoder) Begin() error {
+	if !e.array {
+		return nil
+	}
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonSongEncoder) Encode(song models.ExportSong) error {
+	if e.array && e.count > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.count++
+	return e.enc.Encode(song)
+}
+
+func (e *jsonSongEncoder) Flush() error {
+	return nil
+}
+
+func (e *jsonSongEncoder) End() error {
+	if !e.array {
+		return nil
+	}
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+func (e *jsonSongEncoder) ContentType() string {
+	if e.array {
+		return "application/json; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"testing"
+)
+
+func TestSongEncoders(t *testing.T) {
+	songs := []models.ExportSong{
+		{ID: 1, GroupID: 2, Group: "Muse", Song: "Uprising", ReleaseDate: "2009-09-07", Link: "https://example.com/?a=1&b=<2>", Verses: []string{"Paranoia is in bloom", "Rise up, \"rise\""}},
+		{ID: 3, GroupID: 2, Group: "Muse", Song: "Hysteria", ReleaseDate: "2003-12-01"},
+	}
+
+	for _, tc := range []struct {
+		format   string
+		withText bool
+		want     string
+	}{
+		{ExportFormatCSV, false, "id,group_id,group,song,release_date,link,created_at,updated_at\n" +
+			"1,2,Muse,Uprising,2009-09-07,https://example.com/?a=1&b=<2>,,\n" +
+			"3,2,Muse,Hysteria,2003-12-01,,,\n"},
+		{ExportFormatCSV, true, "id,group_id,group,song,release_date,link,created_at,updated_at,text\n" +
+			"1,2,Muse,Uprising,2009-09-07,https://example.com/?a=1&b=<2>,,,\"Paranoia is in bloom\n\nRise up, \"\"rise\"\"\"\n" +
+			"3,2,Muse,Hysteria,2003-12-01,,,,\n"},
+		{ExportFormatJSON, true, `[{"id":1,"group_id":2,"group":"Muse","song":"Uprising","release_date":"2009-09-07","link":"https://example.com/?a=1&b=<2>","verses":["Paranoia is in bloom","Rise up, \"rise\""]}` + "\n" +
+			`,{"id":3,"group_id":2,"group":"Muse","song":"Hysteria","release_date":"2003-12-01","link":""}` + "\n]\n"},
+		{ExportFormatNDJSON, true, `{"id":1,"group_id":2,"group":"Muse","song":"Uprising","release_date":"2009-09-07","link":"https://example.com/?a=1&b=<2>","verses":["Paranoia is in bloom","Rise up, \"rise\""]}` + "\n" +
+			`{"id":3,"group_id":2,"group":"Muse","song":"Hysteria","release_date":"2003-12-01","link":""}` + "\n"},
+	} {
+		var buf bytes.Buffer
+		enc, err := NewSongEncoder(&buf, tc.format, tc.withText)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.Begin(); err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range songs {
+			if err := enc.Encode(s); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.End(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.want {
+			t.Errorf("%s (text %v):\n%s\nwant:\n%s", tc.format, tc.withText, buf.String(), tc.want)
+		}
+	}
+
+	// Пустая выгрузка JSON остается корректным массивом
+	var buf bytes.Buffer
+	enc, _ := NewSongEncoder(&buf, ExportFormatJSON, false)
+	enc.Begin()
+	enc.End()
+	if buf.String() != "[]\n" {
+		t.Errorf("empty json export: %q", buf.String())
+	}
+
+	if _, err := NewSongEncoder(&buf, "xml", false); !errors.Is(err, ErrUnsupportedExportFormat) {
+		t.Errorf("xml: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (s *MusicLibService) CreateGroup(ctx context.Context, group *models.Group) error {
+	s.log(ctx).WithFields(logrus.Fields{"name": group.Name}).Debug("Creating group")
+
+	id, err := s.repo.CreateGroup(group)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	group.ID = id
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Group created")
+
+	return nil
+}
+
+func (s *MusicLibService) GetGroups(ctx context.Context, filter map[string]string, limit, offset int) ([]models.Group, error) {
+	s.log(ctx).WithFields(logrus.Fields{"limit": limit, "offset": offset, "filters": filter}).Debug("Fetching groups")
+
+	groups, err := s.repo.GetGroups(filter, limit, offset)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (s *MusicLibService) GetGroupByID(ctx context.Context, id uint) (*models.Group, error) {
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Fetching group")
+
+	group, err := s.repo.GetGroupByID(id)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (s *MusicLibService) UpdateGroup(ctx context.Context, id uint, patch *models.GroupPatch) error {
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Updating group")
+
+	if err := s.repo.UpdateGroup(id, patch, changeMeta(ctx)); err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Group updated")
+
+	return nil
+}
+
+func (s *MusicLibService) DeleteGroup(ctx context.Context, id uint) error {
+	if err := s.repo.DeleteGroup(id); err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Group deleted")
+
+	return nil
+}
+
+// GetGroupSongs возвращает песни группы; если группы нет - sql.ErrNoRows
+func (s *MusicLibService) GetGroupSongs(ctx context.Context, id uint, limit, offset int) ([]models.Song, error) {
+	if _, err := s.GetGroupByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	filter := map[string]string{"group_id": strconv.FormatUint(uint64(id), 10)}
+	return s.GetAllSongs(ctx, filter, nil, limit, offset)
+}
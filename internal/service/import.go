@@ -0,0 +1,273 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Форматы файлов импорта
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+const defaultImportConcurrency = 4
+
+// ImportSource - источник полей, заданных в самом файле импорта
+const ImportSource = "import"
+
+var ErrUnsupportedImportFormat = errors.New("unsupported import format")
+
+// ParseImport читает строки импорта в формате csv или ndjson.
+// Ошибки разбора отдельных строк не прерывают чтение, а попадают в ImportRow.ParseError.
+func ParseImport(r io.Reader, format string) ([]models.ImportRow, error) {
+	switch format {
+	case ImportFormatCSV:
+		return parseImportCSV(r)
+	case ImportFormatNDJSON:
+		return parseImportNDJSON(r)
+	default:
+		return nil, ErrUnsupportedImportFormat
+	}
+}
+
+// parseImportCSV ожидает строку заголовков: обязательные колонки group и song,
+// необязательные release_date (или releaseDate), text и link
+func parseImportCSV(r io.Reader) ([]models.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == "releasedate" {
+			name = "release_date"
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["group"]; !ok {
+		return nil, errors.New("csv header must contain 'group' column")
+	}
+	if _, ok := columns["song"]; !ok {
+		return nil, errors.New("csv header must contain 'song' column")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []models.ImportRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		row := models.ImportRow{Row: line}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			row.ParseError = parseErr.Err.Error()
+			rows = append(rows, row)
+			continue
+		}
+
+		row.Group = field(record, "group")
+		row.Song = field(record, "song")
+		row.ReleaseDate = field(record, "release_date")
+		row.Text = field(record, "text")
+		row.Link = field(record, "link")
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseImportNDJSON читает по одному JSON-объекту на строку, пустые строки пропускаются
+func parseImportNDJSON(r io.Reader) ([]models.ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var rows []models.ImportRow
+	for line := 1; scanner.Scan(); line++ {
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+
+		var row models.ImportRow
+		if err := json.Unmarshal([]byte(data), &row); err != nil {
+			row = models.ImportRow{ParseError: "invalid json: " + err.Error()}
+		}
+		row.Row = line
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// ImportSongs сохраняет песни из строк импорта. Недостающие данные запрашиваются
+// во внешнем API не более чем в importConcurrency запросов одновременно.
+// Результаты в отчете идут в порядке строк.
+func (s *MusicLibService) ImportSongs(ctx context.Context, rows []models.ImportRow) *models.ImportReport {
+	s.log(ctx).WithFields(logrus.Fields{"rows": len(rows), "concurrency": s.importConcurrency}).Info("Importing songs")
+
+	report := &models.ImportReport{Results: make([]models.ImportResult, len(rows))}
+	ctx = WithSource(ctx, models.AuditSourceImport)
+
+	// Повторы внутри одного файла считаются дубликатами первой строки
+	seen := make(map[string]bool)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < s.importConcurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				report.Results[i] = s.importRow(ctx, rows[i])
+			}
+		}()
+	}
+
+	for i, row := range rows {
+		if row.ParseError == "" && row.Group != "" && row.Song != "" {
+			key := strings.ToLower(row.Group) + "\x00" + row.Song
+			if seen[key] {
+				report.Results[i] = importResult(row, models.ImportDuplicate, 0, "repeated in the import file")
+				continue
+			}
+			seen[key] = true
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, res := range report.Results {
+		switch res.Status {
+		case models.ImportCreated:
+			report.Created++
+		case models.ImportDuplicate:
+			report.Duplicates++
+		default:
+			report.Failed++
+		}
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"created":    report.Created,
+		"duplicates": report.Duplicates,
+		"failed":     report.Failed,
+	}).Info("Import finished")
+
+	return report
+}
+
+func (s *MusicLibService) importRow(ctx context.Context, row models.ImportRow) models.ImportResult {
+	switch {
+	case row.ParseError != "":
+		return importResult(row, models.ImportFailed, 0, row.ParseError)
+	case row.Group == "" || row.Song == "":
+		return importResult(row, models.ImportFailed, 0, "group and song are required")
+	case ctx.Err() != nil:
+		return importResult(row, models.ImportFailed, 0, ctx.Err().Error())
+	}
+
+	exists, err := s.repo.SongExists(row.Group, row.Song)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return importResult(row, models.ImportFailed, 0, "failed to check for duplicates")
+	}
+	if exists {
+		return importResult(row, models.ImportDuplicate, 0, "")
+	}
+
+	song := &models.Song{
+		Group:       row.Group,
+		Song:        row.Song,
+		ReleaseDate: normalizeReleaseDate(row.ReleaseDate),
+		Text:        row.Text,
+		Link:        row.Link,
+	}
+
+	var sources map[string]string
+	if song.ReleaseDate == "" || song.Text == "" || song.Link == "" {
+		details, err := s.GetSongDetails(ctx, row.Group, row.Song)
+		if err != nil {
+			return importResult(row, models.ImportFailed, 0, "enrichment failed: "+err.Error())
+		}
+		sources = make(map[string]string)
+		for _, field := range []struct {
+			name  string
+			dst   *string
+			value string
+		}{
+			{models.DetailReleaseDate, &song.ReleaseDate, details.ReleaseDate},
+			{models.DetailText, &song.Text, details.Text},
+			{models.DetailLink, &song.Link, details.Link},
+		} {
+			if *field.dst != "" {
+				sources[field.name] = ImportSource
+				continue
+			}
+			*field.dst = field.value
+			if src, ok := details.Sources[field.name]; ok {
+				sources[field.name] = src
+			}
+		}
+	}
+
+	if !s.IsValidDate(song.ReleaseDate) {
+		return importResult(row, models.ImportFailed, 0, fmt.Sprintf("invalid release date %q, expected YYYY-MM-DD or DD.MM.YYYY", song.ReleaseDate))
+	}
+
+	if err := s.SaveSong(ctx, song); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return importResult(row, models.ImportDuplicate, 0, "")
+		}
+		return importResult(row, models.ImportFailed, 0, "failed to save song")
+	}
+
+	result := importResult(row, models.ImportCreated, song.ID, "")
+	result.Sources = sources
+	return result
+}
+
+func importResult(row models.ImportRow, status string, id uint, reason string) models.ImportResult {
+	return models.ImportResult{
+		Row:    row.Row,
+		Group:  row.Group,
+		Song:   row.Song,
+		Status: status,
+		ID:     id,
+		Error:  reason,
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	data := "\ufeffGroup, Song,releaseDate,text,link\n" +
+		"Muse,Uprising,2009-09-07,\"Paranoia is in bloom\nThe PR transmissions will resume\",https://example.com/uprising\n" +
+		"Muse,Hysteria\n" +
+		"Muse,\"Bad \"quote\",,,\n"
+
+	rows, err := ParseImport(strings.NewReader(data), ImportFormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("rows: %+v", rows)
+	}
+
+	want := models.ImportRow{
+		Row: 2, Group: "Muse", Song: "Uprising", ReleaseDate: "2009-09-07",
+		Text: "Paranoia is in bloom\nThe PR transmissions will resume", Link: "https://example.com/uprising",
+	}
+	if rows[0] != want {
+		t.Errorf("row 2: %+v", rows[0])
+	}
+	// Недостающие колонки остаются пустыми
+	if rows[1] != (models.ImportRow{Row: 3, Group: "Muse", Song: "Hysteria"}) {
+		t.Errorf("row 3: %+v", rows[1])
+	}
+	if rows[2].ParseError == "" {
+		t.Errorf("row 4 should fail to parse: %+v", rows[2])
+	}
+
+	for _, header := range []string{"song,link\n", "group,link\n", ""} {
+		if _, err := ParseImport(strings.NewReader(header), ImportFormatCSV); err == nil {
+			t.Errorf("header %q was accepted", header)
+		}
+	}
+}
+
+func TestParseImportNDJSON(t *testing.T) {
+	data := `{"group": "Muse", "song": "Uprising", "release_date": "2009-09-07"}
+
+{"group": "Muse", "song": "Hysteria", "text": "It's bugging me"}
+{"group": "Muse",
+`
+
+	rows, err := ParseImport(strings.NewReader(data), ImportFormatNDJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("rows: %+v", rows)
+	}
+	if rows[0] != (models.ImportRow{Row: 1, Group: "Muse", Song: "Uprising", ReleaseDate: "2009-09-07"}) {
+		t.Errorf("line 1: %+v", rows[0])
+	}
+	// Номер строки учитывает пропущенную пустую строку
+	if rows[1] != (models.ImportRow{Row: 3, Group: "Muse", Song: "Hysteria", Text: "It's bugging me"}) {
+		t.Errorf("line 3: %+v", rows[1])
+	}
+	if rows[2].Row != 4 || !strings.HasPrefix(rows[2].ParseError, "invalid json") {
+		t.Errorf("line 4: %+v", rows[2])
+	}
+
+	if _, err := ParseImport(strings.NewReader(data), "xml"); !errors.Is(err, ErrUnsupportedImportFormat) {
+		t.Errorf("xml: %v", err)
+	}
+}
+
+func TestImportSongs(t *testing.T) {
+	s, store := newTestService(t, Options{
+		DetailsProviders: []string{ProviderFile},
+		DetailsFile: writeDetailsFile(t, `[
+			{"group": "Muse", "song": "Hysteria", "releaseDate": "2003-12-01", "text": "It's bugging me", "link": "https://example.com/hysteria"}
+		]`),
+	})
+
+	if _, err := store.SaveSongInfo("Muse", "Starlight", "2006-09-04", "https://example.com/starlight"); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := []models.ImportRow{
+		{Row: 1, Group: "Muse", Song: "Uprising", ReleaseDate: "2009-09-07", Text: "Paranoia is in bloom", Link: "https://example.com/uprising"},
+		{Row: 2, Group: "Muse", Song: "Hysteria", Link: "https://example.com/hysteria-live"},
+		{Row: 3, Group: "muse", Song: "Uprising", ReleaseDate: "2009-09-07", Text: "x", Link: "y"},
+		{Row: 4, Group: "Muse", Song: "Starlight"},
+		{Row: 5, Group: "Muse", Song: "Madness"},
+		{Row: 6, Group: "Muse", Song: "Resistance", ReleaseDate: "yesterday", Text: "x", Link: "y"},
+		{Row: 7, Group: "Muse"},
+		{Row: 8, ParseError: "invalid json"},
+		{Row: 9, Group: "Muse", Song: "Exogenesis", ReleaseDate: "14.09.2009", Text: "x", Link: "y"},
+		{Row: 10, Group: "Muse", Song: "Unnatural Selection", ReleaseDate: "9/14/2009", Text: "x", Link: "y"},
+	}
+
+	report := s.ImportSongs(context.Background(), rows)
+	if report.Created != 3 || report.Duplicates != 2 || report.Failed != 5 {
+		t.Fatalf("report: %+v", report)
+	}
+
+	var statuses []string
+	for i, res := range report.Results {
+		if res.Row != rows[i].Row {
+			t.Fatalf("result %d is for row %d", i, res.Row)
+		}
+		statuses = append(statuses, res.Status)
+	}
+	want := []string{
+		models.ImportCreated, models.ImportCreated, models.ImportDuplicate, models.ImportDuplicate,
+		models.ImportFailed, models.ImportFailed, models.ImportFailed, models.ImportFailed,
+		models.ImportCreated, models.ImportFailed,
+	}
+	if !slices.Equal(statuses, want) {
+		t.Fatalf("statuses: %v", statuses)
+	}
+
+	// Заданная в файле ссылка не перезаписывается данными источника
+	hysteria := report.Results[1]
+	if hysteria.Sources[models.DetailLink] != ImportSource || hysteria.Sources[models.DetailText] != ProviderFile {
+		t.Errorf("sources: %v", hysteria.Sources)
+	}
+	song, err := store.GetSongByID(hysteria.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if song.Link != "https://example.com/hysteria-live" || song.ReleaseDate != "2003-12-01" || song.Text != "It's bugging me" {
+		t.Errorf("imported song: %+v", song)
+	}
+
+	// Даты импорта приводятся к YYYY-MM-DD так же, как даты источников
+	song, err = store.GetSongByID(report.Results[8].ID)
+	if err != nil || song.ReleaseDate != "2009-09-14" {
+		t.Errorf("dotted release date: %+v, %v", song, err)
+	}
+}
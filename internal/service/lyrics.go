@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Форматы синхронизированного текста
+const (
+	LyricsFormatLRC = "lrc"
+	LyricsFormatSRT = "srt"
+	LyricsFormatVTT = "vtt"
+)
+
+var (
+	// ErrInvalidLRC возвращается, если файл LRC не удалось разобрать
+	ErrInvalidLRC = errors.New("invalid LRC")
+	// ErrNotSynced возвращается, если у текста песни нет временных меток
+	ErrNotSynced = errors.New("song lyrics are not time-synced")
+	// ErrUnsupportedLyricsFormat возвращается для неизвестного формата синхронизированного текста
+	ErrUnsupportedLyricsFormat = errors.New("unsupported lyrics format")
+)
+
+// maxCueDuration - сколько максимум показывается строка в SRT и WebVTT,
+// если следующая строка начинается позже или ее нет
+const maxCueDuration = 5 * time.Second
+
+var (
+	// lrcTimestamp - метка времени строки: [mm:ss], [mm:ss.xx] или [mm:ss.xxx]
+	lrcTimestamp = regexp.MustCompile(`^\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	// lrcTag - служебный тег вида [ar:Исполнитель], [ti:Название], [offset:+200]
+	lrcTag = regexp.MustCompile(`^\[([A-Za-z#]+):(.*)\]$`)
+)
+
+type lrcLine struct {
+	time int64 // мс; -1 для строки без метки времени
+	text string
+	src  int // номер строки в файле
+}
+
+// parseLRC разбирает LRC в куплеты с временем строк. Куплеты разделяются пустой строкой
+// (с меткой времени или без), метки вида [Chorus] задают тип куплета. Строки с несколькими
+// метками ([00:12.00][01:30.00]...) повторяются в каждый из моментов, каждый повтор
+// становится отдельным куплетом. Тег offset сдвигает все метки: положительное значение
+// показывает текст раньше.
+func parseLRC(text string) ([]models.Verse, error) {
+	text = strings.ReplaceAll(strings.TrimPrefix(text, "\ufeff"), "\r\n", "\n")
+
+	var offset int64
+	var lines []lrcLine
+	compressed := false
+	for n, raw := range strings.Split(text, "\n") {
+		raw = strings.TrimSpace(raw)
+
+		var times []int64
+		rest := raw
+		for {
+			m := lrcTimestamp.FindStringSubmatch(rest)
+			if m == nil {
+				break
+			}
+			times = append(times, lrcTime(m))
+			rest = rest[len(m[0]):]
+		}
+
+		if len(times) == 0 {
+			if m := lrcTag.FindStringSubmatch(raw); m != nil {
+				if strings.EqualFold(m[1], "offset") {
+					v, err := strconv.ParseInt(strings.TrimSpace(m[2]), 10, 64)
+					if err != nil {
+						return nil, fmt.Errorf("%w: line %d: invalid offset %q", ErrInvalidLRC, n+1, m[2])
+					}
+					offset = v
+				}
+				continue
+			}
+			if raw != "" && !verseMarker.MatchString(raw) {
+				return nil, fmt.Errorf("%w: line %d has no timestamp", ErrInvalidLRC, n+1)
+			}
+			lines = append(lines, lrcLine{time: -1, text: raw, src: n})
+			continue
+		}
+
+		compressed = compressed || len(times) > 1
+		for _, t := range times {
+			lines = append(lines, lrcLine{time: t, text: strings.TrimSpace(rest), src: n})
+		}
+	}
+
+	// Строки с несколькими метками разворачиваются по времени; строка без метки
+	// остается перед ближайшей следующей строкой с меткой
+	if compressed {
+		next := int64(-1)
+		for i := len(lines) - 1; i >= 0; i-- {
+			if lines[i].time < 0 {
+				lines[i].time = next
+			} else {
+				next = lines[i].time
+			}
+		}
+		sort.SliceStable(lines, func(a, b int) bool { return lines[a].time < lines[b].time })
+	}
+
+	verses := []models.Verse{}
+	current := models.Verse{}
+	var texts []string
+	flush := func() {
+		if len(texts) == 0 {
+			return
+		}
+		current.Text = strings.Join(texts, "\n")
+		verses = append(verses, current)
+		current, texts = models.Verse{}, nil
+	}
+
+	for i, line := range lines {
+		// После разворачивания возврат к более ранней строке файла начинает новый куплет
+		if i > 0 && line.src < lines[i-1].src {
+			flush()
+		}
+		if m := verseMarker.FindStringSubmatch(line.text); m != nil {
+			flush()
+			current.Type = verseTypeOf(m[1])
+			continue
+		}
+		if line.text == "" {
+			flush()
+			continue
+		}
+		texts = append(texts, line.text)
+		current.LineTimes = append(current.LineTimes, max(line.time-offset, 0))
+	}
+	flush()
+
+	if len(verses) == 0 {
+		return nil, fmt.Errorf("%w: no timestamped lines", ErrInvalidLRC)
+	}
+
+	// Развернутый повтор остается без метки типа, поэтому берет тип такого же куплета
+	types := make(map[string]string)
+	for i, v := range verses {
+		if v.Type != "" {
+			types[v.Text] = v.Type
+		} else if t, ok := types[v.Text]; ok {
+			verses[i].Type = t
+		}
+	}
+
+	return markRepeats(verses), nil
+}
+
+// lrcTime переводит совпадение lrcTimestamp в миллисекунды
+func lrcTime(m []string) int64 {
+	minutes, _ := strconv.ParseInt(m[1], 10, 64)
+	seconds, _ := strconv.ParseInt(m[2], 10, 64)
+	ms := (minutes*60 + seconds) * 1000
+	if m[3] != "" {
+		// .x - десятые, .xx - сотые, .xxx - тысячные доли секунды
+		frac, _ := strconv.ParseInt((m[3] + "00")[:3], 10, 64)
+		ms += frac
+	}
+	return ms
+}
+
+// syncedLine - строка текста со временем начала
+type syncedLine struct {
+	start int64
+	text  string
+}
+
+// syncedLines возвращает строки синхронизированных куплетов в порядке времени
+func syncedLines(verses []models.Verse) []syncedLine {
+	var lines []syncedLine
+	for _, v := range verses {
+		texts := strings.Split(v.Text, "\n")
+		if len(v.LineTimes) != len(texts) {
+			continue
+		}
+		for i, text := range texts {
+			lines = append(lines, syncedLine{start: v.LineTimes[i], text: text})
+		}
+	}
+	sort.SliceStable(lines, func(a, b int) bool { return lines[a].start < lines[b].start })
+	return lines
+}
+
+func renderLRC(resp *models.SongVersesResp) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[ar:%s]\n[ti:%s]\n", resp.Group, resp.Song)
+
+	for _, v := range resp.Verses {
+		texts := strings.Split(v.Text, "\n")
+		if len(v.LineTimes) != len(texts) {
+			continue
+		}
+		b.WriteString("\n")
+		if v.Type != "" {
+			fmt.Fprintf(&b, "%s\n", v.Marker())
+		}
+		for i, text := range texts {
+			ms := v.LineTimes[i]
+			fmt.Fprintf(&b, "[%02d:%02d.%02d]%s\n", ms/60000, ms/1000%60, ms%1000/10, text)
+		}
+	}
+
+	return b.String()
+}
+
+// renderCues выводит строки как субтитры SRT (с номерами, запятая в миллисекундах)
+// или WebVTT. Строка показывается до начала следующей, но не дольше maxCueDuration.
+func renderCues(lines []syncedLine, vtt bool) string {
+	var b strings.Builder
+	if vtt {
+		b.WriteString("WEBVTT\n\n")
+	}
+
+	for i, line := range lines {
+		end := line.start + maxCueDuration.Milliseconds()
+		if i+1 < len(lines) && lines[i+1].start > line.start && lines[i+1].start < end {
+			end = lines[i+1].start
+		}
+
+		text := line.text
+		if vtt {
+			text = vttEscaper.Replace(text)
+		} else {
+			fmt.Fprintf(&b, "%d\n", i+1)
+		}
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", cueTime(line.start, vtt), cueTime(end, vtt), text)
+	}
+
+	return b.String()
+}
+
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func cueTime(ms int64, vtt bool) string {
+	sep := ","
+	if vtt {
+		sep = "."
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// GetSyncedLyrics возвращает синхронизированный текст песни в формате LRC, SRT или WebVTT.
+// Куплеты без временных меток пропускаются; если меток нет совсем, возвращается ErrNotSynced.
+func (s *MusicLibService) GetSyncedLyrics(ctx context.Context, id uint, format string) (string, error) {
+	s.log(ctx).WithFields(logrus.Fields{"id": id, "format": format}).Debug("Rendering synced lyrics")
+
+	resp, err := s.repo.GetSongVerses(id, -1, 0)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return "", err
+	}
+
+	lines := syncedLines(resp.Verses)
+	if len(lines) == 0 {
+		return "", ErrNotSynced
+	}
+
+	switch format {
+	case LyricsFormatLRC:
+		return renderLRC(resp), nil
+	case LyricsFormatSRT:
+		return renderCues(lines, false), nil
+	case LyricsFormatVTT:
+		return renderCues(lines, true), nil
+	default:
+		return "", ErrUnsupportedLyricsFormat
+	}
+}
+
+// SetSongLRC заменяет текст песни разобранным файлом LRC
+func (s *MusicLibService) SetSongLRC(ctx context.Context, id uint, lrc string) error {
+	s.log(ctx).WithFields(logrus.Fields{"id": id, "size": len(lrc)}).Debug("Uploading LRC")
+
+	verses, err := parseLRC(lrc)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateSongByID(id, &models.SongPatch{}, verses, changeMeta(ctx)); err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"id": id, "verses": len(verses)}).Debug("Song lyrics replaced from LRC")
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestParseLRC(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		lrc  string
+		want []models.Verse
+	}{
+		{
+			name: "tags, offset and verse markers",
+			lrc: "\ufeff[ar:Muse]\r\n[ti:Uprising]\r\n[offset:+500]\r\n" +
+				"[00:00.2]Intro\r\n" +
+				"[00:01.5]Paranoia is in bloom\r\n[00:04.25]The PR transmissions will resume\r\n\r\n" +
+				"[Chorus]\r\n[00:10.123]They will not force us\r\n[00:12]They will stop degrading us\r\n",
+			want: []models.Verse{
+				// Сдвиг не делает время отрицательным
+				{Position: 1, Text: "Intro\nParanoia is in bloom\nThe PR transmissions will resume", LineTimes: []int64{0, 1000, 3750}},
+				{Position: 2, Type: models.VerseTypeChorus, Text: "They will not force us\nThey will stop degrading us", LineTimes: []int64{9623, 11500}},
+			},
+		},
+		{
+			name: "compressed timestamps",
+			lrc: "[00:01.00]Far away\n[Chorus]\n[00:05.00][00:15.00]My life\n[00:07.00][00:17.00]You electrify my life\n" +
+				"\n[00:10.00]The ship is taking me\n",
+			want: []models.Verse{
+				{Position: 1, Text: "Far away", LineTimes: []int64{1000}},
+				{Position: 2, Type: models.VerseTypeChorus, Text: "My life\nYou electrify my life", LineTimes: []int64{5000, 7000}},
+				{Position: 3, Text: "The ship is taking me", LineTimes: []int64{10000}},
+				// Развернутый повтор берет тип припева и ссылается на него, время строк свое
+				{Position: 4, Type: models.VerseTypeChorus, RepeatOf: 2, Text: "My life\nYou electrify my life", LineTimes: []int64{15000, 17000}},
+			},
+		},
+		{
+			name: "timestamped blank line separates verses",
+			lrc:  "[00:01.00]One\n[00:02.00]\n[00:03.00]Two\n",
+			want: []models.Verse{
+				{Position: 1, Text: "One", LineTimes: []int64{1000}},
+				{Position: 2, Text: "Two", LineTimes: []int64{3000}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			verses, err := parseLRC(tc.lrc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(verses, tc.want) {
+				t.Fatalf("parseLRC:\n%+v\nwant:\n%+v", verses, tc.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{"no timestamps here", "[offset:soon]\n[00:01.00]x", "[ar:Muse]\n\n[Chorus]\n", ""} {
+		if _, err := parseLRC(bad); !errors.Is(err, ErrInvalidLRC) {
+			t.Errorf("parseLRC(%q): %v, want ErrInvalidLRC", bad, err)
+		}
+	}
+}
+
+func TestRenderLRC(t *testing.T) {
+	resp := &models.SongVersesResp{Group: "Muse", Song: "Uprising", Verses: []models.Verse{
+		{Position: 1, Text: "Paranoia is in bloom\nThe PR transmissions will resume", LineTimes: []int64{1000, 65430}},
+		{Position: 2, Type: models.VerseTypeChorus, Text: "They will not force us", LineTimes: []int64{61000}},
+		{Position: 3, Text: "Not synced"},
+	}}
+
+	lrc := renderLRC(resp)
+	want := "[ar:Muse]\n[ti:Uprising]\n\n" +
+		"[00:01.00]Paranoia is in bloom\n[01:05.43]The PR transmissions will resume\n\n" +
+		"[Chorus]\n[01:01.00]They will not force us\n"
+	if lrc != want {
+		t.Fatalf("renderLRC:\n%s\nwant:\n%s", lrc, want)
+	}
+
+	// LRC хранит сотые доли секунды
+	resp.Verses[0].LineTimes = []int64{1000, 65437}
+	if renderLRC(resp) != want {
+		t.Fatalf("milliseconds were not truncated:\n%s", renderLRC(resp))
+	}
+	resp.Verses[0].LineTimes = []int64{1000, 65430}
+
+	// Выгруженный LRC разбирается обратно в те же куплеты
+	verses, err := parseLRC(lrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(verses, resp.Verses[:2]) {
+		t.Fatalf("round trip:\n%+v", verses)
+	}
+}
+
+func TestRenderCues(t *testing.T) {
+	lines := []syncedLine{
+		{start: 1000, text: "Far away"},
+		{start: 3000, text: "Rock & <roll>"},
+		{start: 20000, text: "My life"},
+		{start: 20000, text: "Together"},
+		{start: 3723004, text: "Late"},
+	}
+
+	srt := "1\n00:00:01,000 --> 00:00:03,000\nFar away\n\n" +
+		// Следующая строка начинается позже maxCueDuration
+		"2\n00:00:03,000 --> 00:00:08,000\nRock & <roll>\n\n" +
+		"3\n00:00:20,000 --> 00:00:25,000\nMy life\n\n" +
+		"4\n00:00:20,000 --> 00:00:25,000\nTogether\n\n" +
+		"5\n01:02:03,004 --> 01:02:08,004\nLate\n\n"
+	if got := renderCues(lines, false); got != srt {
+		t.Errorf("SRT:\n%s\nwant:\n%s", got, srt)
+	}
+
+	vtt := "WEBVTT\n\n" +
+		"00:00:01.000 --> 00:00:03.000\nFar away\n\n" +
+		"00:00:03.000 --> 00:00:08.000\nRock &amp; &lt;roll&gt;\n\n" +
+		"00:00:20.000 --> 00:00:25.000\nMy life\n\n" +
+		"00:00:20.000 --> 00:00:25.000\nTogether\n\n" +
+		"01:02:03.004 --> 01:02:08.004\nLate\n\n"
+	if got := renderCues(lines, true); got != vtt {
+		t.Errorf("VTT:\n%s\nwant:\n%s", got, vtt)
+	}
+}
+
+func TestGetSyncedLyrics(t *testing.T) {
+	s, store := newTestService(t, Options{})
+	ctx := context.Background()
+
+	id, err := store.SaveSongInfo("Muse", "Uprising", "2009-09-07", "https://example.com/uprising")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSongVerses(ctx, uint(id), []models.Verse{{Text: "Paranoia is in bloom"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetSyncedLyrics(ctx, uint(id), LyricsFormatSRT); !errors.Is(err, ErrNotSynced) {
+		t.Fatalf("lyrics without timestamps: %v", err)
+	}
+
+	// Строки разных куплетов выводятся в порядке времени
+	err = s.SetSongLRC(ctx, uint(id), "[00:05.00][00:01.00]Rise up\n\n[00:03.00]Paranoia is in bloom\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srt, err := s.GetSyncedLyrics(ctx, uint(id), LyricsFormatSRT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:01,000 --> 00:00:03,000\nRise up\n\n" +
+		"2\n00:00:03,000 --> 00:00:05,000\nParanoia is in bloom\n\n" +
+		"3\n00:00:05,000 --> 00:00:10,000\nRise up\n\n"
+	if srt != want {
+		t.Fatalf("SRT:\n%s\nwant:\n%s", srt, want)
+	}
+
+	if _, err := s.GetSyncedLyrics(ctx, uint(id), "ass"); !errors.Is(err, ErrUnsupportedLyricsFormat) {
+		t.Fatalf("unknown format: %v", err)
+	}
+	if err := s.SetSongLRC(ctx, uint(id), "plain text"); !errors.Is(err, ErrInvalidLRC) {
+		t.Fatalf("invalid LRC: %v", err)
+	}
+}
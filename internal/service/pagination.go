@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrCursorNotSupported = errors.New("cursor pagination is not supported with fuzzy matching")
+)
+
+// GetSongsPage возвращает страницу песен в порядке sort (по умолчанию release_date DESC, id DESC).
+// cursor - значение next_cursor или prev_cursor предыдущей страницы, пустая строка - первая страница.
+// Курсор действителен только с той же сортировкой, с которой он был получен.
+func (s *MusicLibService) GetSongsPage(ctx context.Context, filter map[string]string, sort []models.SortKey, cursor string, limit int, withTotal bool) (*models.SongPage, error) {
+	s.log(ctx).WithFields(logrus.Fields{"limit": limit, "cursor": cursor, "filters": filter, "sort": sort}).Debug("Fetching songs page")
+
+	if len(sort) == 0 && (filter["groupMatch"] == models.MatchFuzzy || filter["songMatch"] == models.MatchFuzzy) {
+		return nil, ErrCursorNotSupported
+	}
+
+	if len(sort) == 0 {
+		sort = repository.DefaultSongSort
+	}
+	sortSpec := formatSongSort(sort)
+
+	var after *models.SongCursor
+	if cursor != "" {
+		c, err := decodeCursor(cursor, sort)
+		if err != nil {
+			return nil, err
+		}
+		after = c
+	}
+
+	songs, hasMore, err := s.repo.GetSongsAfter(filter, sort, after, limit)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	page := &models.SongPage{Items: songs}
+	if len(songs) > 0 {
+		first, last := songs[0], songs[len(songs)-1]
+		backward := after != nil && after.Backward
+
+		if hasMore || backward {
+			page.NextCursor = encodeCursor(models.SongCursor{Sort: sortSpec, Values: sortValues(last, sort)})
+		}
+		if (backward && hasMore) || (after != nil && !backward) {
+			page.PrevCursor = encodeCursor(models.SongCursor{Sort: sortSpec, Values: sortValues(first, sort), Backward: true})
+		}
+	}
+
+	if withTotal {
+		total, err := s.CountSongs(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"count": len(songs), "has_more": hasMore}).Debug("Fetched songs page")
+
+	return page, nil
+}
+
+func (s *MusicLibService) CountSongs(ctx context.Context, filter map[string]string) (int, error) {
+	total, err := s.repo.CountSongs(filter)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func encodeCursor(c models.SongCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor разбирает курсор и проверяет, что он выдан для сортировки sort и каждое
+// значение подходит по типу своему ключу: измененный клиентом курсор не должен доходить до базы
+func decodeCursor(cursor string, sort []models.SortKey) (*models.SongCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c models.SongCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != formatSongSort(sort) || len(c.Values) != len(sort) {
+		return nil, ErrInvalidCursor
+	}
+
+	for i, key := range sort {
+		if !validCursorValue(key.Field, c.Values[i]) {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return &c, nil
+}
+
+// cursorTimeLayout - формат created_at и updated_at песни в курсоре
+const cursorTimeLayout = "2006-01-02T15:04:05.999999"
+
+func validCursorValue(field, value string) bool {
+	switch field {
+	case models.SortReleaseDate:
+		// у песен, ожидающих обогащения, даты выхода нет
+		if value == "" {
+			return true
+		}
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case models.SortCreatedAt, models.SortUpdatedAt:
+		_, err := time.Parse(cursorTimeLayout, value)
+		return err == nil
+	case models.SortID:
+		// id в базе - INT
+		_, err := strconv.ParseUint(value, 10, 31)
+		return err == nil
+	case models.SortGroup, models.SortSong:
+		return utf8.ValidString(value) && !strings.ContainsRune(value, 0)
+	}
+	return false
+}
+
+func sortValues(song models.Song, sort []models.SortKey) []string {
+	values := make([]string, len(sort))
+	for i, key := range sort {
+		values[i] = song.SortValue(key.Field)
+	}
+	return values
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	sort := []models.SortKey{{Field: models.SortReleaseDate, Desc: true}, {Field: models.SortSong}, {Field: models.SortID}}
+	valid := models.SongCursor{Sort: "-release_date,song,id", Values: []string{"2009-09-07", "Uprising", "42"}, Backward: true}
+
+	c, err := decodeCursor(encodeCursor(valid), sort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Sort != valid.Sort || !slices.Equal(c.Values, valid.Values) || !c.Backward {
+		t.Fatalf("decoded %+v, want %+v", c, valid)
+	}
+
+	// у песен без даты выхода значение даты в курсоре пустое
+	pending := models.SongCursor{Sort: valid.Sort, Values: []string{"", "Uprising", "42"}}
+	if _, err := decodeCursor(encodeCursor(pending), sort); err != nil {
+		t.Fatalf("cursor with an empty release date: %v", err)
+	}
+
+	for name, cursor := range map[string]string{
+		"not base64":     "!!!",
+		"not JSON":       base64.RawURLEncoding.EncodeToString([]byte("[1, 2")),
+		"other sort":     encodeCursor(models.SongCursor{Sort: "release_date,song,id", Values: valid.Values}),
+		"missing values": encodeCursor(models.SongCursor{Sort: valid.Sort, Values: valid.Values[:2]}),
+		"bad date":       encodeCursor(models.SongCursor{Sort: valid.Sort, Values: []string{"2009-13-07", "Uprising", "42"}}),
+		"date and time":  encodeCursor(models.SongCursor{Sort: valid.Sort, Values: []string{"2009-09-07 00:00", "Uprising", "42"}}),
+		"negative id":    encodeCursor(models.SongCursor{Sort: valid.Sort, Values: []string{"2009-09-07", "Uprising", "-1"}}),
+		"id overflow":    encodeCursor(models.SongCursor{Sort: valid.Sort, Values: []string{"2009-09-07", "Uprising", "2147483648"}}),
+		"NUL in song":    encodeCursor(models.SongCursor{Sort: valid.Sort, Values: []string{"2009-09-07", "Up\x00rising", "42"}}),
+	} {
+		if _, err := decodeCursor(cursor, sort); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: %v, want ErrInvalidCursor", name, err)
+		}
+	}
+
+	timeSort := []models.SortKey{{Field: models.SortCreatedAt}, {Field: models.SortID}}
+	for value, ok := range map[string]bool{
+		"2024-05-01T10:20:30.123456": true,
+		"2024-05-01T10:20:30":        true,
+		"2024-05-01":                 false,
+		"yesterday":                  false,
+	} {
+		cursor := encodeCursor(models.SongCursor{Sort: "created_at,id", Values: []string{value, "1"}})
+		if _, err := decodeCursor(cursor, timeSort); (err == nil) != ok {
+			t.Errorf("created_at %q: %v", value, err)
+		}
+	}
+}
+
+func TestGetSongsPage(t *testing.T) {
+	s, store := newTestService(t, Options{})
+	ctx := context.Background()
+
+	// Две песни с одной датой проверяют, что порядок однозначен благодаря id
+	for _, song := range []struct{ name, date string }{
+		{"Hysteria", "2003-12-01"},
+		{"Starlight", "2006-09-04"},
+		{"Knights of Cydonia", "2006-09-04"},
+		{"Uprising", "2009-09-07"},
+		{"Madness", "2012-08-20"},
+	} {
+		if _, err := store.SaveSongInfo("Muse", song.name, song.date, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := func(page *models.SongPage) []string {
+		var got []string
+		for _, song := range page.Items {
+			got = append(got, song.Song)
+		}
+		return got
+	}
+
+	var pages []*models.SongPage
+	cursor := ""
+	for {
+		page, err := s.GetSongsPage(ctx, map[string]string{}, nil, cursor, 2, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total == nil || *page.Total != 5 {
+			t.Fatalf("total %v, want 5", page.Total)
+		}
+		pages = append(pages, page)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	want := [][]string{{"Madness", "Uprising"}, {"Knights of Cydonia", "Starlight"}, {"Hysteria"}}
+	if len(pages) != len(want) {
+		t.Fatalf("got %d pages, want %d", len(pages), len(want))
+	}
+	for i := range want {
+		if got := names(pages[i]); !slices.Equal(got, want[i]) {
+			t.Errorf("page %d: %q, want %q", i+1, got, want[i])
+		}
+	}
+	if pages[0].PrevCursor != "" {
+		t.Errorf("first page has prev_cursor")
+	}
+
+	// Назад от последней страницы - снова вторая, от второй - первая без prev_cursor
+	prev, err := s.GetSongsPage(ctx, map[string]string{}, nil, pages[2].PrevCursor, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(prev); !slices.Equal(got, want[1]) || prev.NextCursor == "" || prev.PrevCursor == "" || prev.Total != nil {
+		t.Fatalf("previous page from the last one: %q %+v", got, prev)
+	}
+	first, err := s.GetSongsPage(ctx, map[string]string{}, nil, prev.PrevCursor, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(first); !slices.Equal(got, want[0]) || first.PrevCursor != "" {
+		t.Fatalf("first page going back: %q %+v", got, first)
+	}
+
+	bySong := []models.SortKey{{Field: models.SortSong}, {Field: models.SortID}}
+	if _, err := s.GetSongsPage(ctx, map[string]string{}, bySong, pages[0].NextCursor, 2, false); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("cursor with another sort: %v, want ErrInvalidCursor", err)
+	}
+
+	fuzzy := map[string]string{"song": "Uprisng", "songMatch": models.MatchFuzzy}
+	if _, err := s.GetSongsPage(ctx, fuzzy, nil, "", 2, false); !errors.Is(err, ErrCursorNotSupported) {
+		t.Fatalf("fuzzy filter without sort: %v, want ErrCursorNotSupported", err)
+	}
+	if _, err := s.GetSongsPage(ctx, fuzzy, bySong, "", 2, false); err != nil {
+		t.Fatalf("fuzzy filter with sort: %v", err)
+	}
+}
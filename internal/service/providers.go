@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/openapi"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Источники данных песни для настройки DETAILS_PROVIDERS
+const (
+	ProviderMusicInfo = "music-info"
+	ProviderFile      = "file"
+)
+
+// ErrDetailsNotFound - в источнике нет данных о песне
+var ErrDetailsNotFound = errors.New("song details not found")
+
+// DetailsProvider - источник даты выхода, текста и ссылки песни.
+// Если песня источнику неизвестна, SongDetails возвращает ErrDetailsNotFound;
+// незаполненные поля ответа берутся из следующих источников цепочки.
+type DetailsProvider interface {
+	Name() string
+	SongDetails(ctx context.Context, group, song string) (*models.SongDetails, error)
+}
+
+// releaseDateLayouts - форматы даты выхода, которые встречаются в источниках данных.
+// Даты через косую черту не принимаются: по ним нельзя понять, где день, а где месяц.
+var releaseDateLayouts = []string{"2006-01-02", "2.1.2006"}
+
+// normalizeReleaseDate приводит дату к формату YYYY-MM-DD; нераспознанная дата возвращается как есть
+func normalizeReleaseDate(date string) string {
+	date = strings.TrimSpace(date)
+	for _, layout := range releaseDateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return date
+}
+
+// musicInfoProvider получает данные из внешнего API music-info
+type musicInfoProvider struct {
+	client *openapi.MusicInfoClient
+}
+
+func (p *musicInfoProvider) Name() string {
+	return ProviderMusicInfo
+}
+
+func (p *musicInfoProvider) SongDetails(ctx context.Context, group, song string) (*models.SongDetails, error) {
+	resp, err := p.client.GetSongDetail(ctx, group, song)
+	if err != nil {
+		if openapi.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %v", ErrDetailsNotFound, err)
+		}
+		return nil, err
+	}
+
+	return &models.SongDetails{
+		ReleaseDate: normalizeReleaseDate(resp.ReleaseDate),
+		Text:        resp.Text,
+		Link:        resp.Link,
+	}, nil
+}
+
+// fileProvider отдает данные из JSON-файла в формате фикстур cmd/musicinfo-mock:
+// [{"group", "song", "releaseDate", "text", "link"}]. Файл читается один раз при запуске.
+type fileProvider struct {
+	songs map[string]models.SongDetails
+}
+
+func newFileProvider(path string) (*fileProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("details file: %w", err)
+	}
+
+	var list []struct {
+		Group       string `json:"group"`
+		Song        string `json:"song"`
+		ReleaseDate string `json:"releaseDate"`
+		Text        string `json:"text"`
+		Link        string `json:"link"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("details file %s: %w", path, err)
+	}
+
+	p := &fileProvider{songs: make(map[string]models.SongDetails, len(list))}
+	for _, item := range list {
+		p.songs[detailsKey(item.Group, item.Song)] = models.SongDetails{
+			ReleaseDate: normalizeReleaseDate(item.ReleaseDate),
+			Text:        item.Text,
+			Link:        item.Link,
+		}
+	}
+
+	return p, nil
+}
+
+func (p *fileProvider) Name() string {
+	return ProviderFile
+}
+
+func (p *fileProvider) SongDetails(ctx context.Context, group, song string) (*models.SongDetails, error) {
+	details, ok := p.songs[detailsKey(group, song)]
+	if !ok {
+		return nil, ErrDetailsNotFound
+	}
+	return &details, nil
+}
+
+// lookupSongDetails опрашивает источники по порядку, пока не заполнены все поля.
+// Каждое поле берется из первого источника, который его знает. Если какой-то
+// источник был недоступен и данных не хватает, возвращается его ошибка, чтобы
+// запрос можно было повторить.
+func (s *MusicLibService) lookupSongDetails(ctx context.Context, group, song string) (*models.SongDetails, error) {
+	s.log(ctx).WithFields(logrus.Fields{"group": group, "song": song}).Debug("Fetching song details")
+
+	details := &models.SongDetails{Sources: make(map[string]string)}
+	var providerErr error
+
+	for _, p := range s.providers {
+		if details.ReleaseDate != "" && details.Text != "" && details.Link != "" {
+			break
+		}
+
+		found, err := p.SongDetails(ctx, group, song)
+		if err != nil {
+			if !errors.Is(err, ErrDetailsNotFound) {
+				s.log(ctx).WithFields(logrus.Fields{"provider": p.Name(), "error": err.Error()}).Warn("Details provider failed")
+				if providerErr == nil {
+					providerErr = err
+				}
+			}
+			continue
+		}
+
+		for _, field := range []struct {
+			name  string
+			dst   *string
+			value string
+		}{
+			{models.DetailReleaseDate, &details.ReleaseDate, found.ReleaseDate},
+			{models.DetailText, &details.Text, found.Text},
+			{models.DetailLink, &details.Link, found.Link},
+		} {
+			if *field.dst == "" && field.value != "" {
+				*field.dst = field.value
+				details.Sources[field.name] = p.Name()
+			}
+		}
+	}
+
+	complete := details.ReleaseDate != "" && details.Text != "" && details.Link != ""
+	switch {
+	case providerErr != nil && !complete:
+		return nil, providerErr
+	case len(details.Sources) == 0:
+		return nil, ErrDetailsNotFound
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"group": group, "song": song, "sources": details.Sources}).Debug("Song details found")
+
+	return details, nil
+}
+
+// GetSongDetails возвращает дату выхода, текст и ссылку песни из цепочки источников DETAILS_PROVIDERS.
+// Ответы кэшируются, одновременные запросы одной песни выполняются одним обращением.
+func (s *MusicLibService) GetSongDetails(ctx context.Context, group, song string) (*models.SongDetails, error) {
+	if s.detailsCache == nil {
+		return s.lookupSongDetails(ctx, group, song)
+	}
+
+	details, err := s.detailsCache.get(ctx, group, song)
+	if err != nil {
+		return nil, err
+	}
+
+	details.Sources = maps.Clone(details.Sources)
+	return details, nil
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNormalizeReleaseDate(t *testing.T) {
+	for date, want := range map[string]string{
+		"2009-09-07":   "2009-09-07",
+		" 16.07.2006 ": "2006-07-16",
+		"5.1.1988":     "1988-01-05",
+		// День и месяц через косую черту неоднозначны и остаются как есть
+		"7/9/2009":   "7/9/2009",
+		"09/07/2009": "09/07/2009",
+		"31.02.2009": "31.02.2009",
+		"2009":       "2009",
+		"":           "",
+	} {
+		if got := normalizeReleaseDate(date); got != want {
+			t.Errorf("normalizeReleaseDate(%q) = %q, want %q", date, got, want)
+		}
+	}
+}
+
+// namedProvider - источник с заданным именем, который считает обращения к себе
+type namedProvider struct {
+	name    string
+	calls   int
+	details *models.SongDetails
+	err     error
+}
+
+func (p *namedProvider) Name() string {
+	return p.name
+}
+
+func (p *namedProvider) SongDetails(context.Context, string, string) (*models.SongDetails, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	details := *p.details
+	return &details, nil
+}
+
+func TestLookupSongDetailsChain(t *testing.T) {
+	unavailable := errors.New("connection refused")
+
+	for _, tc := range []struct {
+		name      string
+		providers []*namedProvider
+		want      *models.SongDetails
+		err       error
+		calls     []int
+	}{
+		{
+			name: "fields from the first provider that has them",
+			providers: []*namedProvider{
+				{name: "a", details: &models.SongDetails{ReleaseDate: "2009-09-07"}},
+				{name: "b", details: &models.SongDetails{ReleaseDate: "2010-01-01", Text: "Paranoia is in bloom"}},
+				{name: "c", details: &models.SongDetails{Link: "https://example.com/uprising", Text: "ignored"}},
+				{name: "d", details: &models.SongDetails{Link: "never asked"}},
+			},
+			want: &models.SongDetails{
+				ReleaseDate: "2009-09-07", Text: "Paranoia is in bloom", Link: "https://example.com/uprising",
+				Sources: map[string]string{models.DetailReleaseDate: "a", models.DetailText: "b", models.DetailLink: "c"},
+			},
+			calls: []int{1, 1, 1, 0},
+		},
+		{
+			name: "unavailable provider is skipped when the rest is enough",
+			providers: []*namedProvider{
+				{name: "a", err: unavailable},
+				{name: "b", err: ErrDetailsNotFound},
+				{name: "c", details: &models.SongDetails{ReleaseDate: "2009-09-07", Text: "x", Link: "y"}},
+			},
+			want: &models.SongDetails{
+				ReleaseDate: "2009-09-07", Text: "x", Link: "y",
+				Sources: map[string]string{models.DetailReleaseDate: "c", models.DetailText: "c", models.DetailLink: "c"},
+			},
+			calls: []int{1, 1, 1},
+		},
+		{
+			name: "partial details with an unavailable provider are retried",
+			providers: []*namedProvider{
+				{name: "a", err: unavailable},
+				{name: "b", details: &models.SongDetails{ReleaseDate: "2009-09-07"}},
+			},
+			err:   unavailable,
+			calls: []int{1, 1},
+		},
+		{
+			name: "partial details without errors are returned",
+			providers: []*namedProvider{
+				{name: "a", err: ErrDetailsNotFound},
+				{name: "b", details: &models.SongDetails{Link: "y"}},
+			},
+			want:  &models.SongDetails{Link: "y", Sources: map[string]string{models.DetailLink: "b"}},
+			calls: []int{1, 1},
+		},
+		{
+			name:      "nobody knows the song",
+			providers: []*namedProvider{{name: "a", err: ErrDetailsNotFound}, {name: "b", details: &models.SongDetails{}}},
+			err:       ErrDetailsNotFound,
+			calls:     []int{1, 1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, _ := newTestService(t, Options{DetailsProviders: []string{ProviderFile}, DetailsFile: writeDetailsFile(t, `[]`)})
+			s.providers = nil
+			for _, p := range tc.providers {
+				s.providers = append(s.providers, p)
+			}
+
+			details, err := s.lookupSongDetails(context.Background(), "Muse", "Uprising")
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("error %v, want %v", err, tc.err)
+			}
+			if !reflect.DeepEqual(details, tc.want) {
+				t.Fatalf("details %+v, want %+v", details, tc.want)
+			}
+			for i, p := range tc.providers {
+				if p.calls != tc.calls[i] {
+					t.Errorf("provider %s called %d times, want %d", p.name, p.calls, tc.calls[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	p, err := newFileProvider(writeDetailsFile(t, `[
+		{"group": "Muse", "song": "Uprising", "releaseDate": "07.09.2009", "text": "Paranoia is in bloom", "link": "https://example.com/uprising"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	details, err := p.SongDetails(context.Background(), " muse", "UPRISING ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.ReleaseDate != "2009-09-07" || details.Text != "Paranoia is in bloom" {
+		t.Fatalf("details: %+v", details)
+	}
+	if _, err := p.SongDetails(context.Background(), "Muse", "Hysteria"); !errors.Is(err, ErrDetailsNotFound) {
+		t.Fatalf("missing song: %v", err)
+	}
+
+	if _, err := newFileProvider(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("missing file was accepted")
+	}
+	if _, err := newFileProvider(writeDetailsFile(t, `{"group": "Muse"}`)); err == nil {
+		t.Fatal("malformed file was accepted")
+	}
+}
+
+func TestNewSongServiceProviders(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, opts := range []Options{
+		{DetailsProviders: []string{"spotify"}},
+		{DetailsProviders: []string{ProviderFile}},
+	} {
+		if _, err := NewSongService(repository.NewMemoryStore(), logger, opts); err == nil {
+			t.Errorf("providers %v were accepted", opts.DetailsProviders)
+		}
+	}
+}
+
+// music-info не знает песню, и ее данные берутся из файла следующим источником цепочки
+func TestMusicInfoFallsBackToFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("song") == "Uprising" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"releaseDate": "07.09.2009", "text": "", "link": "https://example.com/uprising"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ := newTestService(t, Options{
+		MusicAPIHost:     u.Host,
+		MusicBaseURL:     srv.URL,
+		DetailsProviders: []string{ProviderMusicInfo, ProviderFile},
+		DetailsFile: writeDetailsFile(t, `[
+			{"group": "Muse", "song": "Uprising", "releaseDate": "2000-01-01", "text": "Paranoia is in bloom", "link": "x"},
+			{"group": "Muse", "song": "Hysteria", "releaseDate": "2003-12-01", "text": "It's bugging me", "link": "https://example.com/hysteria"}
+		]`),
+	})
+
+	details, err := s.GetSongDetails(context.Background(), "Muse", "Uprising")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{models.DetailReleaseDate: ProviderMusicInfo, models.DetailText: ProviderFile, models.DetailLink: ProviderMusicInfo}
+	if details.ReleaseDate != "2009-09-07" || details.Text != "Paranoia is in bloom" || !maps.Equal(details.Sources, want) {
+		t.Fatalf("Uprising: %+v", details)
+	}
+
+	details, err = s.GetSongDetails(context.Background(), "Muse", "Hysteria")
+	if err != nil || details.Sources[models.DetailLink] != ProviderFile {
+		t.Fatalf("Hysteria: %+v, %v", details, err)
+	}
+
+	if _, err := s.GetSongDetails(context.Background(), "Muse", "Resistance"); !errors.Is(err, ErrDetailsNotFound) {
+		t.Fatalf("Resistance: %v", err)
+	}
+}
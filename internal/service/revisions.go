@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxDiffLines - сколько различающихся строк с каждой стороны может сравнить diffLines:
+// таблица подпоследовательностей занимает (maxDiffLines+1)^2 ячеек
+const maxDiffLines = 1000
+
+// ErrDiffTooLarge возвращается, если тексты ревизий слишком велики для построчного сравнения
+var ErrDiffTooLarge = errors.New("revision texts are too large to diff")
+
+// GetSongRevisions возвращает ревизии песни от новых к старым
+func (s *MusicLibService) GetSongRevisions(ctx context.Context, id uint, limit, offset int) ([]models.SongRevision, error) {
+	s.log(ctx).WithFields(logrus.Fields{"id": id, "limit": limit, "offset": offset}).Debug("Fetching song revisions")
+
+	revisions, err := s.repo.GetSongRevisions(id, limit, offset)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// GetSongRevision возвращает ревизию песни вместе с текстом
+func (s *MusicLibService) GetSongRevision(ctx context.Context, id uint, rev int) (*models.SongRevision, error) {
+	s.log(ctx).WithFields(logrus.Fields{"id": id, "rev": rev}).Debug("Fetching song revision")
+
+	revision, err := s.repo.GetSongRevision(id, rev)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+// DiffRevisions сравнивает ревизии from и to песни. Если from = 0, берется ревизия перед to;
+// первая ревизия сравнивается с пустой песней.
+func (s *MusicLibService) DiffRevisions(ctx context.Context, id uint, from, to int) (*models.RevisionDiff, error) {
+	s.log(ctx).WithFields(logrus.Fields{"id": id, "from": from, "to": to}).Debug("Diffing song revisions")
+
+	newRev, err := s.GetSongRevision(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == 0 {
+		from = to - 1
+	}
+	oldRev := &models.SongRevision{}
+	if from > 0 {
+		if oldRev, err = s.GetSongRevision(ctx, id, from); err != nil {
+			return nil, err
+		}
+	}
+
+	diff := &models.RevisionDiff{SongID: id, From: from, To: to, Fields: []models.FieldChange{}}
+	for _, field := range []models.FieldChange{
+		{Field: "group", From: oldRev.Group, To: newRev.Group},
+		{Field: "song", From: oldRev.Song, To: newRev.Song},
+		{Field: "release_date", From: oldRev.ReleaseDate, To: newRev.ReleaseDate},
+		{Field: "link", From: oldRev.Link, To: newRev.Link},
+	} {
+		if field.From != field.To {
+			diff.Fields = append(diff.Fields, field)
+		}
+	}
+	if diff.Lines, err = diffLines(revisionLines(oldRev.Verses), revisionLines(newRev.Verses)); err != nil {
+		return nil, err
+	}
+
+	return diff, nil
+}
+
+// revisionLines возвращает строки текста ревизии так, как их вводит пользователь:
+// куплеты через пустую строку, тип куплета - меткой перед ним
+func revisionLines(verses []models.Verse) []string {
+	if len(verses) == 0 {
+		return nil
+	}
+	rendered := make([]string, len(verses))
+	for i, v := range verses {
+		rendered[i] = v.Rendered()
+	}
+	return strings.Split(strings.Join(rendered, "\n\n"), "\n")
+}
+
+// diffLines строит построчный дифф по наибольшей общей подпоследовательности. Общие начало
+// и конец текстов в таблицу не попадают; если в остатке больше maxDiffLines строк с какой-либо
+// стороны, возвращается ErrDiffTooLarge.
+func diffLines(a, b []string) ([]models.DiffLine, error) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := []models.DiffLine{}
+	for _, text := range a[:prefix] {
+		lines = append(lines, models.DiffLine{Op: models.DiffEqual, Text: text})
+	}
+	tail := a[len(a)-suffix:]
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(a) > maxDiffLines || len(b) > maxDiffLines {
+		return nil, fmt.Errorf("%w: %d and %d changed lines, at most %d are compared", ErrDiffTooLarge, len(a), len(b), maxDiffLines)
+	}
+
+	// lcs[i][j] - длина общей подпоследовательности a[i:] и b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, models.DiffLine{Op: models.DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, models.DiffLine{Op: models.DiffRemoved, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, models.DiffLine{Op: models.DiffAdded, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, models.DiffLine{Op: models.DiffRemoved, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, models.DiffLine{Op: models.DiffAdded, Text: b[j]})
+	}
+	for _, text := range tail {
+		lines = append(lines, models.DiffLine{Op: models.DiffEqual, Text: text})
+	}
+
+	return lines, nil
+}
+
+// RevertSong возвращает песню к состоянию ревизии rev. Откат записывается новой ревизией,
+// история не переписывается. Пустая дата выхода в ревизии не стирает текущую.
+// Группа восстанавливается по id под ее текущим названием, чтобы откат к ревизии
+// до переименования группы не создавал группу со старым названием.
+func (s *MusicLibService) RevertSong(ctx context.Context, id uint, rev int) error {
+	s.log(ctx).WithFields(logrus.Fields{"id": id, "rev": rev}).Debug("Reverting song")
+
+	revision, err := s.GetSongRevision(ctx, id, rev)
+	if err != nil {
+		return err
+	}
+
+	group := revision.Group
+	if revision.GroupID != 0 {
+		current, err := s.repo.GetGroupByID(revision.GroupID)
+		switch {
+		case err == nil:
+			group = current.Name
+		case !errors.Is(err, sql.ErrNoRows):
+			s.log(ctx).Error(err)
+			return err
+		}
+	}
+
+	patch := &models.SongPatch{
+		Group: &group,
+		Song:  &revision.Song,
+		Link:  &revision.Link,
+	}
+	if revision.ReleaseDate != "" {
+		patch.ReleaseDate = &revision.ReleaseDate
+	}
+
+	if err := s.repo.UpdateSongByID(id, patch, revision.Verses, changeMeta(ctx)); err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"id": id, "rev": rev}).Debug("Song reverted")
+
+	return nil
+}
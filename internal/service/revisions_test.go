@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	eq := func(text string) models.DiffLine { return models.DiffLine{Op: models.DiffEqual, Text: text} }
+	add := func(text string) models.DiffLine { return models.DiffLine{Op: models.DiffAdded, Text: text} }
+	del := func(text string) models.DiffLine { return models.DiffLine{Op: models.DiffRemoved, Text: text} }
+
+	for _, tc := range []struct {
+		a, b string
+		want []models.DiffLine
+	}{
+		{"", "", []models.DiffLine{}},
+		{"", "a\nb", []models.DiffLine{add("a"), add("b")}},
+		{"a\nb", "", []models.DiffLine{del("a"), del("b")}},
+		{"a\nb\nc", "a\nb\nc", []models.DiffLine{eq("a"), eq("b"), eq("c")}},
+		{"a\nb\nc\nd", "a\nx\nc\nd", []models.DiffLine{eq("a"), del("b"), add("x"), eq("c"), eq("d")}},
+		{"a\nb\nc", "b\nc\nd", []models.DiffLine{del("a"), eq("b"), eq("c"), add("d")}},
+		// Общие начало и конец не дублируются, даже если пересекаются
+		{"a\na", "a\na\na", []models.DiffLine{eq("a"), eq("a"), add("a")}},
+		{"x\na\ny\nb\nz", "a\nq\nb", []models.DiffLine{del("x"), eq("a"), del("y"), add("q"), eq("b"), del("z")}},
+	} {
+		got, err := diffLines(split(tc.a), split(tc.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("diffLines(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func split(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+func TestDiffLinesLimit(t *testing.T) {
+	numbered := func(prefix string, n int) []string {
+		lines := make([]string, n)
+		for i := range lines {
+			lines[i] = fmt.Sprintf("%s %d", prefix, i)
+		}
+		return lines
+	}
+
+	// Одинаковые начало и конец не считаются, сколько бы их ни было
+	common := numbered("same", 5*maxDiffLines)
+	a := append(append(append([]string{}, common...), numbered("old", maxDiffLines)...), common...)
+	b := append(append(append([]string{}, common...), numbered("new", maxDiffLines)...), common...)
+	lines, err := diffLines(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 12*maxDiffLines {
+		t.Fatalf("%d diff lines", len(lines))
+	}
+
+	if _, err := diffLines(a, append(numbered("new", maxDiffLines+1), a...)); !errors.Is(err, ErrDiffTooLarge) {
+		t.Fatalf("diff over the limit: %v", err)
+	}
+}
+
+func TestDiffAndRevertRevisions(t *testing.T) {
+	s, store := newTestService(t, Options{})
+	ctx := WithActor(context.Background(), "ed")
+
+	song := &models.Song{Group: "Muse", Song: "Madness", ReleaseDate: "2012-08-20", Link: "https://example.com/madness", Text: "I, I can't get these memories out of my mind"}
+	if err := s.SaveSong(ctx, song); err != nil {
+		t.Fatal(err)
+	}
+	link := "https://example.com/madness-live"
+	if err := s.PatchSong(ctx, song.ID, &models.SongPatch{Link: &link}); err != nil {
+		t.Fatal(err)
+	}
+	text := "I, I can't get these memories out of my mind\n\nAnd some kind of madness"
+	if err := s.PatchSong(ctx, song.ID, &models.SongPatch{Text: &text}); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := s.GetSongRevisions(ctx, song.ID, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 3 || revisions[0].Rev != 3 || revisions[0].Author != "ed" {
+		t.Fatalf("revisions: %+v", revisions)
+	}
+
+	diff, err := s.DiffRevisions(ctx, song.ID, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.DiffLine{
+		{Op: models.DiffEqual, Text: "I, I can't get these memories out of my mind"},
+		{Op: models.DiffAdded, Text: ""},
+		{Op: models.DiffAdded, Text: "And some kind of madness"},
+	}
+	if diff.From != 2 || len(diff.Fields) != 0 || !reflect.DeepEqual(diff.Lines, want) {
+		t.Fatalf("diff 2..3: %+v", diff)
+	}
+
+	diff, err = s.DiffRevisions(ctx, song.ID, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Fields) != 1 || diff.Fields[0] != (models.FieldChange{Field: "link", From: "https://example.com/madness", To: link}) {
+		t.Fatalf("diff 1..2: %+v", diff)
+	}
+
+	// Первая ревизия сравнивается с пустой песней
+	diff, err = s.DiffRevisions(ctx, song.ID, 0, 1)
+	if err != nil || diff.From != 0 || len(diff.Fields) != 4 {
+		t.Fatalf("diff of the first revision: %+v, %v", diff, err)
+	}
+
+	if err := s.RevertSong(ctx, song.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetSongByID(song.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Link != "https://example.com/madness" || got.Text != "I, I can't get these memories out of my mind" {
+		t.Fatalf("song after revert: %+v", got)
+	}
+	if revisions, _ = s.GetSongRevisions(ctx, song.ID, 100, 0); len(revisions) != 4 {
+		t.Fatalf("revert was not recorded as a revision: %d revisions", len(revisions))
+	}
+
+	if _, err := s.DiffRevisions(ctx, song.ID, 0, 9); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("diff of a missing revision: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrUnsupportedLanguage   = errors.New("unsupported search language")
+	ErrUnsupportedSearchMode = errors.New("unsupported search mode")
+)
+
+var searchLanguages = map[string]string{
+	"":                       models.SearchLangSimple,
+	models.SearchLangSimple:  models.SearchLangSimple,
+	"en":                     models.SearchLangEnglish,
+	models.SearchLangEnglish: models.SearchLangEnglish,
+	"ru":                     models.SearchLangRussian,
+	models.SearchLangRussian: models.SearchLangRussian,
+}
+
+// SearchLyrics ищет куплеты по запросу. lang - simple (без морфологии, по умолчанию),
+// en/english или ru/russian; mode - websearch (по умолчанию), plain или phrase.
+func (s *MusicLibService) SearchLyrics(ctx context.Context, query, lang, mode string, limit, offset int) ([]models.SearchHit, error) {
+	s.log(ctx).WithFields(logrus.Fields{"query": query, "lang": lang, "mode": mode, "limit": limit, "offset": offset}).Debug("Searching lyrics")
+
+	language, ok := searchLanguages[strings.ToLower(lang)]
+	if !ok {
+		return nil, ErrUnsupportedLanguage
+	}
+
+	switch mode {
+	case "":
+		mode = models.SearchModeWebsearch
+	case models.SearchModePlain, models.SearchModePhrase, models.SearchModeWebsearch:
+	default:
+		return nil, ErrUnsupportedSearchMode
+	}
+
+	hits, err := s.repo.SearchLyrics(models.SearchQuery{Query: query, Language: language, Mode: mode}, limit, offset)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"hits": len(hits)}).Debug("Lyrics search finished")
+
+	return hits, nil
+}
@@ -1,143 +1,293 @@
 package service
 
 import (
-	openapiMusic "mikromolekula2002/music_library_ver1.0/apiAutoGenerated/MusicInfo"
+	"context"
+	"errors"
+	"fmt"
 	"mikromolekula2002/music_library_ver1.0/internal/models"
 	"mikromolekula2002/music_library_ver1.0/internal/openapi"
 	"mikromolekula2002/music_library_ver1.0/internal/repository"
-	"net/url"
+	"mikromolekula2002/music_library_ver1.0/pkg/logger"
 	"regexp"
-	"strings"
+	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
 var dateRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
 
 type MusicLibService struct {
-	repo    *repository.Repository
-	Logger  *logrus.Logger
-	openAPI *openapiMusic.APIClient
+	repo              repository.SongStore
+	Logger            *logrus.Logger
+	musicInfo         *openapi.MusicInfoClient
+	providers         []DetailsProvider
+	detailsCache      *detailsCache
+	importConcurrency int
+	enrichment        EnrichmentOptions
+	enrichmentWake    chan struct{}
+	trash             TrashOptions
 }
 
-func NewSongService(repo *repository.Repository, logger *logrus.Logger, musicAPIHost, musicBaseURL string) *MusicLibService {
-	return &MusicLibService{
-		repo:    repo,
-		Logger:  logger,
-		openAPI: openapi.MakeMusicAPIClient(musicAPIHost, musicBaseURL),
+// Options - настройки сервиса из конфигурации; нулевые значения заменяются значениями по умолчанию
+type Options struct {
+	MusicAPIHost string
+	MusicBaseURL string
+	// MusicAPI - тайм-аут, повторы и выключатель клиента music-info
+	MusicAPI openapi.ClientOptions
+	// DetailsProviders - порядок опроса источников данных песни (ProviderMusicInfo, ProviderFile);
+	// по умолчанию только music-info
+	DetailsProviders []string
+	// DetailsFile - JSON-файл источника ProviderFile
+	DetailsFile  string
+	DetailsCache DetailsCacheOptions
+	// ImportConcurrency - сколько запросов к внешнему API одновременно выполняет импорт
+	ImportConcurrency int
+	Enrichment        EnrichmentOptions
+	Trash             TrashOptions
+}
+
+func NewSongService(repo repository.SongStore, logger *logrus.Logger, opts Options) (*MusicLibService, error) {
+	if opts.ImportConcurrency <= 0 {
+		opts.ImportConcurrency = defaultImportConcurrency
+	}
+
+	s := &MusicLibService{
+		repo:              repo,
+		Logger:            logger,
+		importConcurrency: opts.ImportConcurrency,
+		enrichment:        opts.Enrichment.withDefaults(),
+		enrichmentWake:    make(chan struct{}, 1),
+		trash:             opts.Trash.withDefaults(),
+	}
+	s.detailsCache = newDetailsCache(opts.DetailsCache, logger, s.lookupSongDetails)
+
+	musicAPI := opts.MusicAPI
+	onStateChange := musicAPI.OnStateChange
+	musicAPI.OnStateChange = func(from, to openapi.BreakerState) {
+		fields := logrus.Fields{"from": from.String(), "to": to.String()}
+		if to == openapi.BreakerOpen {
+			s.Logger.WithFields(fields).Warn("Music info circuit breaker opened")
+		} else {
+			s.Logger.WithFields(fields).Info("Music info circuit breaker state changed")
+		}
+		if onStateChange != nil {
+			onStateChange(from, to)
+		}
 	}
+	if musicAPI.OnRetry == nil {
+		musicAPI.OnRetry = func(ctx context.Context, attempt int, err error, delay time.Duration) {
+			s.log(ctx).WithFields(logrus.Fields{"attempt": attempt, "error": err.Error(), "delay": delay.String()}).Debug("Retrying music info request")
+		}
+	}
+
+	if len(opts.DetailsProviders) == 0 {
+		opts.DetailsProviders = []string{ProviderMusicInfo}
+	}
+	for _, name := range opts.DetailsProviders {
+		switch name {
+		case ProviderMusicInfo:
+			s.musicInfo = openapi.NewMusicInfoClient(opts.MusicAPIHost, opts.MusicBaseURL, musicAPI)
+			s.providers = append(s.providers, &musicInfoProvider{client: s.musicInfo})
+		case ProviderFile:
+			provider, err := newFileProvider(opts.DetailsFile)
+			if err != nil {
+				return nil, err
+			}
+			s.providers = append(s.providers, provider)
+		default:
+			return nil, fmt.Errorf("unknown details provider %q", name)
+		}
+	}
+
+	return s, nil
 }
 
-func (s *MusicLibService) SaveSong(song *models.Song) error {
+// MusicInfoBreakerState возвращает состояние выключателя запросов к music-info;
+// если music-info не входит в цепочку источников, выключатель всегда закрыт
+func (s *MusicLibService) MusicInfoBreakerState() openapi.BreakerState {
+	if s.musicInfo == nil {
+		return openapi.BreakerClosed
+	}
+	return s.musicInfo.BreakerState()
+}
+
+// log возвращает запись лога запроса из ctx, чтобы логи сервиса можно было связать с запросом
+func (s *MusicLibService) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, s.Logger)
+}
 
+// SaveSong сохраняет песню целиком; автор первой ревизии берется из ctx
+func (s *MusicLibService) SaveSong(ctx context.Context, song *models.Song) error {
 	songID, err := s.repo.SaveSongInfo(song.Group, song.Song, song.ReleaseDate, song.Link)
 	if err != nil {
-		s.Logger.Error(err)
+		s.log(ctx).Error(err)
 		return err
 	}
 
-	s.Logger.Debug("Song info saved successfully", logrus.Fields{"songID": songID})
+	s.log(ctx).WithFields(logrus.Fields{"songID": songID}).Debug("Song info saved successfully")
 
 	song.ID = uint(songID)
 
-	verses := strings.Split(song.Text, "\n\n")
-	for _, val := range verses {
-		if err := s.repo.SaveSongText(song.ID, val); err != nil {
-			s.Logger.Error(err)
-			return err
-		}
+	// Как и раньше, пустой текст сохраняется одним пустым куплетом
+	verses := parseVerses(song.Text)
+	if len(verses) == 0 {
+		verses = []models.Verse{{Position: 1}}
+	}
+	if err := s.repo.SaveSongText(song.ID, verses, changeMeta(ctx)); err != nil {
+		s.log(ctx).Error(err)
+		return err
 	}
 
-	s.Logger.Debug("Song text saved successfully", logrus.Fields{"songID": songID})
+	s.log(ctx).WithFields(logrus.Fields{"songID": songID}).Debug("Song text saved successfully")
 
 	return nil
 }
 
-func (s *MusicLibService) GetSongDetailsFromAPI(group string, song string, ctx *gin.Context) (*models.Song, error) {
-	op := "service.GetSongDetailsFromAPI"
+// GetSongTextByGroup возвращает куплеты (by = verse) или строки (by = line) песни
+func (s *MusicLibService) GetSongTextByGroup(ctx context.Context, groupName, songName, by string, songLimit, songOffset int) ([]string, error) {
+	s.log(ctx).WithFields(logrus.Fields{"group": groupName, "song": songName, "by": by, "limit": songLimit, "offset": songOffset}).Debug("Fetching song text")
 
-	s.Logger.Debug("Fetching song text", logrus.Fields{
-		"group": group,
-		"song":  song,
-	})
+	songsParts, err := s.repo.GetSongTextByGroup(groupName, songName, by, songLimit, songOffset)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	return songsParts, nil
+}
+
+func (s *MusicLibService) UpdateSong(ctx context.Context, song *models.Song) error {
+	s.log(ctx).WithFields(logrus.Fields{"group": song.Group, "song": song.Song}).Debug("Updating song")
+
+	verses := parseVerses(song.Text)
 
-	params := url.Values{}
-	params.Add("group", group)
-	params.Add("song", song)
+	if err := s.repo.UpdateSong(song.Group, song.Song, song.ReleaseDate, song.Link, verses, changeMeta(ctx)); err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"group": song.Group, "song": song.Song}).Debug("Song updated")
+
+	return nil
+}
 
-	resp, _, err := s.openAPI.DefaultAPI.InfoGet(ctx).Group(group).Song(song).Execute()
+func (s *MusicLibService) DeleteSong(ctx context.Context, groupName, songName string) error {
+	if err := s.repo.DeleteSong(groupName, songName, changeMeta(ctx)); err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"group": groupName, "song": songName}).Debug("Song deleted")
+
+	return nil
+}
+
+func (s *MusicLibService) GetAllSongs(ctx context.Context, filter map[string]string, sort []models.SortKey, limit, offset int) ([]models.Song, error) {
+	s.log(ctx).WithFields(logrus.Fields{"limit": limit, "offset": offset, "filters": filter, "sort": sort}).Debug("Fetching all songs")
+
+	songs, err := s.repo.GetSongs(filter, sort, limit, offset)
 	if err != nil {
-		s.Logger.Errorf("%s: %v", op, err)
+		s.log(ctx).Error(err)
 		return nil, err
 	}
 
-	songData := &models.Song{
-		Group:       group,
-		Song:        song,
-		Text:        resp.Text,
-		Link:        resp.Link,
-		ReleaseDate: resp.ReleaseDate,
-	}
+	s.log(ctx).WithFields(logrus.Fields{"count": len(songs)}).Debug("Fetched songs count") // Непонятный лог, он не кол-во песен логирует, а соджержимое одной песни
 
-	return songData, nil
+	return songs, nil
 }
 
-func (s *MusicLibService) GetSongTextByGroup(groupName, songName string, songLimit, songOffset int) ([]string, error) {
-	s.Logger.Debug("Fetching song text", logrus.Fields{"group": groupName, "song": songName, "limit": songLimit, "offset": songOffset})
+func (s *MusicLibService) GetSongByID(ctx context.Context, id uint) (*models.Song, error) {
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Fetching song")
 
-	songsParts, err := s.repo.GetSongTextByGroup(groupName, songName, songLimit, songOffset)
+	song, err := s.repo.GetSongByID(id)
 	if err != nil {
-		s.Logger.Error(err)
+		s.log(ctx).Error(err)
 		return nil, err
 	}
 
-	return songsParts, nil
+	return song, nil
 }
 
-func (s *MusicLibService) UpdateSong(song *models.Song) error {
-	s.Logger.Debug("Updating song", logrus.Fields{"group": song.Group, "song": song.Song})
+// GetSongTextByID возвращает куплеты (by = verse) или строки (by = line) песни
+func (s *MusicLibService) GetSongTextByID(ctx context.Context, id uint, by string, limit, offset int) (*models.SongTextResp, error) {
+	s.log(ctx).WithFields(logrus.Fields{"id": id, "by": by, "limit": limit, "offset": offset}).Debug("Fetching song text")
 
-	verses := []string{}
-	if song.Text != "" {
-		verses = strings.Split(song.Text, "\n\n")
+	text, err := s.repo.GetSongTextByID(id, by, limit, offset)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
 	}
 
-	if err := s.repo.UpdateSong(song.Group, song.Song, song.ReleaseDate, song.Link, verses); err != nil {
-		s.Logger.Error(err)
-		return err
+	return text, nil
+}
+
+func (s *MusicLibService) GetVerse(ctx context.Context, id uint, n int) (*models.VerseResp, error) {
+	s.log(ctx).WithFields(logrus.Fields{"id": id, "verse": n}).Debug("Fetching verse")
+
+	verse, err := s.repo.GetVerse(id, n)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
 	}
 
-	s.Logger.Debug("Song updated", logrus.Fields{"group": song.Group, "song": song.Song})
+	return &models.VerseResp{SongID: id, Verse: n, Type: verse.Type, RepeatOf: verse.RepeatOf, Text: verse.Text}, nil
+}
 
-	return nil
+// ReplaceSong полностью заменяет данные песни, включая текст
+func (s *MusicLibService) ReplaceSong(ctx context.Context, id uint, song *models.Song) error {
+	return s.PatchSong(ctx, id, &models.SongPatch{
+		Group:       &song.Group,
+		Song:        &song.Song,
+		ReleaseDate: &song.ReleaseDate,
+		Text:        &song.Text,
+		Link:        &song.Link,
+	})
 }
 
-func (s *MusicLibService) DeleteSong(groupName, songName string) error {
-	if err := s.repo.DeleteSong(groupName, songName); err != nil {
-		s.Logger.Error(err)
+// PatchSong обновляет только переданные поля песни
+func (s *MusicLibService) PatchSong(ctx context.Context, id uint, patch *models.SongPatch) error {
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Updating song")
+
+	var verses []models.Verse
+	switch {
+	case patch.LRC != nil:
+		var err error
+		if verses, err = parseLRC(*patch.LRC); err != nil {
+			return err
+		}
+	case patch.Text != nil:
+		verses = parseVerses(*patch.Text)
+	}
+
+	if err := s.repo.UpdateSongByID(id, patch, verses, changeMeta(ctx)); err != nil {
+		s.log(ctx).Error(err)
 		return err
 	}
 
-	s.Logger.Debug("Song deleted", logrus.Fields{"group": groupName, "song": songName})
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Song updated")
 
 	return nil
 }
 
-func (s *MusicLibService) GetAllSongs(filter map[string]string, limit, offset int) ([]models.Song, error) {
-	s.Logger.Debug("Fetching all songs", logrus.Fields{"limit": limit, "offset": offset, "filters": filter})
-
-	songs, err := s.repo.GetSongs(filter, limit, offset)
-	if err != nil {
-		s.Logger.Error(err)
-		return nil, err
+func (s *MusicLibService) DeleteSongByID(ctx context.Context, id uint) error {
+	if err := s.repo.DeleteSongByID(id, changeMeta(ctx)); err != nil {
+		s.log(ctx).Error(err)
+		return err
 	}
 
-	s.Logger.Debug("Fetched songs count", logrus.Fields{"count": len(songs)}) // Непонятный лог, он не кол-во песен логирует, а соджержимое одной песни
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Song deleted")
 
-	return songs, nil
+	return nil
 }
 
 func (s *MusicLibService) IsValidDate(date string) bool {
 	return dateRegex.MatchString(date)
 }
+
+// PingMusicInfo проверяет доступность music-info для проверки готовности
+func (s *MusicLibService) PingMusicInfo(ctx context.Context) error {
+	if s.musicInfo == nil {
+		return errors.New("music-info is not among the details providers")
+	}
+	return s.musicInfo.Ping(ctx)
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"io"
+	"mikromolekula2002/music_library_ver1.0/internal/repository"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newTestService создает сервис поверх хранилища в памяти без внешних источников данных
+func newTestService(t *testing.T, opts Options) (*MusicLibService, *repository.MemoryStore) {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	store := repository.NewMemoryStore()
+	s, err := NewSongService(store, logger, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, store
+}
+
+// writeDetailsFile сохраняет данные для источника file и возвращает путь к файлу
+func writeDetailsFile(t *testing.T, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "details.json")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
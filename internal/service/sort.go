@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"strings"
+)
+
+var ErrInvalidSort = errors.New("invalid sort parameter")
+
+// songSortFields - поля, по которым клиент может сортировать список песен
+var songSortFields = map[string]bool{
+	models.SortGroup:       true,
+	models.SortSong:        true,
+	models.SortReleaseDate: true,
+	models.SortCreatedAt:   true,
+	models.SortUpdatedAt:   true,
+	models.SortID:          true,
+}
+
+// ParseSongSort разбирает параметр сортировки вида "group,-release_date,song":
+// поля через запятую, "-" перед полем - по убыванию, без знака или "+" - по возрастанию.
+// Если id не указан, он добавляется последним ключом, чтобы порядок был однозначным.
+func ParseSongSort(spec string) ([]models.SortKey, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+
+	var keys []models.SortKey
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+
+		key := models.SortKey{Field: part}
+		switch {
+		case strings.HasPrefix(part, "-"):
+			key = models.SortKey{Field: part[1:], Desc: true}
+		case strings.HasPrefix(part, "+"):
+			key = models.SortKey{Field: part[1:]}
+		}
+
+		if !songSortFields[key.Field] {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidSort, key.Field)
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("%w: field %q is repeated", ErrInvalidSort, key.Field)
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+
+	if !seen[models.SortID] {
+		keys = append(keys, models.SortKey{Field: models.SortID, Desc: keys[len(keys)-1].Desc})
+	}
+
+	return keys, nil
+}
+
+// formatSongSort - каноническая запись сортировки, сохраняется в курсоре
+func formatSongSort(keys []models.SortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key.Field
+		if key.Desc {
+			parts[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestParseSongSort(t *testing.T) {
+	for _, tc := range []struct {
+		spec string
+		want []models.SortKey
+	}{
+		{"", nil},
+		{"  ", nil},
+		{"song", []models.SortKey{{Field: models.SortSong}, {Field: models.SortID}}},
+		// id добавляется в направлении последнего ключа
+		{"group, -release_date", []models.SortKey{{Field: models.SortGroup}, {Field: models.SortReleaseDate, Desc: true}, {Field: models.SortID, Desc: true}}},
+		{"+created_at,-id,song", []models.SortKey{{Field: models.SortCreatedAt}, {Field: models.SortID, Desc: true}, {Field: models.SortSong}}},
+		{"-updated_at", []models.SortKey{{Field: models.SortUpdatedAt, Desc: true}, {Field: models.SortID, Desc: true}}},
+	} {
+		got, err := ParseSongSort(tc.spec)
+		if err != nil {
+			t.Errorf("ParseSongSort(%q): %v", tc.spec, err)
+			continue
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("ParseSongSort(%q) = %v, want %v", tc.spec, got, tc.want)
+		}
+	}
+
+	for _, spec := range []string{"title", "song,-song", "song,", "--song", "group_name", "release_date;DROP TABLE song_info"} {
+		if _, err := ParseSongSort(spec); !errors.Is(err, ErrInvalidSort) {
+			t.Errorf("ParseSongSort(%q): %v, want ErrInvalidSort", spec, err)
+		}
+	}
+}
+
+func TestFormatSongSort(t *testing.T) {
+	keys, err := ParseSongSort("group,+song,-release_date")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := formatSongSort(keys)
+	if spec != "group,song,-release_date,-id" {
+		t.Fatalf("formatSongSort = %q", spec)
+	}
+
+	again, err := ParseSongSort(spec)
+	if err != nil || !slices.Equal(again, keys) {
+		t.Fatalf("ParseSongSort(formatSongSort) = %v, %v, want %v", again, err, keys)
+	}
+}
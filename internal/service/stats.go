@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+)
+
+// GetLibraryStats возвращает размер библиотеки: число песен, групп, альбомов и незавершенных задач
+func (s *MusicLibService) GetLibraryStats(ctx context.Context) (*models.LibraryStats, error) {
+	stats, err := s.repo.GetLibraryStats(ctx)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TrashOptions - настройки корзины удаленных песен
+type TrashOptions struct {
+	// Retention - сколько песня хранится в корзине до окончательного удаления; < 0 - без ограничения
+	Retention time.Duration
+	// PurgeInterval - как часто корзина очищается от песен с истекшим сроком хранения
+	PurgeInterval time.Duration
+}
+
+const (
+	defaultTrashRetention     = 30 * 24 * time.Hour
+	defaultTrashPurgeInterval = time.Hour
+)
+
+func (o TrashOptions) withDefaults() TrashOptions {
+	if o.Retention == 0 {
+		o.Retention = defaultTrashRetention
+	}
+	if o.PurgeInterval <= 0 {
+		o.PurgeInterval = defaultTrashPurgeInterval
+	}
+	return o
+}
+
+// GetDeletedSongs возвращает песни из корзины, сначала удаленные последними
+func (s *MusicLibService) GetDeletedSongs(ctx context.Context, limit, offset int) ([]models.Song, error) {
+	s.log(ctx).WithFields(logrus.Fields{"limit": limit, "offset": offset}).Debug("Fetching deleted songs")
+
+	songs, err := s.repo.GetDeletedSongs(limit, offset)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	return songs, nil
+}
+
+// RestoreSong возвращает песню из корзины вместе с текстом и историей
+func (s *MusicLibService) RestoreSong(ctx context.Context, id uint) error {
+	if err := s.repo.RestoreSong(id, changeMeta(ctx)); err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Song restored")
+
+	return nil
+}
+
+// PurgeSong окончательно удаляет песню из корзины
+func (s *MusicLibService) PurgeSong(ctx context.Context, id uint) error {
+	if err := s.repo.PurgeSong(id, changeMeta(ctx)); err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Song purged")
+
+	return nil
+}
+
+// RunTrashPurger запускает периодическую очистку корзины от песен с истекшим сроком хранения.
+// Она работает до отмены ctx; возвращаемая функция дожидается завершения текущей очистки.
+func (s *MusicLibService) RunTrashPurger(ctx context.Context) (wait func()) {
+	done := make(chan struct{})
+	if s.trash.Retention < 0 {
+		s.log(ctx).Info("Trash purge disabled")
+		close(done)
+		return func() { <-done }
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"retention": s.trash.Retention.String(), "interval": s.trash.PurgeInterval.String()}).Info("Starting trash purger")
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(s.trash.PurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			s.purgeTrash(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() { <-done }
+}
+
+func (s *MusicLibService) purgeTrash(ctx context.Context) {
+	meta := models.ChangeMeta{Actor: ActorRetention, Source: models.AuditSourceRetention}
+	purged, err := s.repo.PurgeDeletedSongs(s.trash.Retention, meta)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return
+	}
+	if purged > 0 {
+		s.log(ctx).WithFields(logrus.Fields{"songs": purged}).Info("Trash purged")
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"testing"
+	"time"
+)
+
+func TestTrashOptionsDefaults(t *testing.T) {
+	opts := TrashOptions{}.withDefaults()
+	if opts.Retention != defaultTrashRetention || opts.PurgeInterval != defaultTrashPurgeInterval {
+		t.Fatalf("defaults: %+v", opts)
+	}
+
+	// Отрицательный срок хранения отключает очистку и не заменяется значением по умолчанию
+	if opts = (TrashOptions{Retention: -1}).withDefaults(); opts.Retention != -1 {
+		t.Fatalf("disabled retention: %+v", opts)
+	}
+}
+
+func TestRunTrashPurger(t *testing.T) {
+	s, store := newTestService(t, Options{Trash: TrashOptions{Retention: time.Millisecond, PurgeInterval: 10 * time.Millisecond}})
+	ctx := context.Background()
+
+	song := &models.Song{Group: "Muse", Song: "Hysteria"}
+	if err := s.SaveSong(ctx, song); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteSongByID(ctx, song.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	wait := s.RunTrashPurger(ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		deleted, err := store.GetDeletedSongs(100, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deleted) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("song was not purged")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	wait()
+}
+
+func TestRunTrashPurgerDisabled(t *testing.T) {
+	s, store := newTestService(t, Options{Trash: TrashOptions{Retention: -1, PurgeInterval: time.Millisecond}})
+	ctx := context.Background()
+
+	song := &models.Song{Group: "Muse", Song: "Hysteria"}
+	if err := s.SaveSong(ctx, song); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteSongByID(ctx, song.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// При отключенной очистке wait возвращается сразу, а корзина не трогается
+	s.RunTrashPurger(ctx)()
+	time.Sleep(10 * time.Millisecond)
+	if deleted, err := store.GetDeletedSongs(100, 0); err != nil || len(deleted) != 1 {
+		t.Fatalf("trash: %+v, %v", deleted, err)
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidVerses возвращается, если куплеты заданы с ошибкой
+var ErrInvalidVerses = errors.New("invalid verses")
+
+// verseMarker - метка типа куплета в отдельной строке: [Chorus], [Verse 2], [Intro: Guitar]
+var verseMarker = regexp.MustCompile(`(?i)^\[\s*(verse|chorus|refrain|bridge|intro|outro)\b[^\]\n]*\][ \t]*$`)
+
+var verseTypes = map[string]struct{}{
+	models.VerseTypeVerse:  {},
+	models.VerseTypeChorus: {},
+	models.VerseTypeBridge: {},
+	models.VerseTypeIntro:  {},
+	models.VerseTypeOutro:  {},
+}
+
+// parseVerses разбивает текст на куплеты по пустой строке. Строка-метка вроде [Chorus]
+// задает тип следующего за ней куплета и сама начинает новый куплет. Дословный повтор
+// куплета того же типа сохраняется ссылкой на первое вхождение. Пустой текст дает
+// пустой (не nil) срез.
+func parseVerses(text string) []models.Verse {
+	verses := []models.Verse{}
+	if text == "" {
+		return verses
+	}
+
+	for _, block := range strings.Split(text, "\n\n") {
+		current := models.Verse{}
+		var lines []string
+		flush := func() {
+			current.Text = strings.Join(lines, "\n")
+			verses = append(verses, current)
+		}
+
+		for _, line := range strings.Split(block, "\n") {
+			m := verseMarker.FindStringSubmatch(line)
+			if m == nil {
+				lines = append(lines, line)
+				continue
+			}
+			if lines != nil || current.Type != "" {
+				flush()
+			}
+			current, lines = models.Verse{Type: verseTypeOf(m[1])}, nil
+		}
+		flush()
+	}
+
+	return markRepeats(verses)
+}
+
+func verseTypeOf(marker string) string {
+	marker = strings.ToLower(marker)
+	if marker == "refrain" {
+		return models.VerseTypeChorus
+	}
+	return marker
+}
+
+// markRepeats нумерует куплеты и заменяет повторы ссылками на первое вхождение.
+// Время строк у повтора остается свое.
+func markRepeats(verses []models.Verse) []models.Verse {
+	type verseKey struct{ verseType, text string }
+	first := make(map[verseKey]int)
+	for i := range verses {
+		v := &verses[i]
+		v.Position = i + 1
+		if v.Text == "" || v.RepeatOf != 0 {
+			continue
+		}
+
+		key := verseKey{v.Type, v.Text}
+		if pos, ok := first[key]; ok {
+			v.RepeatOf = pos
+			continue
+		}
+		first[key] = v.Position
+	}
+	return verses
+}
+
+// normalizeVerses проверяет явно заданные куплеты и нумерует их по порядку.
+// Повтор должен ссылаться на более ранний куплет, который сам не является повтором;
+// тип и текст повтора, если указаны, должны совпадать с исходным.
+func normalizeVerses(verses []models.Verse) ([]models.Verse, error) {
+	normalized := make([]models.Verse, len(verses))
+	for i, v := range verses {
+		v.Position = i + 1
+		v.Type = strings.ToLower(strings.TrimSpace(v.Type))
+		if v.Type != "" {
+			if _, ok := verseTypes[v.Type]; !ok {
+				return nil, fmt.Errorf("%w: verse %d: unknown type %q", ErrInvalidVerses, v.Position, v.Type)
+			}
+		}
+
+		if v.RepeatOf != 0 {
+			if v.RepeatOf < 1 || v.RepeatOf >= v.Position {
+				return nil, fmt.Errorf("%w: verse %d: repeat_of must point to an earlier verse", ErrInvalidVerses, v.Position)
+			}
+			orig := normalized[v.RepeatOf-1]
+			switch {
+			case orig.RepeatOf != 0:
+				return nil, fmt.Errorf("%w: verse %d: repeat_of must point to an original verse, not a repeat", ErrInvalidVerses, v.Position)
+			case v.Type != "" && v.Type != orig.Type, v.Text != "" && v.Text != orig.Text:
+				return nil, fmt.Errorf("%w: verse %d: type and text of a repeat must match verse %d", ErrInvalidVerses, v.Position, orig.Position)
+			}
+			v.Type, v.Text = orig.Type, orig.Text
+		}
+
+		if len(v.LineTimes) > 0 {
+			if lines := strings.Count(v.Text, "\n") + 1; len(v.LineTimes) != lines {
+				return nil, fmt.Errorf("%w: verse %d: line_times has %d values for %d lines", ErrInvalidVerses, v.Position, len(v.LineTimes), lines)
+			}
+			for _, t := range v.LineTimes {
+				if t < 0 {
+					return nil, fmt.Errorf("%w: verse %d: line_times must not be negative", ErrInvalidVerses, v.Position)
+				}
+			}
+		}
+
+		normalized[i] = v
+	}
+	return normalized, nil
+}
+
+// GetSongVerses возвращает куплеты песни с типами и ссылками на повторы
+func (s *MusicLibService) GetSongVerses(ctx context.Context, id uint, limit, offset int) (*models.SongVersesResp, error) {
+	s.log(ctx).WithFields(logrus.Fields{"id": id, "limit": limit, "offset": offset}).Debug("Fetching song verses")
+
+	verses, err := s.repo.GetSongVerses(id, limit, offset)
+	if err != nil {
+		s.log(ctx).Error(err)
+		return nil, err
+	}
+
+	return verses, nil
+}
+
+// SetSongVerses полностью заменяет текст песни явно заданными куплетами
+func (s *MusicLibService) SetSongVerses(ctx context.Context, id uint, verses []models.Verse) error {
+	s.log(ctx).WithFields(logrus.Fields{"id": id, "verses": len(verses)}).Debug("Setting song verses")
+
+	normalized, err := normalizeVerses(verses)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateSongByID(id, &models.SongPatch{}, normalized, changeMeta(ctx)); err != nil {
+		s.log(ctx).Error(err)
+		return err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{"id": id}).Debug("Song verses updated")
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"mikromolekula2002/music_library_ver1.0/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestParseVerses(t *testing.T) {
+	text := "Far away\nThe ship is taking me far away\n\n" +
+		"[Chorus]\nMy life\nYou electrify my life\n\n" +
+		"[Verse 2]\nOur hopes and expectations\n[Refrain]\nMy life\nYou electrify my life\n\n" +
+		"Far away\nThe ship is taking me far away\n\n" +
+		"[Intro: Guitar]\n\n" +
+		"[Prologue]\nnot a marker"
+
+	want := []models.Verse{
+		{Position: 1, Text: "Far away\nThe ship is taking me far away"},
+		{Position: 2, Type: models.VerseTypeChorus, Text: "My life\nYou electrify my life"},
+		{Position: 3, Type: models.VerseTypeVerse, Text: "Our hopes and expectations"},
+		// [Refrain] - тот же припев и сохраняется ссылкой на первое вхождение
+		{Position: 4, Type: models.VerseTypeChorus, RepeatOf: 2, Text: "My life\nYou electrify my life"},
+		{Position: 5, RepeatOf: 1, Text: "Far away\nThe ship is taking me far away"},
+		{Position: 6, Type: models.VerseTypeIntro},
+		{Position: 7, Text: "[Prologue]\nnot a marker"},
+	}
+	if got := parseVerses(text); !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseVerses:\n%+v\nwant:\n%+v", got, want)
+	}
+
+	if got := parseVerses(""); got == nil || len(got) != 0 {
+		t.Fatalf("parseVerses of an empty text: %#v", got)
+	}
+}
+
+func TestNormalizeVerses(t *testing.T) {
+	verses, err := normalizeVerses([]models.Verse{
+		{Position: 9, Type: " Verse ", Text: "Far away"},
+		{Type: "chorus", Text: "My life\nYou electrify my life", LineTimes: []int64{1000, 2500}},
+		{RepeatOf: 2, LineTimes: []int64{9000, 10500}},
+		{RepeatOf: 1, Type: "verse", Text: "Far away"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.Verse{
+		{Position: 1, Type: "verse", Text: "Far away"},
+		{Position: 2, Type: "chorus", Text: "My life\nYou electrify my life", LineTimes: []int64{1000, 2500}},
+		{Position: 3, Type: "chorus", RepeatOf: 2, Text: "My life\nYou electrify my life", LineTimes: []int64{9000, 10500}},
+		{Position: 4, Type: "verse", RepeatOf: 1, Text: "Far away"},
+	}
+	if !reflect.DeepEqual(verses, want) {
+		t.Fatalf("normalizeVerses:\n%+v\nwant:\n%+v", verses, want)
+	}
+
+	for name, bad := range map[string][]models.Verse{
+		"unknown type":         {{Type: "solo", Text: "x"}},
+		"repeat of itself":     {{RepeatOf: 1}},
+		"repeat of later":      {{RepeatOf: 2}, {Text: "x"}},
+		"repeat of a repeat":   {{Text: "x"}, {RepeatOf: 1}, {RepeatOf: 2}},
+		"repeat type mismatch": {{Type: "verse", Text: "x"}, {RepeatOf: 1, Type: "chorus"}},
+		"repeat text mismatch": {{Text: "x"}, {RepeatOf: 1, Text: "y"}},
+		"line times count":     {{Text: "a\nb", LineTimes: []int64{1}}},
+		"negative line time":   {{Text: "a", LineTimes: []int64{-1}}},
+	} {
+		if _, err := normalizeVerses(bad); !errors.Is(err, ErrInvalidVerses) {
+			t.Errorf("%s: %v, want ErrInvalidVerses", name, err)
+		}
+	}
+}
+
+func TestSetSongVerses(t *testing.T) {
+	s, store := newTestService(t, Options{})
+	ctx := context.Background()
+
+	id, err := store.SaveSongInfo("Muse", "Starlight", "2006-09-04", "https://example.com/starlight")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.SetSongVerses(ctx, uint(id), []models.Verse{{Type: "solo"}}); !errors.Is(err, ErrInvalidVerses) {
+		t.Fatalf("invalid verses: %v", err)
+	}
+
+	err = s.SetSongVerses(ctx, uint(id), []models.Verse{
+		{Type: models.VerseTypeChorus, Text: "My life\nYou electrify my life"},
+		{Text: "Far away"},
+		{RepeatOf: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := s.GetSongVerses(ctx, uint(id), 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Song != "Starlight" || len(resp.Verses) != 2 || resp.Verses[1].Position != 3 || resp.Verses[1].RepeatOf != 1 || resp.Verses[1].Type != models.VerseTypeChorus {
+		t.Fatalf("verses: %+v", resp)
+	}
+}
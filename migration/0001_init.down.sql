@@ -1 +1,2 @@
-DROP TABLE songs_info
\ No newline at end of file
+DROP TABLE IF EXISTS song_text;
+DROP TABLE IF EXISTS song_info;
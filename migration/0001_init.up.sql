@@ -12,7 +12,7 @@ CREATE TABLE IF NOT EXISTS song_info (
 CREATE TABLE IF NOT EXISTS song_text (
     id SERIAL PRIMARY KEY,
     song_id INT NOT NULL REFERENCES song_info(id) ON DELETE CASCADE,
-    verse TEXT NOT NULL,
+    verse TEXT NOT NULL
 );
 
 -- Индекс для быстрого поиска song_id по group_name + song
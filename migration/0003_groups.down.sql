@@ -0,0 +1,5 @@
+ALTER TABLE song_info DROP CONSTRAINT IF EXISTS song_info_group_id_song_key;
+ALTER TABLE song_info ADD CONSTRAINT song_info_group_name_song_key UNIQUE (group_name, song);
+
+ALTER TABLE song_info DROP COLUMN IF EXISTS group_id;
+DROP TABLE IF EXISTS groups;
@@ -0,0 +1,48 @@
+-- Группы (исполнители) как отдельная сущность
+CREATE TABLE IF NOT EXISTS groups (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    country VARCHAR(100) NOT NULL DEFAULT '',
+    formed_year INT,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- "Muse" и "muse" - одна и та же группа
+CREATE UNIQUE INDEX IF NOT EXISTS idx_groups_name_lower ON groups (LOWER(name));
+
+-- Заполняем группы из существующих песен, для каждого названия берем самое раннее написание
+INSERT INTO groups (name)
+SELECT DISTINCT ON (LOWER(group_name)) group_name
+FROM song_info
+ORDER BY LOWER(group_name), id
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE song_info ADD COLUMN IF NOT EXISTS group_id INT REFERENCES groups(id) ON DELETE RESTRICT;
+
+UPDATE song_info si SET group_id = g.id
+FROM groups g
+WHERE LOWER(g.name) = LOWER(si.group_name);
+
+ALTER TABLE song_info ALTER COLUMN group_id SET NOT NULL;
+
+-- Песня уникальна в пределах группы, а не написания ее названия
+ALTER TABLE song_info DROP CONSTRAINT IF EXISTS song_info_group_name_song_key;
+
+-- "Muse/Uprising" и "muse/Uprising" попали в одну группу: самая ранняя песня сохраняет
+-- название, к остальным добавляется их id
+UPDATE song_info si SET song = LEFT(si.song, 240) || ' (' || si.id || ')'
+WHERE EXISTS (
+    SELECT 1 FROM song_info earlier
+    WHERE earlier.group_id = si.group_id AND earlier.song = si.song AND earlier.id < si.id
+);
+
+-- Название группы в песнях - каноническое написание из groups
+UPDATE song_info si SET group_name = g.name
+FROM groups g
+WHERE g.id = si.group_id;
+
+ALTER TABLE song_info ADD CONSTRAINT song_info_group_id_song_key UNIQUE (group_id, song);
+
+CREATE INDEX IF NOT EXISTS idx_song_info_group_id ON song_info (group_id);
@@ -0,0 +1,22 @@
+-- Альбомы группы
+CREATE TABLE IF NOT EXISTS albums (
+    id SERIAL PRIMARY KEY,
+    group_id INT NOT NULL REFERENCES groups(id) ON DELETE RESTRICT,
+    title VARCHAR(255) NOT NULL,
+    release_date DATE,
+    album_type VARCHAR(10) NOT NULL DEFAULT 'LP' CHECK (album_type IN ('LP', 'EP', 'single')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (group_id, title)
+);
+
+-- Упорядоченный список треков альбома
+CREATE TABLE IF NOT EXISTS album_tracks (
+    album_id INT NOT NULL REFERENCES albums(id) ON DELETE CASCADE,
+    song_id INT NOT NULL REFERENCES song_info(id) ON DELETE CASCADE,
+    position INT NOT NULL CHECK (position > 0),
+    PRIMARY KEY (album_id, position),
+    UNIQUE (album_id, song_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_album_tracks_song_id ON album_tracks (song_id);
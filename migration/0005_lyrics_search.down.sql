@@ -0,0 +1,4 @@
+ALTER TABLE song_text
+    DROP COLUMN IF EXISTS tsv_russian,
+    DROP COLUMN IF EXISTS tsv_english,
+    DROP COLUMN IF EXISTS tsv_simple;
@@ -0,0 +1,10 @@
+-- Полнотекстовый поиск по куплетам. Колонки tsvector вычисляются самой БД
+-- при каждой вставке/изменении куплета, отдельно для каждой поддерживаемой конфигурации.
+ALTER TABLE song_text
+    ADD COLUMN IF NOT EXISTS tsv_simple tsvector GENERATED ALWAYS AS (to_tsvector('simple'::regconfig, verse)) STORED,
+    ADD COLUMN IF NOT EXISTS tsv_english tsvector GENERATED ALWAYS AS (to_tsvector('english'::regconfig, verse)) STORED,
+    ADD COLUMN IF NOT EXISTS tsv_russian tsvector GENERATED ALWAYS AS (to_tsvector('russian'::regconfig, verse)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_song_text_tsv_simple ON song_text USING GIN (tsv_simple);
+CREATE INDEX IF NOT EXISTS idx_song_text_tsv_english ON song_text USING GIN (tsv_english);
+CREATE INDEX IF NOT EXISTS idx_song_text_tsv_russian ON song_text USING GIN (tsv_russian);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_song_info_song_trgm;
+DROP INDEX IF EXISTS idx_song_info_group_name_trgm;
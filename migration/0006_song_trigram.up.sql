@@ -0,0 +1,5 @@
+-- Нечеткий поиск по названиям групп и песен (similarity, ILIKE)
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_song_info_group_name_trgm ON song_info USING GIN (group_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_song_info_song_trgm ON song_info USING GIN (song gin_trgm_ops);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS enrichment_jobs;
+
+-- Песни без даты выхода не пережили бы возврат ограничения NOT NULL
+DELETE FROM song_info WHERE release_date IS NULL;
+
+ALTER TABLE song_info
+    DROP COLUMN IF EXISTS enrichment_status,
+    ALTER COLUMN release_date SET NOT NULL;
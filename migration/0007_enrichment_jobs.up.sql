@@ -0,0 +1,22 @@
+-- Песня сохраняется сразу, а дата выхода, ссылка и текст заполняются фоновой задачей
+ALTER TABLE song_info
+    ALTER COLUMN release_date DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS enrichment_status VARCHAR(10) NOT NULL DEFAULT 'done'
+        CHECK (enrichment_status IN ('pending', 'done', 'failed'));
+
+-- Очередь задач обогащения песен данными внешнего API.
+-- run_at - время следующей попытки для queued и окончание аренды для running
+CREATE TABLE IF NOT EXISTS enrichment_jobs (
+    id SERIAL PRIMARY KEY,
+    song_id INT NOT NULL REFERENCES song_info(id) ON DELETE CASCADE,
+    status VARCHAR(10) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'done', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL CHECK (max_attempts > 0),
+    last_error TEXT NOT NULL DEFAULT '',
+    run_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_enrichment_jobs_due ON enrichment_jobs (run_at) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_enrichment_jobs_song_id ON enrichment_jobs (song_id);
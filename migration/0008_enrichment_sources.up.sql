@@ -0,0 +1,2 @@
+-- Какой источник данных дал каждое поле песни: {"release_date": "music-info", "text": "file", ...}
+ALTER TABLE enrichment_jobs ADD COLUMN sources JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,22 @@
+DROP VIEW IF EXISTS song_lines;
+DROP VIEW IF EXISTS song_verses;
+
+ALTER TABLE song_text
+    DROP CONSTRAINT IF EXISTS song_text_repeat_check,
+    DROP CONSTRAINT IF EXISTS song_text_repeat_of_fkey,
+    DROP CONSTRAINT IF EXISTS song_text_song_position_key;
+
+-- Повторы снова хранятся полным текстом, типы возвращаются метками в первой строке
+UPDATE song_text st
+SET verse = orig.verse, verse_type = orig.verse_type
+FROM song_text orig
+WHERE orig.song_id = st.song_id AND orig.position = st.repeat_of;
+
+UPDATE song_text
+SET verse = '[' || INITCAP(verse_type) || ']' || E'\n' || verse
+WHERE verse_type IS NOT NULL;
+
+ALTER TABLE song_text
+    DROP COLUMN IF EXISTS repeat_of,
+    DROP COLUMN IF EXISTS verse_type,
+    DROP COLUMN IF EXISTS position;
@@ -0,0 +1,59 @@
+-- Явный порядок куплетов, тип куплета и повторы. Повтор хранит только ссылку
+-- на позицию исходного куплета той же песни, его текст и тип берутся из исходного.
+ALTER TABLE song_text
+    ADD COLUMN position INT,
+    ADD COLUMN verse_type VARCHAR(10) CHECK (verse_type IN ('verse', 'chorus', 'bridge', 'intro', 'outro')),
+    ADD COLUMN repeat_of INT;
+
+UPDATE song_text st
+SET position = numbered.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY song_id ORDER BY id) AS position
+    FROM song_text
+) numbered
+WHERE numbered.id = st.id;
+
+-- Метки вида [Chorus] или [Verse 2] в первой строке куплета переносятся в verse_type
+UPDATE song_text
+SET verse_type = CASE LOWER(SUBSTRING(verse FROM '^\[\s*([A-Za-z]+)'))
+        WHEN 'refrain' THEN 'chorus'
+        ELSE LOWER(SUBSTRING(verse FROM '^\[\s*([A-Za-z]+)'))
+    END,
+    verse = REGEXP_REPLACE(verse, '^\[[^\]\n]*\][ \t]*(\n|$)', '')
+WHERE verse ~* '^\[\s*(verse|chorus|refrain|bridge|intro|outro)\M[^\]\n]*\][ \t]*(\n|$)';
+
+-- Дословные повторы куплета заменяются ссылкой на первое вхождение
+UPDATE song_text st
+SET repeat_of = first.position, verse = '', verse_type = NULL
+FROM song_text first
+WHERE first.song_id = st.song_id
+    AND first.position < st.position
+    AND first.verse = st.verse
+    AND first.verse_type IS NOT DISTINCT FROM st.verse_type
+    AND st.verse <> ''
+    AND first.position = (
+        SELECT MIN(o.position) FROM song_text o
+        WHERE o.song_id = st.song_id AND o.verse = st.verse AND o.verse_type IS NOT DISTINCT FROM st.verse_type
+    );
+
+ALTER TABLE song_text
+    ALTER COLUMN position SET NOT NULL,
+    ADD CONSTRAINT song_text_song_position_key UNIQUE (song_id, position),
+    ADD CONSTRAINT song_text_repeat_of_fkey FOREIGN KEY (song_id, repeat_of)
+        REFERENCES song_text (song_id, position) ON DELETE CASCADE,
+    ADD CONSTRAINT song_text_repeat_check CHECK (repeat_of IS NULL OR (repeat_of < position AND verse = '' AND verse_type IS NULL));
+
+-- Куплеты с подставленными текстом и типом повторов
+CREATE VIEW song_verses AS
+SELECT st.song_id, st.position, COALESCE(orig.verse_type, st.verse_type) AS verse_type,
+    st.repeat_of, COALESCE(orig.verse, st.verse) AS verse
+FROM song_text st
+LEFT JOIN song_text orig ON orig.song_id = st.song_id AND orig.position = st.repeat_of;
+
+-- Строки куплетов: line - номер строки внутри куплета, line_number - сквозной номер в песне
+CREATE VIEW song_lines AS
+SELECT sv.song_id, sv.position, l.line,
+    ROW_NUMBER() OVER (PARTITION BY sv.song_id ORDER BY sv.position, l.line) AS line_number,
+    l.text
+FROM song_verses sv
+CROSS JOIN LATERAL UNNEST(STRING_TO_ARRAY(sv.verse, E'\n')) WITH ORDINALITY AS l(text, line);
@@ -0,0 +1,17 @@
+DROP VIEW IF EXISTS song_lines;
+DROP VIEW IF EXISTS song_verses;
+
+CREATE VIEW song_verses AS
+SELECT st.song_id, st.position, COALESCE(orig.verse_type, st.verse_type) AS verse_type,
+    st.repeat_of, COALESCE(orig.verse, st.verse) AS verse
+FROM song_text st
+LEFT JOIN song_text orig ON orig.song_id = st.song_id AND orig.position = st.repeat_of;
+
+CREATE VIEW song_lines AS
+SELECT sv.song_id, sv.position, l.line,
+    ROW_NUMBER() OVER (PARTITION BY sv.song_id ORDER BY sv.position, l.line) AS line_number,
+    l.text
+FROM song_verses sv
+CROSS JOIN LATERAL UNNEST(STRING_TO_ARRAY(sv.verse, E'\n')) WITH ORDINALITY AS l(text, line);
+
+ALTER TABLE song_text DROP COLUMN IF EXISTS line_times;
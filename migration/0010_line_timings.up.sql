@@ -0,0 +1,11 @@
+-- Время начала каждой строки куплета в миллисекундах от начала песни (NULL - текст не синхронизирован).
+-- Хранится у каждой позиции отдельно: повтор припева звучит в другое время, чем исходный.
+ALTER TABLE song_text
+    ADD COLUMN line_times INT[],
+    ADD CONSTRAINT song_text_line_times_check CHECK (line_times IS NULL OR 0 <= ALL (line_times));
+
+CREATE OR REPLACE VIEW song_verses AS
+SELECT st.song_id, st.position, COALESCE(orig.verse_type, st.verse_type) AS verse_type,
+    st.repeat_of, COALESCE(orig.verse, st.verse) AS verse, st.line_times
+FROM song_text st
+LEFT JOIN song_text orig ON orig.song_id = st.song_id AND orig.position = st.repeat_of;
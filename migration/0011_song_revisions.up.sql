@@ -0,0 +1,37 @@
+-- Неизменяемые снимки данных и текста песни после каждого изменения.
+-- Куплеты хранятся целиком (с подставленным текстом повторов), чтобы ревизия
+-- не зависела от текущего состояния song_text.
+CREATE TABLE IF NOT EXISTS song_revisions (
+    id SERIAL PRIMARY KEY,
+    song_id INT NOT NULL REFERENCES song_info(id) ON DELETE CASCADE,
+    rev INT NOT NULL,
+    author VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    group_name VARCHAR(255) NOT NULL,
+    song VARCHAR(255) NOT NULL,
+    release_date DATE,
+    link VARCHAR(500) NOT NULL,
+    verses JSONB NOT NULL DEFAULT '[]',
+    UNIQUE (song_id, rev)
+);
+
+CREATE OR REPLACE FUNCTION song_revisions_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'song revisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER song_revisions_no_update
+    BEFORE UPDATE ON song_revisions
+    FOR EACH ROW EXECUTE FUNCTION song_revisions_immutable();
+
+-- Первая ревизия для уже существующих песен
+INSERT INTO song_revisions (song_id, rev, author, created_at, group_name, song, release_date, link, verses)
+SELECT si.id, 1, 'migration', si.updated_at, si.group_name, si.song, si.release_date, si.link,
+    COALESCE((
+        SELECT JSONB_AGG(JSONB_BUILD_OBJECT(
+            'position', sv.position, 'type', COALESCE(sv.verse_type, ''), 'repeat_of', COALESCE(sv.repeat_of, 0),
+            'text', sv.verse, 'line_times', sv.line_times) ORDER BY sv.position)
+        FROM song_verses sv WHERE sv.song_id = si.id
+    ), '[]')
+FROM song_info si;
@@ -0,0 +1,8 @@
+-- Песни из корзины удаляются окончательно, иначе они нарушили бы уникальность
+DELETE FROM song_info WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_song_info_deleted_at;
+DROP INDEX IF EXISTS idx_song_info_group_song_live;
+ALTER TABLE song_info ADD CONSTRAINT song_info_group_id_song_key UNIQUE (group_id, song);
+
+ALTER TABLE song_info DROP COLUMN IF EXISTS deleted_at;
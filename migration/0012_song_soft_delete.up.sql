@@ -0,0 +1,9 @@
+-- Удаленные песни остаются в корзине до окончательного удаления (deleted_at IS NOT NULL)
+ALTER TABLE song_info ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Песня в корзине не мешает создать новую с тем же названием
+ALTER TABLE song_info DROP CONSTRAINT IF EXISTS song_info_group_id_song_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_song_info_group_song_live ON song_info (group_id, song) WHERE deleted_at IS NULL;
+
+-- Для списка корзины и очистки по сроку хранения
+CREATE INDEX IF NOT EXISTS idx_song_info_deleted_at ON song_info (deleted_at) WHERE deleted_at IS NOT NULL;
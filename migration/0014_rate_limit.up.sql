@@ -0,0 +1,9 @@
+-- Корзины токенов ограничителя запросов, общие для всех реплик сервиса.
+-- UNLOGGED: после сбоя базы корзины можно потерять, клиенты просто начнут с полного бюджета.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key VARCHAR(512) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
@@ -0,0 +1 @@
+ALTER TABLE song_revisions DROP COLUMN IF EXISTS group_id;
@@ -0,0 +1,11 @@
+-- Ревизия помнит группу песни, а не только ее название: откат к ревизии, записанной
+-- до переименования группы, оставляет песню в той же группе
+ALTER TABLE song_revisions ADD COLUMN IF NOT EXISTS group_id INT;
+
+-- Для старых ревизий группа находится по названию; ревизии с уже переименованной
+-- группой остаются без group_id, и откат к ним по-прежнему идет по названию
+ALTER TABLE song_revisions DISABLE TRIGGER song_revisions_no_update;
+UPDATE song_revisions r SET group_id = g.id
+FROM groups g
+WHERE LOWER(g.name) = LOWER(r.group_name);
+ALTER TABLE song_revisions ENABLE TRIGGER song_revisions_no_update;
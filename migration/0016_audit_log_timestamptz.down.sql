@@ -0,0 +1 @@
+ALTER TABLE audit_log ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE current_setting('TimeZone');
@@ -0,0 +1,3 @@
+-- Время записей аудита хранится с часовым поясом, чтобы фильтры from/to с любым смещением
+-- сравнивались корректно. Прежние значения записаны в поясе сессии базы.
+ALTER TABLE audit_log ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE current_setting('TimeZone');
@@ -0,0 +1,3 @@
+ALTER TABLE rate_limit_buckets DROP COLUMN IF EXISTS allowed;
+DELETE FROM rate_limit_buckets WHERE LENGTH(key) > 512;
+ALTER TABLE rate_limit_buckets ALTER COLUMN key TYPE VARCHAR(512);
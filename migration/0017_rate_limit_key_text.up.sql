@@ -0,0 +1,4 @@
+-- Ключ корзины - класс и имя клиента, а имя из JWT может быть любой длины.
+-- allowed - был ли в корзине целый токен при последнем списании: его возвращает upsert в Take.
+ALTER TABLE rate_limit_buckets ALTER COLUMN key TYPE TEXT;
+ALTER TABLE rate_limit_buckets ADD COLUMN IF NOT EXISTS allowed BOOLEAN NOT NULL DEFAULT TRUE;
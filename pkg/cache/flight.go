@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+// Group объединяет одновременные вызовы с одинаковым ключом: функция выполняется
+// один раз, остальные вызывающие получают ее результат. Нулевое значение готово к работе.
+type Group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*flightCall[V]
+}
+
+type flightCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// Do выполняет fn, если вызов с таким ключом еще не идет, иначе ждет уже идущий.
+// shared сообщает, что результат получен чужим вызовом. Отмена ctx прекращает
+// ожидание только для этого вызывающего; сам fn не отменяется, поэтому он не должен
+// зависеть от контекста отдельного вызывающего.
+func (g *Group[K, V]) Do(ctx context.Context, key K, fn func() (V, error)) (value V, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*flightCall[V])
+	}
+
+	c, ok := g.calls[key]
+	if !ok {
+		c = &flightCall[V]{done: make(chan struct{})}
+		g.calls[key] = c
+		go g.run(key, c, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, c.err, ok
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err(), ok
+	}
+}
+
+func (g *Group[K, V]) run(key K, c *flightCall[V], fn func() (V, error)) {
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.value, c.err = fn()
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupCoalescesCalls(t *testing.T) {
+	var g Group[string, int]
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	const callers = 10
+	var wg sync.WaitGroup
+	var sharedCount atomic.Int32
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err, shared := g.Do(context.Background(), "song", func() (int, error) {
+				calls.Add(1)
+				<-release
+				return 42, nil
+			})
+			if err != nil || v != 42 {
+				t.Errorf("Do = %d, %v", v, err)
+			}
+			if shared {
+				sharedCount.Add(1)
+			}
+		}()
+	}
+
+	// Даем всем вызывающим подключиться к первому вызову
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 || sharedCount.Load() != callers-1 {
+		t.Fatalf("%d calls, %d shared results", calls.Load(), sharedCount.Load())
+	}
+
+	// После завершения вызова ключ освобождается
+	v, err, shared := g.Do(context.Background(), "song", func() (int, error) { return 7, errors.New("boom") })
+	if v != 7 || err == nil || shared {
+		t.Fatalf("second Do = %d, %v, %v", v, err, shared)
+	}
+}
+
+func TestGroupCallerCancel(t *testing.T) {
+	var g Group[string, int]
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		v, err, _ := g.Do(context.Background(), "song", func() (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		if err != nil || v != 1 {
+			t.Errorf("first caller: %d, %v", v, err)
+		}
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err, _ := g.Do(ctx, "song", func() (int, error) { return 2, nil }); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled caller: %v", err)
+	}
+
+	// Отмена одного вызывающего не прерывает общий вызов
+	close(release)
+	<-done
+}
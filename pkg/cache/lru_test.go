@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEviction(t *testing.T) {
+	c := NewLRU[string, int](2)
+
+	c.Set("a", 1, time.Minute)
+	c.Set("b", 2, time.Minute)
+	// Обращение к a делает вытесняемой b
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %d, %v", v, ok)
+	}
+	c.Set("c", 3, time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("least recently used entry was not evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if v, ok := c.Get(key); !ok || v != want {
+			t.Fatalf("Get(%s) = %d, %v", key, v, ok)
+		}
+	}
+
+	// Перезапись не увеличивает кэш
+	c.Set("a", 10, time.Minute)
+	if v, _ := c.Get("a"); v != 10 || c.Len() != 2 {
+		t.Fatalf("overwritten a = %d, len %d", v, c.Len())
+	}
+
+	c.Delete("a")
+	c.Delete("missing")
+	if _, ok := c.Get("a"); ok || c.Len() != 1 {
+		t.Fatalf("deleted entry is still cached, len %d", c.Len())
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	c := NewLRU[string, int](10)
+
+	c.Set("short", 1, 10*time.Millisecond)
+	c.Set("long", 2, time.Minute)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := c.Get("short"); ok {
+		t.Fatal("expired entry was returned")
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expired entry was not removed on access: len %d", c.Len())
+	}
+	if v, ok := c.Get("long"); !ok || v != 2 {
+		t.Fatalf("Get(long) = %d, %v", v, ok)
+	}
+
+	// Перезапись продлевает время жизни
+	c.Set("short", 3, time.Minute)
+	if v, ok := c.Get("short"); !ok || v != 3 {
+		t.Fatalf("Get(short) after Set = %d, %v", v, ok)
+	}
+}
+
+func TestLRUMinimumSize(t *testing.T) {
+	c := NewLRU[int, int](0)
+	c.Set(1, 1, time.Minute)
+	c.Set(2, 2, time.Minute)
+
+	if _, ok := c.Get(2); !ok || c.Len() != 1 {
+		t.Fatalf("size 0 cache: len %d", c.Len())
+	}
+}
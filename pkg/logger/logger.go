@@ -1,14 +1,17 @@
 package logger
 
 import (
+	"context"
 	"os"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
 var Log *logrus.Logger
 
-func InitLogger(cfgLogLevel, cfgLogOutput, cfgLogFilePath string) *logrus.Logger {
+// InitLogger создает логгер; cfgLogFormat - text или json (одна JSON-запись на строку)
+func InitLogger(cfgLogLevel, cfgLogOutput, cfgLogFilePath, cfgLogFormat string) *logrus.Logger {
 	logger := logrus.New()
 
 	switch cfgLogLevel {
@@ -31,9 +34,32 @@ func InitLogger(cfgLogLevel, cfgLogOutput, cfgLogFilePath string) *logrus.Logger
 		logger.SetOutput(os.Stdout)
 	}
 
-	logger.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp: true,
-	})
+	switch cfgLogFormat {
+	case "json":
+		logger.SetFormatter(&logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+		})
+	default:
+		logger.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp: true,
+		})
+	}
 
 	return logger
 }
+
+type entryKey struct{}
+
+// WithEntry возвращает контекст с записью лога, через которую пишутся все логи в его рамках,
+// например с идентификатором запроса
+func WithEntry(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// FromContext возвращает запись лога из контекста, а если ее нет - запись без полей логгера fallback
+func FromContext(ctx context.Context, fallback *logrus.Logger) *logrus.Entry {
+	if entry, ok := ctx.Value(entryKey{}).(*logrus.Entry); ok {
+		return entry
+	}
+	return logrus.NewEntry(fallback)
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestInitLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	log := InitLogger("debug", "file", path, "json")
+	if log.GetLevel() != logrus.DebugLevel {
+		t.Fatalf("level %s", log.GetLevel())
+	}
+	if _, ok := log.Formatter.(*logrus.JSONFormatter); !ok {
+		t.Fatalf("formatter %T", log.Formatter)
+	}
+
+	log = InitLogger("trace", "stdout", "", "text")
+	if log.GetLevel() != logrus.InfoLevel {
+		t.Fatalf("unknown level became %s", log.GetLevel())
+	}
+	if _, ok := log.Formatter.(*logrus.TextFormatter); !ok {
+		t.Fatalf("formatter %T", log.Formatter)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	base := logrus.New()
+	base.SetOutput(&buf)
+	base.SetFormatter(&logrus.JSONFormatter{})
+
+	// Без записи в контексте пишется запись без полей
+	FromContext(context.Background(), base).Info("plain")
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil || line["msg"] != "plain" || line["request_id"] != nil {
+		t.Fatalf("plain line %s: %v", buf.Bytes(), err)
+	}
+
+	buf.Reset()
+	ctx := WithEntry(context.Background(), base.WithField("request_id", "req-1"))
+	FromContext(ctx, nil).Info("with request")
+	line = nil
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil || line["request_id"] != "req-1" {
+		t.Fatalf("line with request %s: %v", buf.Bytes(), err)
+	}
+}